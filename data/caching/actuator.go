@@ -0,0 +1,81 @@
+package caching
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hadroncorp/geck/actuator"
+	"github.com/hadroncorp/geck/internal/reflection"
+)
+
+const actuatorProbeKey = "geck.caching.actuator.probe"
+
+// Actuator is the actuator.Actuator implementation for Cache instances.
+//
+// A probe entry is written (and read back) to verify cache availability. If Cache implements StatsProvider, the
+// probe entry is not read back, so hit and miss counters are not altered by probes, and Stats are reported as state
+// details.
+type Actuator struct {
+	Cache Cache
+}
+
+var _ actuator.Actuator = (*Actuator)(nil)
+
+// NewActuator allocates a new Actuator instance.
+func NewActuator(cache Cache) Actuator {
+	return Actuator{
+		Cache: cache,
+	}
+}
+
+// State returns the current state of the target component. Returns error if communication with component
+// has failed (not the same as State.Status).
+func (a Actuator) State(ctx context.Context) (actuator.State, error) {
+	details := map[string]any{
+		"cache_type": reflection.NewTypeFullNameAny(a.Cache),
+	}
+	provider, isStatsProvider := a.Cache.(StatsProvider)
+	if err := a.probe(ctx, !isStatsProvider); err != nil {
+		return actuator.State{
+			Status:      actuator.StatusDown,
+			Description: err.Error(),
+			Details:     details,
+		}, nil
+	}
+	if isStatsProvider {
+		if stats, err := provider.Stats(ctx); err == nil {
+			details["hits"] = stats.Hits
+			details["misses"] = stats.Misses
+			details["delete_hits"] = stats.DeleteHits
+			details["delete_misses"] = stats.DeleteMisses
+			details["collisions"] = stats.Collisions
+			details["evictions"] = stats.Evictions
+			details["entry_count"] = stats.EntryCount
+			details["capacity_bytes"] = stats.CapacityBytes
+		}
+	}
+	return actuator.State{
+		Status:  actuator.StatusUp,
+		Details: details,
+	}, nil
+}
+
+// probe writes and deletes a probe entry. The entry is read back before deletion if readBack is true.
+func (a Actuator) probe(ctx context.Context, readBack bool) error {
+	probe := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err := a.Cache.Set(ctx, actuatorProbeKey, probe); err != nil {
+		return err
+	}
+	if readBack {
+		got, err := a.Cache.Get(ctx, actuatorProbeKey)
+		if err != nil {
+			return err
+		} else if !bytes.Equal(probe, got) {
+			return errors.New("cache probe entry mismatch")
+		}
+	}
+	return a.Cache.Delete(ctx, actuatorProbeKey)
+}
@@ -0,0 +1,51 @@
+package caching_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/actuator"
+	"github.com/hadroncorp/geck/data/caching"
+)
+
+func TestActuator_State(t *testing.T) {
+	ctx := context.Background()
+	cache := newCacheEmbedded(t, caching.BigCacheConfig{})
+	require.NoError(t, cache.Set(ctx, "foo", []byte("bar")))
+	_, _ = cache.Get(ctx, "foo")
+	_, _ = cache.Get(ctx, "baz")
+
+	state, err := caching.NewActuator(cache).State(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actuator.StatusUp, state.Status)
+	details, ok := state.Details.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), details["entry_count"])
+	assert.Equal(t, int64(1), details["hits"])
+	assert.Equal(t, int64(1), details["misses"])
+
+	// probes do not alter hit and miss counters
+	state, err = caching.NewActuator(cache).State(ctx)
+	require.NoError(t, err)
+	details, ok = state.Details.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), details["hits"])
+	assert.Equal(t, int64(1), details["misses"])
+
+	state, err = caching.NewActuator(failingCache{CacheEmbedded: cache}).State(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actuator.StatusDown, state.Status)
+	assert.Equal(t, "cache unavailable", state.Description)
+}
+
+type failingCache struct {
+	caching.CacheEmbedded
+}
+
+func (c failingCache) Set(_ context.Context, _ string, _ []byte) error {
+	return errors.New("cache unavailable")
+}
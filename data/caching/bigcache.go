@@ -7,8 +7,13 @@ import (
 	"go.uber.org/fx"
 )
 
-func NewBigCache(lifecycle fx.Lifecycle, cfg BigCacheConfig) (*bigcache.BigCache, error) {
-	bc, err := bigcache.New(context.Background(), bigcache.DefaultConfig(cfg.ItemTTL))
+func NewBigCache(lifecycle fx.Lifecycle, cfg BigCacheConfig, evictions *BigCacheEvictions) (*bigcache.BigCache, error) {
+	bcCfg := bigcache.DefaultConfig(cfg.ItemTTL)
+	if evictions != nil {
+		bcCfg.OnRemoveWithReason = evictions.onRemove
+		bcCfg = bcCfg.OnRemoveFilterSet(bigcache.Expired, bigcache.NoSpace)
+	}
+	bc, err := bigcache.New(context.Background(), bcCfg)
 	if err != nil {
 		return nil, err
 	}
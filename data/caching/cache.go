@@ -8,9 +8,29 @@ type Cache interface {
 	Set(ctx context.Context, key string, value []byte) error
 	SetMany(ctx context.Context, keyValues map[string][]byte) error
 	Append(ctx context.Context, key string, value []byte) error
+	// Add appends value as a new item of the list stored in key. Values are stored as opaque bytes, so they might
+	// contain any binary data.
 	Add(ctx context.Context, key string, value []byte) error
+	// List retrieves all items of the list stored in key, from the oldest to the newest.
 	List(ctx context.Context, key string) ([][]byte, error)
+	// RemoveFromList removes every item equal to value from the list stored in key.
+	RemoveFromList(ctx context.Context, key string, value []byte) error
+	// ListLength retrieves the number of items of the list stored in key.
+	ListLength(ctx context.Context, key string) (int, error)
 	Get(ctx context.Context, key string) ([]byte, error)
 	Delete(ctx context.Context, key string) error
 	DeleteMany(ctx context.Context, keys []string) error
 }
+
+// AtomicCache is a Cache supporting atomic conditional writes. Required by components needing mutual
+// exclusion (e.g. distributed locks).
+type AtomicCache interface {
+	Cache
+	// SetIfAbsent stores value in key only if key does not exist. Returns true if value was stored.
+	SetIfAbsent(ctx context.Context, key string, value []byte) (bool, error)
+	// CompareAndSwap replaces the value stored in key with newValue only if the current value equals oldValue.
+	// Returns true if value was replaced.
+	CompareAndSwap(ctx context.Context, key string, oldValue, newValue []byte) (bool, error)
+	// CompareAndDelete removes key only if its current value equals oldValue. Returns true if key was removed.
+	CompareAndDelete(ctx context.Context, key string, oldValue []byte) (bool, error)
+}
@@ -1,28 +1,60 @@
 package caching
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"errors"
+	"sync"
 
 	"github.com/allegro/bigcache/v3"
 )
 
-const (
-	embeddedAppendValueSeparator byte = '\n'
-)
-
+// CacheEmbedded is the in-process Cache implementation using bigcache.
+//
+// Lists are stored using a length-prefixed (framed) encoding, so items might contain any binary data. Lists written
+// by previous versions (newline-separated) are still readable and get migrated to the framed encoding on their
+// next write (or explicitly through MigrateList).
 type CacheEmbedded struct {
-	DB *bigcache.BigCache
+	DB     *bigcache.BigCache
+	Config BigCacheConfig
+	// Evictions tracks entries evicted by DB. Optional.
+	Evictions *BigCacheEvictions
+
+	// writeMu serializes read-modify-write operations (e.g. lists, conditional writes) as bigcache has no
+	// compare-and-swap primitive. Shared by copies of the instance; falls back to cacheEmbeddedWriteMu if nil.
+	writeMu *sync.Mutex
 }
 
-var _ Cache = (*CacheEmbedded)(nil)
+// cacheEmbeddedWriteMu serializes read-modify-write operations of CacheEmbedded instances not allocated through
+// NewCacheEmbedded (e.g. zero values).
+var cacheEmbeddedWriteMu sync.Mutex
 
-func NewCacheEmbedded(db *bigcache.BigCache) CacheEmbedded {
+var (
+	_ Cache         = (*CacheEmbedded)(nil)
+	_ AtomicCache   = (*CacheEmbedded)(nil)
+	_ StatsProvider = (*CacheEmbedded)(nil)
+)
+
+// NewCacheEmbedded allocates a new CacheEmbedded instance.
+//
+// The given BigCacheEvictions (if any) MUST be the same instance used to allocate db (see NewBigCache).
+func NewCacheEmbedded(db *bigcache.BigCache, cfg BigCacheConfig, evictions *BigCacheEvictions) CacheEmbedded {
 	return CacheEmbedded{
-		DB: db,
+		DB:        db,
+		Config:    cfg,
+		Evictions: evictions,
+		writeMu:   &sync.Mutex{},
+	}
+}
+
+// lockWrites acquires the mutex serializing read-modify-write operations.
+func (m CacheEmbedded) lockWrites() *sync.Mutex {
+	mu := m.writeMu
+	if mu == nil {
+		mu = &cacheEmbeddedWriteMu
 	}
+	mu.Lock()
+	return mu
 }
 
 func (m CacheEmbedded) Set(_ context.Context, key string, value []byte) error {
@@ -55,29 +87,136 @@ func (m CacheEmbedded) Append(_ context.Context, key string, value []byte) error
 }
 
 func (m CacheEmbedded) Add(_ context.Context, key string, value []byte) error {
-	formattedVal := make([]byte, 0, 1+len(value))
-	formattedVal = append(formattedVal, embeddedAppendValueSeparator)
-	formattedVal = append(formattedVal, value...)
-	return m.DB.Append(key, formattedVal)
+	mu := m.lockWrites()
+	defer mu.Unlock()
+
+	current, err := m.DB.Get(key)
+	if err != nil && errors.Is(err, bigcache.ErrEntryNotFound) {
+		return m.DB.Set(key, encodeListItem(value))
+	} else if err != nil {
+		return err
+	}
+
+	if !isLegacyList(current) && !m.isListCapped() {
+		// framed entries are self-contained, no need to re-encode the whole list
+		return m.DB.Append(key, encodeListItem(value))
+	}
+
+	items, err := decodeList(current)
+	if err != nil {
+		return err
+	}
+	items = append(items, value)
+	return m.DB.Set(key, encodeList(trimList(items, m.Config.ListMaxItems, m.Config.ListMaxBytes)))
 }
 
 func (m CacheEmbedded) List(_ context.Context, key string) ([][]byte, error) {
 	listBytes, err := m.DB.Get(key)
 	if err != nil {
 		return nil, err
-	} else if len(listBytes) <= 1 {
-		return nil, nil
 	}
+	return decodeList(listBytes)
+}
 
-	// remove first item separator
-	listBytes = listBytes[1:]
-	out := make([][]byte, 0)
-	scanner := bufio.NewScanner(bytes.NewReader(listBytes))
-	for scanner.Scan() {
-		item := scanner.Bytes()
-		out = append(out, item)
+func (m CacheEmbedded) RemoveFromList(_ context.Context, key string, value []byte) error {
+	mu := m.lockWrites()
+	defer mu.Unlock()
+
+	current, err := m.DB.Get(key)
+	if err != nil {
+		return err
 	}
-	return out, nil
+	items, err := decodeList(current)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([][]byte, 0, len(items))
+	for _, item := range items {
+		if !bytes.Equal(item, value) {
+			remaining = append(remaining, item)
+		}
+	}
+	if len(remaining) == 0 {
+		return m.DB.Delete(key)
+	} else if len(remaining) == len(items) && !isLegacyList(current) {
+		return nil
+	}
+	return m.DB.Set(key, encodeList(remaining))
+}
+
+func (m CacheEmbedded) ListLength(_ context.Context, key string) (int, error) {
+	listBytes, err := m.DB.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	items, err := decodeList(listBytes)
+	if err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
+// MigrateList rewrites the list stored in key using the framed encoding if it was written using the legacy
+// (newline-separated) encoding. Does nothing if the list is already framed.
+func (m CacheEmbedded) MigrateList(_ context.Context, key string) error {
+	mu := m.lockWrites()
+	defer mu.Unlock()
+
+	current, err := m.DB.Get(key)
+	if err != nil {
+		return err
+	} else if !isLegacyList(current) {
+		return nil
+	}
+	return m.DB.Set(key, encodeList(decodeLegacyList(current)))
+}
+
+func (m CacheEmbedded) SetIfAbsent(_ context.Context, key string, value []byte) (bool, error) {
+	mu := m.lockWrites()
+	defer mu.Unlock()
+
+	_, err := m.DB.Get(key)
+	if err == nil {
+		return false, nil
+	} else if !errors.Is(err, bigcache.ErrEntryNotFound) {
+		return false, err
+	}
+	return true, m.DB.Set(key, value)
+}
+
+func (m CacheEmbedded) CompareAndSwap(_ context.Context, key string, oldValue, newValue []byte) (bool, error) {
+	mu := m.lockWrites()
+	defer mu.Unlock()
+
+	current, err := m.DB.Get(key)
+	if err != nil && errors.Is(err, bigcache.ErrEntryNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else if !bytes.Equal(current, oldValue) {
+		return false, nil
+	}
+	return true, m.DB.Set(key, newValue)
+}
+
+func (m CacheEmbedded) CompareAndDelete(_ context.Context, key string, oldValue []byte) (bool, error) {
+	mu := m.lockWrites()
+	defer mu.Unlock()
+
+	current, err := m.DB.Get(key)
+	if err != nil && errors.Is(err, bigcache.ErrEntryNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	} else if !bytes.Equal(current, oldValue) {
+		return false, nil
+	}
+	return true, m.DB.Delete(key)
+}
+
+func (m CacheEmbedded) isListCapped() bool {
+	return m.Config.ListMaxItems > 0 || m.Config.ListMaxBytes > 0
 }
 
 func (m CacheEmbedded) Get(_ context.Context, key string) ([]byte, error) {
@@ -98,3 +237,22 @@ func (m CacheEmbedded) DeleteMany(_ context.Context, keys []string) error {
 	}
 	return errors.Join(errs...)
 }
+
+// Stats retrieves current cache statistics.
+func (m CacheEmbedded) Stats(_ context.Context) (Stats, error) {
+	stats := m.DB.Stats()
+	var evictions int64
+	if m.Evictions != nil {
+		evictions = m.Evictions.Count()
+	}
+	return Stats{
+		Hits:          stats.Hits,
+		Misses:        stats.Misses,
+		DeleteHits:    stats.DelHits,
+		DeleteMisses:  stats.DelMisses,
+		Collisions:    stats.Collisions,
+		Evictions:     evictions,
+		EntryCount:    int64(m.DB.Len()),
+		CapacityBytes: int64(m.DB.Capacity()),
+	}, nil
+}
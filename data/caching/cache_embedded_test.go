@@ -1,11 +1,16 @@
 package caching_test
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
 
 	"github.com/allegro/bigcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/caching"
 )
 
 func TestCacheEmbedded_Delete(t *testing.T) {
@@ -25,3 +30,112 @@ func TestCacheEmbedded_Delete(t *testing.T) {
 	out, _ := db.Get("criteria_hash")
 	t.Log(string(out))
 }
+
+func newCacheEmbedded(t *testing.T, cfg caching.BigCacheConfig) caching.CacheEmbedded {
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return caching.NewCacheEmbedded(db, cfg, nil)
+}
+
+func TestCacheEmbedded_List(t *testing.T) {
+	ctx := context.Background()
+	cache := newCacheEmbedded(t, caching.BigCacheConfig{})
+
+	bigItem := bytes.Repeat([]byte("a"), 128*1024) // bigger than bufio.Scanner default buffer
+	items := [][]byte{
+		[]byte("foo"),
+		[]byte("bar\nbaz"),
+		{0x00, 0x01, '\n', 0xff},
+		{},
+		bigItem,
+	}
+	for _, item := range items {
+		require.NoError(t, cache.Add(ctx, "list", item))
+	}
+
+	out, err := cache.List(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, items, out)
+
+	length, err := cache.ListLength(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, len(items), length)
+
+	require.NoError(t, cache.RemoveFromList(ctx, "list", []byte("bar\nbaz")))
+	out, err = cache.List(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{items[0], items[2], items[3], items[4]}, out)
+
+	_, err = cache.List(ctx, "missing")
+	assert.ErrorIs(t, err, bigcache.ErrEntryNotFound)
+}
+
+func TestCacheEmbedded_ListCapped(t *testing.T) {
+	ctx := context.Background()
+	cache := newCacheEmbedded(t, caching.BigCacheConfig{
+		ListMaxItems: 3,
+		ListMaxBytes: 8,
+	})
+
+	for _, item := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, cache.Add(ctx, "list", []byte(item)))
+	}
+	out, err := cache.List(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("b"), []byte("c"), []byte("d")}, out)
+
+	require.NoError(t, cache.Add(ctx, "list", []byte("eeeeeee")))
+	out, err = cache.List(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("d"), []byte("eeeeeee")}, out)
+}
+
+func TestCacheEmbedded_ListLegacy(t *testing.T) {
+	ctx := context.Background()
+	cache := newCacheEmbedded(t, caching.BigCacheConfig{})
+
+	// lists written by previous versions
+	require.NoError(t, cache.Set(ctx, "list", []byte("\nfoo\nbar")))
+	out, err := cache.List(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar")}, out)
+
+	require.NoError(t, cache.Add(ctx, "list", []byte("baz\n")))
+	out, err = cache.List(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar"), []byte("baz\n")}, out)
+
+	require.NoError(t, cache.Set(ctx, "other_list", []byte("\nfoo")))
+	require.NoError(t, cache.MigrateList(ctx, "other_list"))
+	raw, err := cache.Get(ctx, "other_list")
+	require.NoError(t, err)
+	assert.NotEqual(t, byte('\n'), raw[0])
+	out, err = cache.List(ctx, "other_list")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("foo")}, out)
+}
+
+func TestCacheEmbedded_ZeroValue(t *testing.T) {
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	ctx := context.Background()
+	cache := caching.CacheEmbedded{DB: db}
+	require.NoError(t, cache.Add(ctx, "list", []byte("foo")))
+	require.NoError(t, cache.Add(ctx, "list", []byte("bar")))
+	require.NoError(t, cache.RemoveFromList(ctx, "list", []byte("foo")))
+	length, err := cache.ListLength(ctx, "list")
+	require.NoError(t, err)
+	assert.Equal(t, 1, length)
+	ok, err := cache.SetIfAbsent(ctx, "key", []byte("bar"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
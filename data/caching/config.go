@@ -4,4 +4,10 @@ import "time"
 
 type BigCacheConfig struct {
 	ItemTTL time.Duration `env:"BIG_CACHE_ITEM_TTL" envDefault:"5m"`
+	// ListMaxItems maximum number of items a list might hold. Oldest items are trimmed once exceeded.
+	// Zero means unbounded.
+	ListMaxItems int `env:"BIG_CACHE_LIST_MAX_ITEMS" envDefault:"0"`
+	// ListMaxBytes maximum size (sum of item sizes) a list might hold. Oldest items are trimmed once exceeded.
+	// Zero means unbounded.
+	ListMaxBytes int `env:"BIG_CACHE_LIST_MAX_BYTES" envDefault:"0"`
 }
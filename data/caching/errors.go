@@ -0,0 +1,17 @@
+package caching
+
+import (
+	"errors"
+
+	"github.com/allegro/bigcache/v3"
+)
+
+var (
+	// ErrEntryNotFound the requested entry does not exist or has expired.
+	//
+	// Cache implementations MUST return (or wrap) this error so callers are able to run checks
+	// using errors.Is.
+	ErrEntryNotFound = bigcache.ErrEntryNotFound
+	// ErrCorruptedList the stored list entry cannot be decoded.
+	ErrCorruptedList = errors.New("caching: corrupted list entry")
+)
@@ -0,0 +1,100 @@
+package caching
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// listFrameMarker prefixes every framed list item. Legacy (newline-separated) lists always start with
+	// legacyListSeparator, so the first byte of an entry is enough to tell both encodings apart.
+	listFrameMarker     byte = 0x00
+	legacyListSeparator byte = '\n'
+)
+
+// encodeListItem encodes value as a single list frame with the nomenclature: MARKER|UVARINT(LEN)|VALUE.
+//
+// Frames are self-contained, so encoded items can be appended to an existing entry without re-encoding it.
+func encodeListItem(value []byte) []byte {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(value))
+	buf = append(buf, listFrameMarker)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+// encodeList encodes items as a sequence of list frames.
+func encodeList(items [][]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += 1 + binary.MaxVarintLen64 + len(item)
+	}
+	buf := make([]byte, 0, size)
+	for _, item := range items {
+		buf = append(buf, listFrameMarker)
+		buf = binary.AppendUvarint(buf, uint64(len(item)))
+		buf = append(buf, item...)
+	}
+	return buf
+}
+
+// isLegacyList indicates if raw was written using the legacy newline-separated encoding.
+func isLegacyList(raw []byte) bool {
+	return len(raw) > 0 && raw[0] == legacyListSeparator
+}
+
+// decodeList decodes a list entry, supporting both framed and legacy encodings.
+//
+// Returned items are sub-slices of raw.
+func decodeList(raw []byte) ([][]byte, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	} else if isLegacyList(raw) {
+		return decodeLegacyList(raw), nil
+	}
+
+	out := make([][]byte, 0)
+	for len(raw) > 0 {
+		if raw[0] != listFrameMarker {
+			return nil, ErrCorruptedList
+		}
+		size, n := binary.Uvarint(raw[1:])
+		if n <= 0 || uint64(len(raw)-1-n) < size {
+			return nil, ErrCorruptedList
+		}
+		start := 1 + n
+		end := start + int(size)
+		out = append(out, raw[start:end:end])
+		raw = raw[end:]
+	}
+	return out, nil
+}
+
+// decodeLegacyList decodes lists written by previous versions, where every item was prefixed by a newline.
+func decodeLegacyList(raw []byte) [][]byte {
+	// remove first item separator
+	raw = raw[1:]
+	if len(raw) == 0 {
+		return nil
+	}
+	return bytes.Split(raw, []byte{legacyListSeparator})
+}
+
+// trimList removes the oldest items from the given list until both caps are satisfied.
+// A cap lower or equal than zero is ignored.
+func trimList(items [][]byte, maxItems, maxBytes int) [][]byte {
+	if maxItems > 0 && len(items) > maxItems {
+		items = items[len(items)-maxItems:]
+	}
+	if maxBytes <= 0 {
+		return items
+	}
+	total := 0
+	for _, item := range items {
+		total += len(item)
+	}
+	for len(items) > 0 && total > maxBytes {
+		total -= len(items[0])
+		items = items[1:]
+	}
+	return items
+}
@@ -0,0 +1,78 @@
+package caching
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+
+	"github.com/allegro/bigcache/v3"
+)
+
+// Stats cache statistics.
+type Stats struct {
+	// Hits number of successfully found keys.
+	Hits int64 `json:"hits"`
+	// Misses number of not found keys.
+	Misses int64 `json:"misses"`
+	// DeleteHits number of successfully deleted keys.
+	DeleteHits int64 `json:"delete_hits"`
+	// DeleteMisses number of not deleted keys.
+	DeleteMisses int64 `json:"delete_misses"`
+	// Collisions number of key collisions.
+	Collisions int64 `json:"collisions"`
+	// Evictions number of entries removed by the cache itself (e.g. expiration, no space left).
+	Evictions int64 `json:"evictions"`
+	// EntryCount number of entries currently stored.
+	EntryCount int64 `json:"entry_count"`
+	// CapacityBytes number of bytes currently allocated by the cache.
+	CapacityBytes int64 `json:"capacity_bytes"`
+}
+
+// StatsProvider is a Cache able to report its Stats.
+type StatsProvider interface {
+	// Stats retrieves current cache statistics.
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// BigCacheEvictions counts entries evicted by a bigcache.BigCache instance, as bigcache.Stats does not track them.
+type BigCacheEvictions struct {
+	count atomic.Int64
+}
+
+// NewBigCacheEvictions allocates a new BigCacheEvictions instance.
+func NewBigCacheEvictions() *BigCacheEvictions {
+	return &BigCacheEvictions{}
+}
+
+// Count retrieves the number of evicted entries.
+func (b *BigCacheEvictions) Count() int64 {
+	return b.count.Load()
+}
+
+// onRemove is meant to be used as bigcache.Config.OnRemoveWithReason callback.
+func (b *BigCacheEvictions) onRemove(_ string, _ []byte, reason bigcache.RemoveReason) {
+	if reason == bigcache.Expired || reason == bigcache.NoSpace {
+		b.count.Add(1)
+	}
+}
+
+// NewStatsExpvar allocates an expvar.Func exporting current Stats from the given StatsProvider, so they can be
+// scraped as metrics (e.g. through expvar.Handler).
+func NewStatsExpvar(provider StatsProvider) expvar.Func {
+	return func() any {
+		stats, err := provider.Stats(context.Background())
+		if err != nil {
+			return nil
+		}
+		return stats
+	}
+}
+
+// PublishStatsExpvar publishes Stats from the given StatsProvider as an expvar.Var with the given name.
+// Does nothing if name was already published.
+func PublishStatsExpvar(name string, provider StatsProvider) {
+	if expvar.Get(name) != nil {
+		return
+	}
+	expvar.Publish(name, NewStatsExpvar(provider))
+}
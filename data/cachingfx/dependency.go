@@ -4,16 +4,27 @@ import (
 	"github.com/caarlos0/env/v11"
 	"go.uber.org/fx"
 
+	"github.com/hadroncorp/geck/actuatorfx"
 	"github.com/hadroncorp/geck/data/caching"
 )
 
 var CacheEmbeddedModule = fx.Module("cache_embedded",
 	fx.Provide(
 		env.ParseAs[caching.BigCacheConfig],
+		caching.NewBigCacheEvictions,
 		caching.NewBigCache,
 		fx.Annotate(
 			caching.NewCacheEmbedded,
 			fx.As(new(caching.Cache)),
+			fx.As(new(caching.AtomicCache)),
 		),
+		actuatorfx.AsActuator(caching.NewActuator),
+	),
+	fx.Invoke(
+		func(cache caching.Cache) {
+			if provider, ok := cache.(caching.StatsProvider); ok {
+				caching.PublishStatsExpvar("geck.caching.embedded", provider)
+			}
+		},
 	),
 )
@@ -0,0 +1,593 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/validation"
+)
+
+// ChangeSetType the format a ChangeSet was parsed from.
+type ChangeSetType string
+
+const (
+	// ChangeSetTypeMergePatch JSON Merge Patch (RFC 7396).
+	ChangeSetTypeMergePatch ChangeSetType = "MERGE_PATCH"
+	// ChangeSetTypeJSONPatch JSON Patch (RFC 6902).
+	ChangeSetTypeJSONPatch ChangeSetType = "JSON_PATCH"
+	// ChangeSetTypeUpdateMask partial document along with the FieldMask of the fields to update.
+	ChangeSetTypeUpdateMask ChangeSetType = "UPDATE_MASK"
+)
+
+const (
+	patchTag          = "patch"
+	patchTagImmutable = "immutable"
+)
+
+// ChangeSet a set of changes to apply to a document of type T (e.g. an update command). Documents are patched
+// using their JSON representation, thus paths use JSON field names.
+//
+// Only fields of T tagged with `patch:"immutable"` cannot be modified, every other field is patchable. Identifiers
+// are no exception, tag them explicitly (e.g. TaskID string `json:"task_id" patch:"immutable"`).
+type ChangeSet[T any] struct {
+	// Type the format the ChangeSet was parsed from.
+	Type ChangeSetType
+	// Paths fields touched by the ChangeSet. Indexes of repeated fields are omitted.
+	Paths FieldMask
+	patch func(doc any) (any, error)
+}
+
+// NewChangeSetMergePatch allocates a ChangeSet from a JSON Merge Patch (RFC 7396) document. Members set to null
+// are reset to their zero value.
+func NewChangeSetMergePatch[T any](raw []byte) (ChangeSet[T], error) {
+	patch, err := decodeJSONDocument(raw)
+	if err != nil {
+		return ChangeSet[T]{}, systemerror.NewMalformedArgument("body", err.Error())
+	}
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return ChangeSet[T]{}, systemerror.NewMalformedArgument("body", "merge patch must be a JSON object")
+	}
+	return ChangeSet[T]{
+		Type:  ChangeSetTypeMergePatch,
+		Paths: NewFieldMask(collectMergePatchPaths(patchObj, "")...),
+		patch: func(doc any) (any, error) {
+			return applyMergePatch(doc, patchObj), nil
+		},
+	}, nil
+}
+
+// NewChangeSetJSONPatch allocates a ChangeSet from a JSON Patch (RFC 6902) document. Every operation (add, remove,
+// replace, move, copy and test) is supported.
+func NewChangeSetJSONPatch[T any](raw []byte) (ChangeSet[T], error) {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(raw, &operations); err != nil {
+		return ChangeSet[T]{}, systemerror.NewMalformedArgument("body", err.Error())
+	}
+	paths := make([]string, 0, len(operations))
+	for i := range operations {
+		if err := operations[i].parse(); err != nil {
+			return ChangeSet[T]{}, systemerror.NewMalformedArgument(fmt.Sprintf("body[%d]", i), err.Error())
+		}
+		if operations[i].Op != jsonPatchOpTest {
+			paths = append(paths, convertJSONPointerPath(operations[i].pathTokens))
+		}
+		if operations[i].Op == jsonPatchOpMove {
+			paths = append(paths, convertJSONPointerPath(operations[i].fromTokens))
+		}
+	}
+	return ChangeSet[T]{
+		Type:  ChangeSetTypeJSONPatch,
+		Paths: NewFieldMask(paths...),
+		patch: func(doc any) (any, error) {
+			var err error
+			for _, operation := range operations {
+				if doc, err = operation.apply(doc); err != nil {
+					return nil, err
+				}
+			}
+			return doc, nil
+		},
+	}, nil
+}
+
+// NewChangeSetUpdateMask allocates a ChangeSet updating the fields in mask with the values of body, a partial
+// document. Fields in mask missing from body are reset to their zero value.
+//
+// Paths of mask are validated against T. Masks cannot traverse repeated fields.
+func NewChangeSetUpdateMask[T any](mask FieldMask, raw []byte) (ChangeSet[T], error) {
+	if mask.IsEmpty() {
+		return ChangeSet[T]{}, systemerror.NewMissingArgument("update_mask")
+	}
+	var zero T
+	if err := mask.Validate(zero); err != nil {
+		return ChangeSet[T]{}, err
+	}
+	body, err := decodeJSONDocument(raw)
+	if err != nil {
+		return ChangeSet[T]{}, systemerror.NewMalformedArgument("body", err.Error())
+	}
+	if _, ok := body.(map[string]any); !ok {
+		return ChangeSet[T]{}, systemerror.NewMalformedArgument("body", "body must be a JSON object")
+	}
+	return ChangeSet[T]{
+		Type:  ChangeSetTypeUpdateMask,
+		Paths: mask,
+		patch: func(doc any) (any, error) {
+			var err error
+			for _, path := range mask {
+				tokens := strings.Split(path, fieldMaskSeparator)
+				value, errGet := getJSONPointer(body, tokens)
+				if errGet != nil {
+					value = nil
+				}
+				if doc, err = setJSONPath(doc, tokens, copyJSONValue(value)); err != nil {
+					return nil, err
+				}
+			}
+			return doc, nil
+		},
+	}, nil
+}
+
+// Apply applies the ChangeSet to current, returning the resulting document. The result is validated using
+// validator (skipped if nil) and immutable fields are verified to be unchanged.
+func (c ChangeSet[T]) Apply(ctx context.Context, validator validation.Validator, current T) (T, error) {
+	if c.patch == nil {
+		return current, nil
+	}
+	var result T
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return result, err
+	}
+	doc, err := decodeJSONDocument(raw)
+	if err != nil {
+		return result, err
+	}
+	original := copyJSONValue(doc)
+	if doc, err = c.patch(doc); err != nil {
+		return result, err
+	}
+
+	if raw, err = json.Marshal(doc); err != nil {
+		return result, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&result); err != nil {
+		return result, systemerror.NewMalformedArgument("body", err.Error())
+	}
+	if err = verifyImmutableFields(original, result); err != nil {
+		return result, err
+	}
+	if validator != nil {
+		if err = validator.Validate(ctx, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// verifyImmutableFields verifies fields tagged as immutable hold the same value in original and result.
+func verifyImmutableFields[T any](original any, result T) error {
+	paths := collectImmutablePaths(reflect.TypeFor[T](), "", make(map[reflect.Type]struct{}))
+	if len(paths) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeJSONDocument(raw)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		tokens := strings.Split(path, fieldMaskSeparator)
+		before, _ := getJSONPointer(original, tokens)
+		after, _ := getJSONPointer(doc, tokens)
+		if !reflect.DeepEqual(before, after) {
+			return systemerror.NewImmutableArgument(path)
+		}
+	}
+	return nil
+}
+
+// collectImmutablePaths returns the paths of the fields tagged as immutable in typ, sorted by name. Nested
+// structs are traversed once, repeated fields are not.
+func collectImmutablePaths(typ reflect.Type, prefix string, visited map[reflect.Type]struct{}) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if _, ok := visited[typ]; ok {
+		return nil
+	}
+	visited[typ] = struct{}{}
+	defer delete(visited, typ)
+	paths := make([]string, 0)
+	for name, field := range jsonFields(typ) {
+		if field.Tag.Get(patchTag) == patchTagImmutable {
+			paths = append(paths, prefix+name)
+			continue
+		}
+		paths = append(paths, collectImmutablePaths(field.Type, prefix+name+fieldMaskSeparator, visited)...)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func decodeJSONDocument(raw []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	} else if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return doc, nil
+}
+
+func collectMergePatchPaths(patch map[string]any, prefix string) []string {
+	paths := make([]string, 0, len(patch))
+	for key, value := range patch {
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			paths = append(paths, collectMergePatchPaths(nested, prefix+key+fieldMaskSeparator)...)
+			continue
+		}
+		paths = append(paths, prefix+key)
+	}
+	return paths
+}
+
+// applyMergePatch applies patch to target as specified by RFC 7396. target is not modified.
+func applyMergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return copyJSONValue(patch)
+	}
+	targetObj, ok := target.(map[string]any)
+	result := make(map[string]any, len(targetObj)+len(patchObj))
+	if ok {
+		for key, value := range targetObj {
+			result[key] = value
+		}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = applyMergePatch(result[key], value)
+	}
+	return result
+}
+
+// setJSONPath sets value at the object path tokens of doc, creating intermediate objects. The member is removed if
+// value is nil.
+func setJSONPath(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	obj, ok := doc.(map[string]any)
+	if doc == nil {
+		obj, ok = make(map[string]any), true
+	}
+	if !ok {
+		return nil, systemerror.NewInvalidArgument("update_mask", tokens[0], "object field")
+	}
+	if len(tokens) == 1 {
+		if value == nil {
+			delete(obj, tokens[0])
+		} else {
+			obj[tokens[0]] = value
+		}
+		return obj, nil
+	}
+	child, err := setJSONPath(obj[tokens[0]], tokens[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	obj[tokens[0]] = child
+	return obj, nil
+}
+
+func copyJSONValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(typed))
+		for key, item := range typed {
+			copied[key] = copyJSONValue(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(typed))
+		for i, item := range typed {
+			copied[i] = copyJSONValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// -- JSON Patch (RFC 6902) --
+
+const (
+	jsonPatchOpAdd     = "add"
+	jsonPatchOpRemove  = "remove"
+	jsonPatchOpReplace = "replace"
+	jsonPatchOpMove    = "move"
+	jsonPatchOpCopy    = "copy"
+	jsonPatchOpTest    = "test"
+)
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	pathTokens []string
+	fromTokens []string
+	value      any
+}
+
+func (o *jsonPatchOperation) parse() (err error) {
+	if o.Path == nil {
+		return fmt.Errorf("member 'path' is missing")
+	} else if o.pathTokens, err = parseJSONPointer(*o.Path); err != nil {
+		return err
+	}
+	switch o.Op {
+	case jsonPatchOpAdd, jsonPatchOpReplace, jsonPatchOpTest:
+		if o.Value == nil {
+			return fmt.Errorf("member 'value' is missing")
+		}
+		o.value, err = decodeJSONDocument(o.Value)
+		return err
+	case jsonPatchOpMove, jsonPatchOpCopy:
+		if o.From == nil {
+			return fmt.Errorf("member 'from' is missing")
+		} else if o.fromTokens, err = parseJSONPointer(*o.From); err != nil {
+			return err
+		} else if o.Op == jsonPatchOpMove && isJSONPointerPrefix(o.fromTokens, o.pathTokens) &&
+			len(o.fromTokens) < len(o.pathTokens) {
+			return fmt.Errorf("cannot move a value into one of its children")
+		}
+		return nil
+	case jsonPatchOpRemove:
+		return nil
+	default:
+		return fmt.Errorf("operation '%s' is not supported", o.Op)
+	}
+}
+
+func (o *jsonPatchOperation) apply(doc any) (any, error) {
+	switch o.Op {
+	case jsonPatchOpAdd:
+		return addJSONPointer(doc, o.pathTokens, copyJSONValue(o.value), *o.Path)
+	case jsonPatchOpRemove:
+		return removeJSONPointer(doc, o.pathTokens, *o.Path)
+	case jsonPatchOpReplace:
+		var err error
+		if doc, err = removeJSONPointer(doc, o.pathTokens, *o.Path); err != nil {
+			return nil, err
+		}
+		return addJSONPointer(doc, o.pathTokens, copyJSONValue(o.value), *o.Path)
+	case jsonPatchOpMove, jsonPatchOpCopy:
+		value, err := getJSONPointer(doc, o.fromTokens)
+		if err != nil {
+			return nil, newJSONPatchPathError(*o.From)
+		}
+		if o.Op == jsonPatchOpMove {
+			if doc, err = removeJSONPointer(doc, o.fromTokens, *o.From); err != nil {
+				return nil, err
+			}
+		}
+		return addJSONPointer(doc, o.pathTokens, copyJSONValue(value), *o.Path)
+	case jsonPatchOpTest:
+		value, err := getJSONPointer(doc, o.pathTokens)
+		if err != nil || !reflect.DeepEqual(normalizeJSONValue(value), normalizeJSONValue(o.value)) {
+			return nil, systemerror.NewFailedPrecondition("PATCH_TEST_FAILED",
+				fmt.Sprintf("test operation failed at path '%s'", *o.Path), map[string]string{"path": *o.Path})
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("operation '%s' is not supported", o.Op)
+	}
+}
+
+func newJSONPatchPathError(path string) error {
+	return systemerror.NewUnprocessableContent("INVALID_PATCH_PATH",
+		fmt.Sprintf("path '%s' cannot be resolved", path), map[string]string{"path": path})
+}
+
+// parseJSONPointer parses a JSON Pointer (RFC 6901) into its reference tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	} else if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("pointer '%s' must start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isJSONPointerPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// convertJSONPointerPath converts JSON Pointer tokens into a FieldMask path, omitting array indexes.
+func convertJSONPointerPath(tokens []string) string {
+	segments := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if _, err := strconv.Atoi(token); err == nil || token == "-" {
+			continue
+		}
+		segments = append(segments, token)
+	}
+	return strings.Join(segments, fieldMaskSeparator)
+}
+
+func getJSONPointer(doc any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch typed := doc.(type) {
+		case map[string]any:
+			value, ok := typed[token]
+			if !ok {
+				return nil, fmt.Errorf("member '%s' not found", token)
+			}
+			doc = value
+		case []any:
+			index, err := parseJSONPointerIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = typed[index]
+		default:
+			return nil, fmt.Errorf("cannot traverse '%s'", token)
+		}
+	}
+	return doc, nil
+}
+
+func addJSONPointer(doc any, tokens []string, value any, path string) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	token := tokens[0]
+	switch typed := doc.(type) {
+	case map[string]any:
+		if len(tokens) == 1 {
+			typed[token] = value
+			return typed, nil
+		}
+		child, ok := typed[token]
+		if !ok {
+			return nil, newJSONPatchPathError(path)
+		}
+		child, err := addJSONPointer(child, tokens[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+		typed[token] = child
+		return typed, nil
+	case []any:
+		if len(tokens) == 1 {
+			if token == "-" {
+				return append(typed, value), nil
+			}
+			index, err := parseJSONPointerIndex(token, len(typed))
+			if err != nil {
+				return nil, newJSONPatchPathError(path)
+			}
+			typed = append(typed, nil)
+			copy(typed[index+1:], typed[index:])
+			typed[index] = value
+			return typed, nil
+		}
+		index, err := parseJSONPointerIndex(token, len(typed)-1)
+		if err != nil {
+			return nil, newJSONPatchPathError(path)
+		}
+		if typed[index], err = addJSONPointer(typed[index], tokens[1:], value, path); err != nil {
+			return nil, err
+		}
+		return typed, nil
+	default:
+		return nil, newJSONPatchPathError(path)
+	}
+}
+
+func removeJSONPointer(doc any, tokens []string, path string) (any, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	token := tokens[0]
+	switch typed := doc.(type) {
+	case map[string]any:
+		child, ok := typed[token]
+		if !ok {
+			return nil, newJSONPatchPathError(path)
+		}
+		if len(tokens) == 1 {
+			delete(typed, token)
+			return typed, nil
+		}
+		child, err := removeJSONPointer(child, tokens[1:], path)
+		if err != nil {
+			return nil, err
+		}
+		typed[token] = child
+		return typed, nil
+	case []any:
+		index, err := parseJSONPointerIndex(token, len(typed)-1)
+		if err != nil {
+			return nil, newJSONPatchPathError(path)
+		}
+		if len(tokens) == 1 {
+			return append(typed[:index], typed[index+1:]...), nil
+		}
+		if typed[index], err = removeJSONPointer(typed[index], tokens[1:], path); err != nil {
+			return nil, err
+		}
+		return typed, nil
+	default:
+		return nil, newJSONPatchPathError(path)
+	}
+}
+
+// parseJSONPointerIndex parses an array index token, verifying it is within [0, maxIndex].
+func parseJSONPointerIndex(token string, maxIndex int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > maxIndex || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	return index, nil
+}
+
+// normalizeJSONValue converts json.Number values into float64 so numbers are compared by value (e.g. 1 and 1.0).
+func normalizeJSONValue(value any) any {
+	switch typed := value.(type) {
+	case json.Number:
+		number, err := typed.Float64()
+		if err != nil {
+			return typed
+		}
+		return number
+	case map[string]any:
+		normalized := make(map[string]any, len(typed))
+		for key, item := range typed {
+			normalized[key] = normalizeJSONValue(item)
+		}
+		return normalized
+	case []any:
+		normalized := make([]any, len(typed))
+		for i, item := range typed {
+			normalized[i] = normalizeJSONValue(item)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
@@ -0,0 +1,231 @@
+package data
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+const fieldMaskSeparator = "."
+
+type fieldMaskContextType string
+
+// FieldMaskContextKey context key holding the FieldMask of the current read operation.
+const FieldMaskContextKey fieldMaskContextType = "geck.data.field_mask"
+
+// FieldMask a set of field paths (e.g. name, audit.create_time) selecting a partial view of a resource. Paths use
+// JSON field names separated by dots; repeated fields are traversed transparently, so items.name selects the
+// name of every item.
+//
+// An empty FieldMask selects every field.
+type FieldMask []string
+
+// NewFieldMask allocates a FieldMask from paths. Paths are trimmed, de-duplicated and sorted; paths already
+// covered by an ancestor path are dropped.
+func NewFieldMask(paths ...string) FieldMask {
+	mask := make(FieldMask, 0, len(paths))
+	for _, path := range paths {
+		if path = strings.TrimSpace(path); path != "" {
+			mask = append(mask, path)
+		}
+	}
+	sort.Strings(mask)
+	mask = slices.Compact(mask)
+	normalized := mask[:0]
+	for _, path := range mask {
+		covered := slices.ContainsFunc(normalized, func(ancestor string) bool {
+			return isFieldPathAncestor(ancestor, path)
+		})
+		if !covered {
+			normalized = append(normalized, path)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+	return normalized
+}
+
+// ParseFieldMask parses a comma-separated list of field paths (e.g. name,audit.create_time).
+func ParseFieldMask(raw string) (FieldMask, error) {
+	paths := strings.Split(raw, ",")
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		for _, segment := range strings.Split(path, fieldMaskSeparator) {
+			if segment == "" || strings.ContainsAny(segment, " \t*") {
+				return nil, systemerror.NewInvalidFormatArgument("field_mask", "comma-separated field paths")
+			}
+		}
+	}
+	return NewFieldMask(paths...), nil
+}
+
+// IsEmpty indicates whether the FieldMask selects every field.
+func (m FieldMask) IsEmpty() bool {
+	return len(m) == 0
+}
+
+// String returns the comma-separated representation of the FieldMask.
+func (m FieldMask) String() string {
+	return strings.Join(m, ",")
+}
+
+// Contains indicates whether path is selected by the FieldMask. A path is selected if the mask is empty, if the
+// path (or one of its ancestors) is part of the mask or if the path is an ancestor of a masked path.
+func (m FieldMask) Contains(path string) bool {
+	if m.IsEmpty() {
+		return true
+	}
+	for _, maskPath := range m {
+		if isFieldPathAncestor(maskPath, path) || isFieldPathAncestor(path, maskPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// Columns returns the columns of fields (field path to column name) selected by the FieldMask, sorted by name.
+// Columns in required (e.g. primary keys, version) are always returned. Returns nil if the mask is empty,
+// meaning every column must be read.
+func (m FieldMask) Columns(fields CriteriaFields, required ...string) []string {
+	if m.IsEmpty() {
+		return nil
+	}
+	columns := make([]string, 0, len(required)+len(m))
+	columns = append(columns, required...)
+	for field, column := range fields {
+		if m.Contains(field) {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	return slices.Compact(columns)
+}
+
+// Validate verifies every path of the FieldMask exists in the JSON representation of v. Embedded structs are
+// flattened, while slices, arrays and pointers are traversed. Maps and interfaces accept any sub-path.
+//
+// Returns a systemerror invalid argument error listing the paths available where resolution failed.
+func (m FieldMask) Validate(v any) error {
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		return nil
+	}
+	for _, path := range m {
+		if err := validateFieldPath(typ, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isFieldPathAncestor(ancestor, path string) bool {
+	return ancestor == path || strings.HasPrefix(path, ancestor+fieldMaskSeparator)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func validateFieldPath(typ reflect.Type, path string) error {
+	prefix := ""
+	for _, segment := range strings.Split(path, fieldMaskSeparator) {
+		typ = indirectFieldType(typ)
+		if typ.Kind() == reflect.Map || typ.Kind() == reflect.Interface {
+			return nil
+		}
+		fields := jsonFields(typ)
+		field, ok := fields[segment]
+		if !ok {
+			options := make([]string, 0, len(fields))
+			for name := range fields {
+				options = append(options, prefix+name)
+			}
+			sort.Strings(options)
+			return systemerror.NewArgumentNotOneOf(prefix+segment, options...)
+		}
+		typ = field.Type
+		prefix += segment + fieldMaskSeparator
+	}
+	return nil
+}
+
+// indirectFieldType resolves the type holding the fields of typ, skipping pointers and repeated fields.
+func indirectFieldType(typ reflect.Type) reflect.Type {
+	for {
+		switch typ.Kind() {
+		case reflect.Pointer:
+			typ = typ.Elem()
+		case reflect.Slice, reflect.Array:
+			if typ.Elem().Kind() == reflect.Uint8 {
+				return typ
+			}
+			typ = typ.Elem()
+		default:
+			return typ
+		}
+	}
+}
+
+// jsonFields returns the fields of typ as encoded by encoding/json. Returns an empty set if typ is not a struct
+// or if it has a custom encoding.
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	if typ.Kind() != reflect.Struct || typ.Implements(jsonMarshalerType) || typ.Implements(textMarshalerType) ||
+		reflect.PointerTo(typ).Implements(jsonMarshalerType) || reflect.PointerTo(typ).Implements(textMarshalerType) {
+		return fields
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedField := range jsonFields(embedded) {
+					if _, ok := fields[embeddedName]; !ok {
+						fields[embeddedName] = embeddedField
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// NewContextWithFieldMask appends a FieldMask to the given context.Context. Repositories may read it to select
+// fewer columns.
+func NewContextWithFieldMask(ctx context.Context, mask FieldMask) context.Context {
+	return context.WithValue(ctx, FieldMaskContextKey, mask)
+}
+
+// GetFieldMaskFromContext retrieves the FieldMask from the given context.Context. Returns an empty FieldMask if
+// not found.
+func GetFieldMaskFromContext(ctx context.Context) FieldMask {
+	mask, _ := ctx.Value(FieldMaskContextKey).(FieldMask)
+	return mask
+}
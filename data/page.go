@@ -16,3 +16,25 @@ type Page[T any] struct {
 	TotalItems        int       `json:"total_items"`
 	Items             []T       `json:"items"`
 }
+
+// PageMetadata is implemented by Page regardless of its item type. Use it to read pagination metadata from
+// components not aware of item types (e.g. transport adapters).
+type PageMetadata interface {
+	GetPreviousPageToken() PageToken
+	GetNextPageToken() PageToken
+	GetTotalItems() int
+}
+
+var _ PageMetadata = Page[any]{}
+
+func (p Page[T]) GetPreviousPageToken() PageToken {
+	return p.PreviousPageToken
+}
+
+func (p Page[T]) GetNextPageToken() PageToken {
+	return p.NextPageToken
+}
+
+func (p Page[T]) GetTotalItems() int {
+	return p.TotalItems
+}
@@ -47,3 +47,7 @@ func (a *Auditable) Update(ctx context.Context) {
 		a.LastUpdateBy = principal.Username()
 	}
 }
+
+func (a Auditable) GetLastUpdateTime() time.Time {
+	return a.LastUpdateTime
+}
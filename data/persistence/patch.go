@@ -0,0 +1,36 @@
+package persistence
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/validation"
+)
+
+// Updatable a Persistable keeping track of its modifications (e.g. Auditable).
+type Updatable interface {
+	Update(ctx context.Context)
+}
+
+// Patch applies changes to entity, an already loaded entity. The entity is converted into its patch document
+// through toDocument, then the changes are applied and validated (see data.ChangeSet.Apply). Finally, the resulting
+// document is written back into entity through fromDocument and entity is updated (e.g. Auditable.Update).
+//
+// Returns false if changes left the document untouched; in that case, entity is not updated.
+func Patch[E any, PE interface {
+	*E
+	Updatable
+}, D any](ctx context.Context, validator validation.Validator, entity PE, changes data.ChangeSet[D],
+	toDocument func(entity E) D, fromDocument func(entity PE, doc D)) (bool, error) {
+	current := toDocument(*entity)
+	doc, err := changes.Apply(ctx, validator, current)
+	if err != nil {
+		return false, err
+	} else if reflect.DeepEqual(current, doc) {
+		return false, nil
+	}
+	fromDocument(entity, doc)
+	entity.Update(ctx)
+	return true, nil
+}
@@ -1,12 +1,24 @@
 package persistence
 
+import "time"
+
+// AuditableView the view of Auditable. Its fields are managed by the system, thus they cannot be patched
+// (see data.ChangeSet).
 type AuditableView struct {
-	CreateTime           string  `json:"create_time"`
-	CreateTimeMillis     int64   `json:"create_time_millis"`
-	CreateBy             *string `json:"create_by"`
-	LastUpdateTime       string  `json:"last_update_time"`
-	LastUpdateTimeMillis int64   `json:"last_update_time_millis"`
-	LastUpdateBy         *string `json:"last_update_by"`
-	IsActive             bool    `json:"is_active"`
-	Version              int64   `json:"version"`
+	CreateTime           string  `json:"create_time" patch:"immutable"`
+	CreateTimeMillis     int64   `json:"create_time_millis" patch:"immutable"`
+	CreateBy             *string `json:"create_by" patch:"immutable"`
+	LastUpdateTime       string  `json:"last_update_time" patch:"immutable"`
+	LastUpdateTimeMillis int64   `json:"last_update_time_millis" patch:"immutable"`
+	LastUpdateBy         *string `json:"last_update_by" patch:"immutable"`
+	IsActive             bool    `json:"is_active" patch:"immutable"`
+	Version              int64   `json:"version" patch:"immutable"`
+}
+
+func (a AuditableView) GetVersion() int64 {
+	return a.Version
+}
+
+func (a AuditableView) GetLastUpdateTime() time.Time {
+	return time.UnixMilli(a.LastUpdateTimeMillis).UTC()
 }
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Object is the metadata of a stored object.
+type Object struct {
+	// Key unique identifier of the object within its Bucket (e.g. documents/2024/report.pdf).
+	Key         string
+	Size        int64
+	ContentType string
+	// ETag opaque identifier of the object content. Changes every time the object is written.
+	ETag string
+	// Metadata user-defined key-value pairs.
+	Metadata     map[string]string
+	LastModified time.Time
+}
+
+// PutOptions options of Bucket.Put.
+type PutOptions struct {
+	ContentType string
+	// Size content length, if known in advance. Negative if unknown.
+	Size     int64
+	Metadata map[string]string
+}
+
+// Bucket is a flat object store (e.g. local filesystem, S3, GCS), keeping the subset of operations every
+// object store supports.
+//
+// Keys are slash-separated paths. Keys MUST NOT be empty, start with a slash or hold . or .. segments, otherwise
+// ErrInvalidKey is returned.
+type Bucket interface {
+	// Put stores body under key, replacing any existing object. body is streamed, so objects are not buffered in
+	// memory. If reading body fails, nothing is stored and the read error is returned.
+	Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (Object, error)
+	// Get retrieves the content of the object stored under key. Callers MUST close the returned reader. Returns
+	// ErrObjectNotFound if key does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Stat retrieves the metadata of the object stored under key. Returns ErrObjectNotFound if key does not exist.
+	Stat(ctx context.Context, key string) (Object, error)
+	// Delete removes the object stored under key. Removing missing objects is not an error.
+	Delete(ctx context.Context, key string) error
+}
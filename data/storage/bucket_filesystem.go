@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	filesystemObjectsDir  = "objects"
+	filesystemMetadataDir = "metadata"
+	filesystemTempPattern = ".tmp-*"
+)
+
+// filesystemMetadata the object metadata stored next to its content.
+type filesystemMetadata struct {
+	ContentType string            `json:"content_type"`
+	ETag        string            `json:"etag"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// BucketFilesystem is the Bucket implementation using the local filesystem. Object contents are stored under
+// ConfigFilesystem.RootPath/objects, while their metadata is stored under ConfigFilesystem.RootPath/metadata.
+//
+// Objects are written to temporary files and then renamed, so readers never get partially written objects.
+// ETag values are the hex-encoded SHA-256 digest of object contents.
+type BucketFilesystem struct {
+	rootPath string
+}
+
+var _ Bucket = BucketFilesystem{}
+
+// NewBucketFilesystem allocates a new BucketFilesystem instance, creating its root directory if required.
+func NewBucketFilesystem(cfg ConfigFilesystem) (BucketFilesystem, error) {
+	for _, dir := range []string{filesystemObjectsDir, filesystemMetadataDir} {
+		if err := os.MkdirAll(filepath.Join(cfg.RootPath, dir), 0o750); err != nil {
+			return BucketFilesystem{}, err
+		}
+	}
+	return BucketFilesystem{
+		rootPath: cfg.RootPath,
+	}, nil
+}
+
+func (b BucketFilesystem) objectPath(key string) string {
+	return filepath.Join(b.rootPath, filesystemObjectsDir, filepath.FromSlash(key))
+}
+
+func (b BucketFilesystem) metadataPath(key string) string {
+	return filepath.Join(b.rootPath, filesystemMetadataDir, filepath.FromSlash(key)+".json")
+}
+
+func (b BucketFilesystem) Put(ctx context.Context, key string, body io.Reader, opts PutOptions) (Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return Object{}, err
+	}
+	hash := sha256.New()
+	path := b.objectPath(key)
+	if err := writeFileAtomic(path, io.TeeReader(contextReader{ctx: ctx, reader: body}, hash)); err != nil {
+		return Object{}, err
+	}
+
+	meta := filesystemMetadata{
+		ContentType: opts.ContentType,
+		ETag:        hex.EncodeToString(hash.Sum(nil)),
+		Metadata:    opts.Metadata,
+	}
+	rawMeta, err := json.Marshal(meta)
+	if err != nil {
+		return Object{}, err
+	}
+	if err = writeFileAtomic(b.metadataPath(key), bytes.NewReader(rawMeta)); err != nil {
+		return Object{}, errors.Join(err, os.Remove(path))
+	}
+	return b.Stat(ctx, key)
+}
+
+func (b BucketFilesystem) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	obj, err := b.Stat(ctx, key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	file, err := os.Open(b.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Object{}, ErrObjectNotFound
+	} else if err != nil {
+		return nil, Object{}, err
+	}
+	return file, obj, nil
+}
+
+func (b BucketFilesystem) Stat(_ context.Context, key string) (Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(b.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return Object{}, ErrObjectNotFound
+	} else if err != nil {
+		return Object{}, err
+	}
+	obj := Object{
+		Key:          key,
+		Size:         info.Size(),
+		LastModified: info.ModTime().UTC(),
+	}
+	rawMeta, err := os.ReadFile(b.metadataPath(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Object{}, err
+	}
+	meta := filesystemMetadata{}
+	if len(rawMeta) > 0 {
+		if err = json.Unmarshal(rawMeta, &meta); err != nil {
+			return Object{}, err
+		}
+	}
+	obj.ContentType, obj.ETag, obj.Metadata = meta.ContentType, meta.ETag, meta.Metadata
+	if obj.ContentType == "" {
+		obj.ContentType = "application/octet-stream"
+	}
+	return obj, nil
+}
+
+func (b BucketFilesystem) Delete(_ context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	errObj := os.Remove(b.objectPath(key))
+	errMeta := os.Remove(b.metadataPath(key))
+	if errors.Is(errObj, fs.ErrNotExist) {
+		errObj = nil
+	}
+	if errors.Is(errMeta, fs.ErrNotExist) {
+		errMeta = nil
+	}
+	return errors.Join(errObj, errMeta)
+}
+
+// writeFileAtomic writes content into a temporary file, renaming it to path once written. The temporary file is
+// removed if writing fails.
+func writeFileAtomic(path string, content io.Reader) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, filesystemTempPattern)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, content); err != nil {
+		return errors.Join(err, file.Close(), os.Remove(file.Name()))
+	}
+	if err = file.Close(); err != nil {
+		return errors.Join(err, os.Remove(file.Name()))
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		return errors.Join(err, os.Remove(file.Name()))
+	}
+	return nil
+}
+
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
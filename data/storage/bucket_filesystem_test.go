@@ -0,0 +1,67 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/storage"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read(_ []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestBucketFilesystem(t *testing.T) {
+	root := t.TempDir()
+	bucket, err := storage.NewBucketFilesystem(storage.ConfigFilesystem{RootPath: root})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	obj, err := bucket.Put(ctx, "documents/report.txt", strings.NewReader("hello"), storage.PutOptions{
+		ContentType: "text/plain",
+		Size:        -1,
+		Metadata:    map[string]string{"owner": "jane"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), obj.Size)
+	assert.Equal(t, "text/plain", obj.ContentType)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", obj.ETag)
+	assert.Equal(t, map[string]string{"owner": "jane"}, obj.Metadata)
+
+	body, obj, err := bucket.Get(ctx, "documents/report.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(body)
+	require.NoError(t, body.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+	assert.Equal(t, "documents/report.txt", obj.Key)
+
+	_, err = bucket.Put(ctx, "documents/partial.txt", io.MultiReader(strings.NewReader("hel"), failingReader{}),
+		storage.PutOptions{})
+	assert.Error(t, err)
+	_, err = bucket.Stat(ctx, "documents/partial.txt")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+	entries, err := os.ReadDir(filepath.Join(root, "objects", "documents"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files must be removed")
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/./b", "a//b", "dir/"} {
+		_, err = bucket.Put(ctx, key, strings.NewReader("x"), storage.PutOptions{})
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+	}
+
+	require.NoError(t, bucket.Delete(ctx, "documents/report.txt"))
+	require.NoError(t, bucket.Delete(ctx, "documents/report.txt"))
+	_, _, err = bucket.Get(ctx, "documents/report.txt")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+}
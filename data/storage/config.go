@@ -0,0 +1,7 @@
+package storage
+
+// ConfigFilesystem configuration structure for BucketFilesystem instances.
+type ConfigFilesystem struct {
+	// RootPath directory objects are stored in. Created if it does not exist.
+	RootPath string `env:"STORAGE_FILESYSTEM_ROOT_PATH" envDefault:"./data/objects"`
+}
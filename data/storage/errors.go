@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrObjectNotFound the requested object does not exist.
+	//
+	// Bucket implementations MUST return (or wrap) this error so callers are able to run checks using errors.Is.
+	ErrObjectNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey the object key is empty or holds forbidden segments.
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// ValidateKey checks key follows Bucket key rules.
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
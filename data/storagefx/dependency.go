@@ -0,0 +1,18 @@
+package storagefx
+
+import (
+	"github.com/caarlos0/env/v11"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/data/storage"
+)
+
+var BucketFilesystemModule = fx.Module("storage_bucket_filesystem",
+	fx.Provide(
+		env.ParseAs[storage.ConfigFilesystem],
+		fx.Annotate(
+			storage.NewBucketFilesystem,
+			fx.As(new(storage.Bucket)),
+		),
+	),
+)
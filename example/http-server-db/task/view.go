@@ -3,7 +3,7 @@ package task
 import "github.com/hadroncorp/geck/data/persistence"
 
 type View struct {
-	TaskID string `json:"task_id"`
+	TaskID string `json:"task_id" patch:"immutable"`
 	Name   string `json:"name"`
 	Status string `json:"status"`
 	persistence.AuditableView
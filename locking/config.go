@@ -0,0 +1,12 @@
+package locking
+
+import "time"
+
+// Config configuration structure for Locker instances.
+type Config struct {
+	// RetryInterval time to wait between lock acquisition attempts when calling Locker.Lock.
+	RetryInterval time.Duration `env:"LOCK_RETRY_INTERVAL" envDefault:"100ms"`
+	// KeyPrefix prefix appended to every lock key. Useful to avoid collisions between applications sharing
+	// the same backend.
+	KeyPrefix string `env:"LOCK_KEY_PREFIX" envDefault:"geck.lock#"`
+}
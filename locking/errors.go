@@ -0,0 +1,10 @@
+package locking
+
+import "errors"
+
+var (
+	// ErrNotAcquired the lock is currently held by another owner.
+	ErrNotAcquired = errors.New("locking: lock not acquired")
+	// ErrLeaseLost the lease is no longer valid (e.g. expired, released or taken by another owner).
+	ErrLeaseLost = errors.New("locking: lease lost")
+)
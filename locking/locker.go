@@ -0,0 +1,87 @@
+package locking
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Lease is a granted, time-bounded, lock ownership.
+type Lease struct {
+	// Key the lock key.
+	Key string
+	// ID unique identifier of this lease (i.e. the lock owner).
+	ID string
+	// Token fencing token. Tokens grow monotonically with every acquisition of the same lock, so external
+	// systems are able to reject writes from stale owners (e.g. owner paused after its lease expired). Locker
+	// implementations not able to persist tokens (e.g. LockerCacheEmbedded) document so.
+	Token int64
+	// TTL time the lease is granted for.
+	TTL time.Duration
+	// ExpireTime time the lease expires if not renewed.
+	ExpireTime time.Time
+}
+
+// IsExpired indicates if the lease has expired at the given time.
+func (l Lease) IsExpired(at time.Time) bool {
+	return !at.Before(l.ExpireTime)
+}
+
+// Locker is a mutual exclusion mechanism used across processes (e.g. scheduled jobs, outbox relays running in
+// several replicas).
+type Locker interface {
+	// TryLock acquires the lock for the given key without blocking. Returns ErrNotAcquired if the lock is
+	// currently held by another owner.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+	// Lock acquires the lock for the given key, blocking until acquired or context.Context is done.
+	Lock(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+	// Unlock releases the given Lease. Returns ErrLeaseLost if the lease is no longer valid.
+	Unlock(ctx context.Context, lease Lease) error
+	// Renew extends the given Lease by its TTL. Returns ErrLeaseLost if the lease is no longer valid.
+	Renew(ctx context.Context, lease Lease) (Lease, error)
+}
+
+type tryLockFunc func(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+
+// lockWithRetry calls tryLock every interval until the lock is acquired or context.Context is done.
+func lockWithRetry(ctx context.Context, interval time.Duration, tryLock tryLockFunc, key string,
+	ttl time.Duration) (Lease, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		lease, err := tryLock(ctx, key, ttl)
+		if err == nil || !errors.Is(err, ErrNotAcquired) {
+			return lease, err
+		}
+		select {
+		case <-ctx.Done():
+			return Lease{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// KeepAlive renews the given Lease every half of its TTL until the given context.Context is done.
+//
+// Returns a context.Context cancelled once the lease could not be renewed, so work depending on the lock is
+// able to stop. Call the returned context.CancelFunc to stop renewals.
+func KeepAlive(ctx context.Context, locker Locker, lease Lease) (context.Context, context.CancelFunc) {
+	leaseCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		ticker := time.NewTicker(lease.TTL / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leaseCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			var err error
+			if lease, err = locker.Renew(leaseCtx, lease); err != nil {
+				return
+			}
+		}
+	}()
+	return leaseCtx, cancel
+}
@@ -0,0 +1,169 @@
+package locking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hadroncorp/geck/data/caching"
+	"github.com/hadroncorp/geck/identifier"
+)
+
+// cacheLockRecord the lock state stored in cache. Released locks keep their entry (with an empty owner) so
+// fencing tokens keep growing across acquisitions.
+type cacheLockRecord struct {
+	ID         string `json:"id"`
+	Token      int64  `json:"token"`
+	ExpireTime int64  `json:"expire_time"`
+}
+
+func (r cacheLockRecord) isHeld(now time.Time) bool {
+	return r.ID != "" && now.UnixNano() < r.ExpireTime
+}
+
+// LockerCacheEmbedded is the process-local Locker implementation using an in-process caching.AtomicCache (e.g.
+// caching.CacheEmbedded) as state. Only guarantees mutual exclusion between instances sharing the same cache
+// within the running process; use LockerPostgres to coordinate several replicas.
+//
+// Fencing tokens are NOT guaranteed: tokens restart at 1 whenever the cache evicts or expires the lock entry, so
+// external systems must not rely on them to reject stale owners.
+type LockerCacheEmbedded struct {
+	Config    Config
+	Cache     caching.AtomicCache
+	IDFactory identifier.Factory
+}
+
+var _ Locker = (*LockerCacheEmbedded)(nil)
+
+// NewLockerCacheEmbedded allocates a new LockerCacheEmbedded instance.
+func NewLockerCacheEmbedded(cfg Config, cache caching.AtomicCache, factory identifier.Factory) LockerCacheEmbedded {
+	return LockerCacheEmbedded{
+		Config:    cfg,
+		Cache:     cache,
+		IDFactory: factory,
+	}
+}
+
+// TryLock acquires the lock for the given key without blocking. Returns ErrNotAcquired if the lock is
+// currently held by another owner.
+func (l LockerCacheEmbedded) TryLock(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	id, err := l.IDFactory.NewIdentifier()
+	if err != nil {
+		return Lease{}, err
+	}
+	now := time.Now().UTC()
+	record := cacheLockRecord{
+		ID:         id,
+		Token:      1,
+		ExpireTime: now.Add(ttl).UnixNano(),
+	}
+	cacheKey := l.Config.KeyPrefix + key
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return Lease{}, err
+	}
+	ok, err := l.Cache.SetIfAbsent(ctx, cacheKey, raw)
+	if err != nil {
+		return Lease{}, err
+	} else if ok {
+		return newCacheLease(key, record, ttl), nil
+	}
+
+	current, currentRaw, err := l.get(ctx, cacheKey)
+	if err != nil && errors.Is(err, caching.ErrEntryNotFound) {
+		// released and evicted in between, let caller retry
+		return Lease{}, ErrNotAcquired
+	} else if err != nil {
+		return Lease{}, err
+	} else if current.isHeld(now) {
+		return Lease{}, ErrNotAcquired
+	}
+
+	record.Token = current.Token + 1
+	if raw, err = json.Marshal(record); err != nil {
+		return Lease{}, err
+	}
+	if ok, err = l.Cache.CompareAndSwap(ctx, cacheKey, currentRaw, raw); err != nil {
+		return Lease{}, err
+	} else if !ok {
+		return Lease{}, ErrNotAcquired
+	}
+	return newCacheLease(key, record, ttl), nil
+}
+
+// Lock acquires the lock for the given key, blocking until acquired or context.Context is done.
+func (l LockerCacheEmbedded) Lock(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	return lockWithRetry(ctx, l.Config.RetryInterval, l.TryLock, key, ttl)
+}
+
+// Unlock releases the given Lease. Returns ErrLeaseLost if the lease is no longer valid.
+func (l LockerCacheEmbedded) Unlock(ctx context.Context, lease Lease) error {
+	_, err := l.swap(ctx, lease, func(current cacheLockRecord) cacheLockRecord {
+		return cacheLockRecord{
+			Token: current.Token,
+		}
+	})
+	return err
+}
+
+// Renew extends the given Lease by its TTL. Returns ErrLeaseLost if the lease is no longer valid.
+func (l LockerCacheEmbedded) Renew(ctx context.Context, lease Lease) (Lease, error) {
+	record, err := l.swap(ctx, lease, func(current cacheLockRecord) cacheLockRecord {
+		current.ExpireTime = time.Now().UTC().Add(lease.TTL).UnixNano()
+		return current
+	})
+	if err != nil {
+		return Lease{}, err
+	}
+	return newCacheLease(lease.Key, record, lease.TTL), nil
+}
+
+// swap replaces the lock record held by lease with the one produced by updateFunc.
+func (l LockerCacheEmbedded) swap(ctx context.Context, lease Lease,
+	updateFunc func(current cacheLockRecord) cacheLockRecord) (cacheLockRecord, error) {
+	cacheKey := l.Config.KeyPrefix + lease.Key
+	current, currentRaw, err := l.get(ctx, cacheKey)
+	if err != nil && errors.Is(err, caching.ErrEntryNotFound) {
+		return cacheLockRecord{}, ErrLeaseLost
+	} else if err != nil {
+		return cacheLockRecord{}, err
+	} else if current.ID != lease.ID || !current.isHeld(time.Now().UTC()) {
+		return cacheLockRecord{}, ErrLeaseLost
+	}
+
+	record := updateFunc(current)
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return cacheLockRecord{}, err
+	}
+	ok, err := l.Cache.CompareAndSwap(ctx, cacheKey, currentRaw, raw)
+	if err != nil {
+		return cacheLockRecord{}, err
+	} else if !ok {
+		return cacheLockRecord{}, ErrLeaseLost
+	}
+	return record, nil
+}
+
+func (l LockerCacheEmbedded) get(ctx context.Context, cacheKey string) (cacheLockRecord, []byte, error) {
+	raw, err := l.Cache.Get(ctx, cacheKey)
+	if err != nil {
+		return cacheLockRecord{}, nil, err
+	}
+	record := cacheLockRecord{}
+	if err = json.Unmarshal(raw, &record); err != nil {
+		return cacheLockRecord{}, nil, err
+	}
+	return record, raw, nil
+}
+
+func newCacheLease(key string, record cacheLockRecord, ttl time.Duration) Lease {
+	return Lease{
+		Key:        key,
+		ID:         record.ID,
+		Token:      record.Token,
+		TTL:        ttl,
+		ExpireTime: time.Unix(0, record.ExpireTime).UTC(),
+	}
+}
@@ -0,0 +1,93 @@
+package locking
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hadroncorp/geck/identifier"
+)
+
+// LockerMemory is the in-process Locker implementation. Only guarantees mutual exclusion within the running
+// process, useful for single-replica deployments and testing.
+type LockerMemory struct {
+	Config    Config
+	IDFactory identifier.Factory
+
+	mu     *sync.Mutex
+	leases map[string]Lease
+	tokens map[string]int64
+}
+
+var _ Locker = (*LockerMemory)(nil)
+
+// NewLockerMemory allocates a new LockerMemory instance.
+func NewLockerMemory(cfg Config, factory identifier.Factory) LockerMemory {
+	return LockerMemory{
+		Config:    cfg,
+		IDFactory: factory,
+		mu:        &sync.Mutex{},
+		leases:    make(map[string]Lease),
+		tokens:    make(map[string]int64),
+	}
+}
+
+// TryLock acquires the lock for the given key without blocking. Returns ErrNotAcquired if the lock is
+// currently held by another owner.
+func (l LockerMemory) TryLock(_ context.Context, key string, ttl time.Duration) (Lease, error) {
+	id, err := l.IDFactory.NewIdentifier()
+	if err != nil {
+		return Lease{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now().UTC()
+	if current, ok := l.leases[key]; ok && !current.IsExpired(now) {
+		return Lease{}, ErrNotAcquired
+	}
+	l.tokens[key]++
+	lease := Lease{
+		Key:        key,
+		ID:         id,
+		Token:      l.tokens[key],
+		TTL:        ttl,
+		ExpireTime: now.Add(ttl),
+	}
+	l.leases[key] = lease
+	return lease, nil
+}
+
+// Lock acquires the lock for the given key, blocking until acquired or context.Context is done.
+func (l LockerMemory) Lock(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	return lockWithRetry(ctx, l.Config.RetryInterval, l.TryLock, key, ttl)
+}
+
+// Unlock releases the given Lease. Returns ErrLeaseLost if the lease is no longer valid.
+func (l LockerMemory) Unlock(_ context.Context, lease Lease) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.isHeld(lease, time.Now().UTC()) {
+		return ErrLeaseLost
+	}
+	delete(l.leases, lease.Key)
+	return nil
+}
+
+// Renew extends the given Lease by its TTL. Returns ErrLeaseLost if the lease is no longer valid.
+func (l LockerMemory) Renew(_ context.Context, lease Lease) (Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now().UTC()
+	if !l.isHeld(lease, now) {
+		return Lease{}, ErrLeaseLost
+	}
+	lease.ExpireTime = now.Add(lease.TTL)
+	l.leases[lease.Key] = lease
+	return lease, nil
+}
+
+func (l LockerMemory) isHeld(lease Lease, now time.Time) bool {
+	current, ok := l.leases[lease.Key]
+	return ok && current.ID == lease.ID && !current.IsExpired(now)
+}
@@ -0,0 +1,156 @@
+package locking
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	gecksql "github.com/hadroncorp/geck/data/sql"
+	"github.com/hadroncorp/geck/identifier"
+)
+
+// postgresLockHolder a transaction holding a PostgreSQL advisory lock.
+type postgresLockHolder struct {
+	tx    *sql.Tx
+	timer *time.Timer
+}
+
+// LockerPostgres is the Locker implementation using PostgreSQL transaction-level advisory locks
+// (pg_try_advisory_xact_lock).
+//
+// Each lease pins a database transaction (and thus, a pooled connection) until released or expired. Hence, the
+// connection pool MUST be sized for both the maximum number of concurrent leases and the regular workload;
+// otherwise, queries (and TryLock calls) block until a lease is released. Expired leases get their transaction
+// rolled back, releasing the advisory lock. Fencing tokens are the transaction identifiers (txid_current), which
+// grow monotonically across the whole database cluster.
+type LockerPostgres struct {
+	Config    Config
+	Client    gecksql.Client
+	IDFactory identifier.Factory
+
+	mu      *sync.Mutex
+	holders map[string]postgresLockHolder
+}
+
+var _ Locker = (*LockerPostgres)(nil)
+
+// NewLockerPostgres allocates a new LockerPostgres instance.
+func NewLockerPostgres(cfg Config, client gecksql.Client, factory identifier.Factory) LockerPostgres {
+	return LockerPostgres{
+		Config:    cfg,
+		Client:    client,
+		IDFactory: factory,
+		mu:        &sync.Mutex{},
+		holders:   make(map[string]postgresLockHolder),
+	}
+}
+
+// TryLock acquires the lock for the given key without blocking. Returns ErrNotAcquired if the lock is
+// currently held by another owner.
+func (l LockerPostgres) TryLock(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	id, err := l.IDFactory.NewIdentifier()
+	if err != nil {
+		return Lease{}, err
+	}
+	// transaction MUST outlive the given context, otherwise, database/sql would roll it back (releasing the lock)
+	// as soon as the context is done.
+	tx, err := l.Client.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return Lease{}, err
+	}
+
+	var acquired bool
+	var token int64
+	row := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1), txid_current()", l.hashKey(key))
+	if err = row.Scan(&acquired, &token); err != nil {
+		_ = tx.Rollback()
+		return Lease{}, err
+	} else if !acquired {
+		_ = tx.Rollback()
+		return Lease{}, ErrNotAcquired
+	}
+
+	lease := Lease{
+		Key:        key,
+		ID:         id,
+		Token:      token,
+		TTL:        ttl,
+		ExpireTime: time.Now().UTC().Add(ttl),
+	}
+	l.mu.Lock()
+	l.holders[id] = postgresLockHolder{
+		tx:    tx,
+		timer: time.AfterFunc(ttl, func() { l.release(id) }),
+	}
+	l.mu.Unlock()
+	return lease, nil
+}
+
+// Lock acquires the lock for the given key, blocking until acquired or context.Context is done.
+func (l LockerPostgres) Lock(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	return lockWithRetry(ctx, l.Config.RetryInterval, l.TryLock, key, ttl)
+}
+
+// Unlock releases the given Lease. Returns ErrLeaseLost if the lease is no longer valid.
+func (l LockerPostgres) Unlock(_ context.Context, lease Lease) error {
+	if !l.release(lease.ID) {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Renew extends the given Lease by its TTL. Returns ErrLeaseLost if the lease is no longer valid.
+func (l LockerPostgres) Renew(ctx context.Context, lease Lease) (Lease, error) {
+	l.mu.Lock()
+	holder, ok := l.holders[lease.ID]
+	if !ok || !holder.timer.Stop() {
+		l.mu.Unlock()
+		return Lease{}, ErrLeaseLost
+	}
+	l.mu.Unlock()
+
+	// verifies the connection holding the lock is still alive
+	if _, err := holder.tx.ExecContext(ctx, "SELECT 1"); err != nil {
+		l.release(lease.ID)
+		return Lease{}, ErrLeaseLost
+	}
+	holder.timer.Reset(lease.TTL)
+	lease.ExpireTime = time.Now().UTC().Add(lease.TTL)
+	return lease, nil
+}
+
+// release rolls back the transaction holding the lock. Returns false if no transaction was found.
+func (l LockerPostgres) release(id string) bool {
+	l.mu.Lock()
+	holder, ok := l.holders[id]
+	delete(l.holders, id)
+	l.mu.Unlock()
+	if !ok {
+		return false
+	}
+	holder.timer.Stop()
+	_ = holder.tx.Rollback()
+	return true
+}
+
+func (l LockerPostgres) hashKey(key string) int64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(l.Config.KeyPrefix + key))
+	return int64(hasher.Sum64())
+}
+
+// Close releases every lease held by this instance.
+func (l LockerPostgres) Close() error {
+	l.mu.Lock()
+	ids := make([]string, 0, len(l.holders))
+	for id := range l.holders {
+		ids = append(ids, id)
+	}
+	l.mu.Unlock()
+	for _, id := range ids {
+		l.release(id)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package locking_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/allegro/bigcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/caching"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/locking"
+)
+
+func TestLocker(t *testing.T) {
+	cfg := locking.Config{
+		RetryInterval: time.Millisecond * 5,
+		KeyPrefix:     "lock#",
+	}
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	require.NoError(t, err)
+	defer db.Close()
+
+	lockers := map[string]locking.Locker{
+		"memory": locking.NewLockerMemory(cfg, identifier.NewFactoryUUID()),
+		"cache": locking.NewLockerCacheEmbedded(cfg, caching.NewCacheEmbedded(db, caching.BigCacheConfig{}, nil),
+			identifier.NewFactoryUUID()),
+	}
+	for name, locker := range lockers {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			lease, err := locker.TryLock(ctx, "job", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), lease.Token)
+
+			_, err = locker.TryLock(ctx, "job", time.Minute)
+			assert.ErrorIs(t, err, locking.ErrNotAcquired)
+
+			timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond*20)
+			_, err = locker.Lock(timeoutCtx, "job", time.Minute)
+			cancel()
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+			renewed, err := locker.Renew(ctx, lease)
+			require.NoError(t, err)
+			assert.Equal(t, lease.Token, renewed.Token)
+			assert.False(t, renewed.ExpireTime.Before(lease.ExpireTime))
+
+			require.NoError(t, locker.Unlock(ctx, renewed))
+			assert.ErrorIs(t, locker.Unlock(ctx, renewed), locking.ErrLeaseLost)
+
+			// expired leases are taken over, fencing token keeps growing
+			expiring, err := locker.Lock(ctx, "job", time.Millisecond*10)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), expiring.Token)
+			next, err := locker.Lock(ctx, "job", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), next.Token)
+			_, err = locker.Renew(ctx, expiring)
+			assert.ErrorIs(t, err, locking.ErrLeaseLost)
+		})
+	}
+}
+
+func TestLockerPostgres(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	cfg := locking.Config{KeyPrefix: "lock#"}
+	locker := locking.NewLockerPostgres(cfg, db, identifier.NewFactoryUUID())
+	ctx := context.Background()
+	columns := []string{"pg_try_advisory_xact_lock", "txid_current"}
+
+	// acquire
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(true, int64(42)))
+	lease, err := locker.TryLock(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), lease.Token)
+
+	// contention
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(false, int64(43)))
+	mock.ExpectRollback()
+	_, err = locker.TryLock(ctx, "job", time.Minute)
+	assert.ErrorIs(t, err, locking.ErrNotAcquired)
+
+	// release
+	mock.ExpectRollback()
+	require.NoError(t, locker.Unlock(ctx, lease))
+	assert.ErrorIs(t, locker.Unlock(ctx, lease), locking.ErrLeaseLost)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
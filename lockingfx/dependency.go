@@ -0,0 +1,49 @@
+package lockingfx
+
+import (
+	"context"
+
+	"github.com/caarlos0/env/v11"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/locking"
+)
+
+var LockerMemoryModule = fx.Module("locker_memory",
+	fx.Provide(
+		env.ParseAs[locking.Config],
+		fx.Annotate(
+			locking.NewLockerMemory,
+			fx.As(new(locking.Locker)),
+		),
+	),
+)
+
+var LockerCacheEmbeddedModule = fx.Module("locker_cache_embedded",
+	fx.Provide(
+		env.ParseAs[locking.Config],
+		fx.Annotate(
+			locking.NewLockerCacheEmbedded,
+			fx.As(new(locking.Locker)),
+		),
+	),
+)
+
+var LockerPostgresModule = fx.Module("locker_postgres",
+	fx.Provide(
+		env.ParseAs[locking.Config],
+		locking.NewLockerPostgres,
+		func(locker locking.LockerPostgres) locking.Locker {
+			return locker
+		},
+	),
+	fx.Invoke(
+		func(lifecycle fx.Lifecycle, locker locking.LockerPostgres) {
+			lifecycle.Append(fx.Hook{
+				OnStop: func(_ context.Context) error {
+					return locker.Close()
+				},
+			})
+		},
+	),
+)
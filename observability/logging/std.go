@@ -66,8 +66,13 @@ func (s *StdEvent) Write(msg string) {
 //
 // Uses context.Context to retrieve (and possibly append) useful information like trace identifiers.
 func (s *StdEvent) WriteWithCtx(ctx context.Context, msg string) {
-	if span, _ := tracing.GetSpanFromContext(ctx); span != "" {
-		s.WithField("span_id", span)
+	if span, err := tracing.GetSpanContextFromContext(ctx); err == nil {
+		s.WithField("trace_id", span.TraceID).WithField("span_id", span.SpanID)
+		if span.ParentSpanID != "" {
+			s.WithField("parent_span_id", span.ParentSpanID)
+		}
+	} else if spanID, _ := tracing.GetSpanFromContext(ctx); spanID != "" {
+		s.WithField("span_id", spanID)
 	}
 	s.Write(msg)
 }
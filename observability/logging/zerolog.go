@@ -18,10 +18,17 @@ type TracerZerologHook struct {
 
 var _ zerolog.Hook = (*TracerZerologHook)(nil)
 
+// Run appends trace_id and span_id fields (plus parent_span_id, if any) of the tracing.SpanContext found in the
+// event context.
 func (t TracerZerologHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
 	ctx := e.GetCtx()
-	if span, _ := tracing.GetSpanFromContext(ctx); span != "" {
-		e.Str("span_id", span)
+	if span, err := tracing.GetSpanContextFromContext(ctx); err == nil {
+		e.Str("trace_id", span.TraceID).Str("span_id", span.SpanID)
+		if span.ParentSpanID != "" {
+			e.Str("parent_span_id", span.ParentSpanID)
+		}
+	} else if spanID, _ := tracing.GetSpanFromContext(ctx); spanID != "" {
+		e.Str("span_id", spanID)
 	}
 }
 
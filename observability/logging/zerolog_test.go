@@ -2,6 +2,7 @@ package logging_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 
 	"github.com/hadroncorp/geck/application"
 	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/observability/tracing"
 	"github.com/hadroncorp/geck/versioning"
 )
 
@@ -46,3 +48,15 @@ func TestZerologLogger_Level(t *testing.T) {
 	out = buf.String()
 	assert.Equal(t, "{\"level\":\"error\",\"application_name\":\"foo-app\",\"application_environment\":\"dev\",\"application_version\":\"v0.0.1-alpha\",\"error\":\"some error\",\"field\":true,\"message\":\"some message\"}\n", out)
 }
+
+func TestTracerZerologHook(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	logger := logging.NewZerologLoggerAdapter(logging.NewApplicationZerologLogger(application.Config{}, buf))
+	ctx := tracing.NewContextWithSpan(context.Background(), tracing.SpanContext{
+		TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:       "00f067aa0ba902b7",
+		ParentSpanID: "a2fb4a1d1a96d312",
+	})
+	logger.Info().WriteWithCtx(ctx, "some message")
+	assert.Equal(t, "{\"level\":\"info\",\"application_name\":\"\",\"application_environment\":\"\",\"application_version\":\"\",\"trace_id\":\"4bf92f3577b34da6a3ce929d0e0e4736\",\"span_id\":\"00f067aa0ba902b7\",\"parent_span_id\":\"a2fb4a1d1a96d312\",\"message\":\"some message\"}\n", buf.String())
+}
@@ -0,0 +1,8 @@
+package tracing
+
+// Config configuration structure for trace context propagation.
+type Config struct {
+	// Propagators trace context formats read from and written to carriers (tracecontext, b3 and b3multi).
+	// Incoming trace context is read from the first format found, while every format is written.
+	Propagators []string `env:"TRACING_PROPAGATORS" envDefault:"tracecontext"`
+}
@@ -2,24 +2,28 @@ package tracing
 
 import (
 	"context"
-
-	"github.com/hadroncorp/geck/identifier"
-)
-
-var (
-	spanFactoryID identifier.FactoryUUID
 )
 
 type SpanContextType string
 
-const SpanContextKey SpanContextType = "geck.tracing.span_id"
+const (
+	// SpanContextKey context key holding the span identifier of the current operation.
+	SpanContextKey SpanContextType = "geck.tracing.span_id"
+	// TraceContextKey context key holding the SpanContext of the current operation.
+	TraceContextKey SpanContextType = "geck.tracing.span_context"
+)
 
-// NewTracedContext appends a generated span id to the given context.Context.
-//
-// Uses identifier.FactoryUUID as identifier generation algorithm.
+// NewTracedContext appends a new span to the given context.Context. The span is a child of the span found in ctx
+// (e.g. a remote span extracted by a Propagator); otherwise, a new trace is started.
 func NewTracedContext(ctx context.Context) context.Context {
-	id, _ := spanFactoryID.NewIdentifier()
-	return context.WithValue(ctx, SpanContextKey, id)
+	parent, _ := GetSpanContextFromContext(ctx)
+	return NewContextWithSpan(ctx, NewSpanContext(parent))
+}
+
+// NewContextWithSpan appends span to the given context.Context.
+func NewContextWithSpan(ctx context.Context, span SpanContext) context.Context {
+	ctx = context.WithValue(ctx, TraceContextKey, span)
+	return context.WithValue(ctx, SpanContextKey, span.SpanID)
 }
 
 // GetSpanFromContext retrieves a span identifier from the given context.Context. Produces ErrSpanNotFound if
@@ -31,3 +35,13 @@ func GetSpanFromContext(ctx context.Context) (string, error) {
 	}
 	return span, nil
 }
+
+// GetSpanContextFromContext retrieves a SpanContext from the given context.Context. Produces ErrSpanNotFound if
+// not found.
+func GetSpanContextFromContext(ctx context.Context) (SpanContext, error) {
+	span, ok := ctx.Value(TraceContextKey).(SpanContext)
+	if !ok {
+		return SpanContext{}, ErrSpanNotFound
+	}
+	return span, nil
+}
@@ -2,5 +2,9 @@ package tracing
 
 import "errors"
 
-// ErrSpanNotFound no trace id was found.
-var ErrSpanNotFound = errors.New("span not found")
+var (
+	// ErrSpanNotFound no trace id was found.
+	ErrSpanNotFound = errors.New("span not found")
+	// ErrUnknownPropagator the trace context format is not supported.
+	ErrUnknownPropagator = errors.New("unknown propagator")
+)
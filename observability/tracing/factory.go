@@ -12,6 +12,10 @@ type TraceFactory interface {
 
 // TraceFactoryTemplate is the default implementation of TraceFactory. Inject identifier.Factory to set
 // identifier generation algorithm. Use NewTracedContext if you do not want to use a custom identifier generator.
+//
+// Generated identifiers are used as span identifiers only if they follow the W3C Trace Context format (16 lowercase
+// hex characters, not all zeros), so spans remain propagable (see Propagator). Otherwise (e.g. UUIDs), a random
+// span identifier is used instead.
 type TraceFactoryTemplate struct {
 	FactoryID identifier.Factory
 }
@@ -25,6 +29,10 @@ func NewTraceFactoryTemplate(factory identifier.Factory) TraceFactoryTemplate {
 }
 
 func (t TraceFactoryTemplate) NewTracedContext(ctx context.Context) context.Context {
-	id, _ := t.FactoryID.NewIdentifier()
-	return context.WithValue(ctx, SpanContextKey, id)
+	parent, _ := GetSpanContextFromContext(ctx)
+	span := NewSpanContext(parent)
+	if id, err := t.FactoryID.NewIdentifier(); err == nil && isValidTraceIdentifier(id, spanIDLength) {
+		span.SpanID = id
+	}
+	return NewContextWithSpan(ctx, span)
 }
@@ -0,0 +1,53 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/tracing"
+)
+
+type stubFactoryID string
+
+func (s stubFactoryID) NewIdentifier() (string, error) {
+	return string(s), nil
+}
+
+func TestTraceFactoryTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		factory    identifier.Factory
+		wantSpanID string
+	}{
+		{name: "w3c identifier", factory: stubFactoryID("00f067aa0ba902b7"), wantSpanID: "00f067aa0ba902b7"},
+		{name: "uuid", factory: identifier.NewFactoryUUID()},
+		{name: "uppercase hex", factory: stubFactoryID("00F067AA0BA902B7")},
+		{name: "zero", factory: stubFactoryID("0000000000000000")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := tracing.NewTraceFactoryTemplate(tt.factory)
+			ctx := factory.NewTracedContext(context.Background())
+			span, err := tracing.GetSpanContextFromContext(ctx)
+			require.NoError(t, err)
+			require.True(t, span.IsValid(), "span id %q", span.SpanID)
+			if tt.wantSpanID != "" {
+				assert.Equal(t, tt.wantSpanID, span.SpanID)
+			}
+
+			header := http.Header{}
+			tracing.PropagatorW3C{}.Inject(span, header)
+			assert.Equal(t, "00-"+span.TraceID+"-"+span.SpanID+"-01", header.Get(tracing.HeaderTraceParent))
+
+			child, err := tracing.GetSpanContextFromContext(tracing.NewTracedContext(ctx))
+			require.NoError(t, err)
+			assert.Equal(t, span.TraceID, child.TraceID)
+			assert.Equal(t, span.SpanID, child.ParentSpanID)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trace context headers.
+const (
+	// HeaderTraceParent W3C Trace Context parent header (version-trace_id-parent_id-flags).
+	HeaderTraceParent = "traceparent"
+	// HeaderTraceState W3C Trace Context vendor-specific state header.
+	HeaderTraceState = "tracestate"
+	// HeaderB3 B3 single header (trace_id-span_id-sampled-parent_span_id).
+	HeaderB3 = "b3"
+	// HeaderB3TraceID B3 multiple headers trace identifier.
+	HeaderB3TraceID = "X-B3-TraceId"
+	// HeaderB3SpanID B3 multiple headers span identifier.
+	HeaderB3SpanID = "X-B3-SpanId"
+	// HeaderB3ParentSpanID B3 multiple headers parent span identifier.
+	HeaderB3ParentSpanID = "X-B3-ParentSpanId"
+	// HeaderB3Sampled B3 multiple headers sampling decision.
+	HeaderB3Sampled = "X-B3-Sampled"
+	// HeaderB3Flags B3 multiple headers debug flag.
+	HeaderB3Flags = "X-B3-Flags"
+)
+
+const (
+	traceParentVersion      = "00"
+	traceParentFlagsSampled = "01"
+	traceParentFlagsNone    = "00"
+)
+
+// Carrier holds propagated trace context (e.g. http.Header).
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// Propagator reads and writes SpanContext values from and to a Carrier.
+type Propagator interface {
+	// Extract reads the SpanContext of the caller from carrier. Returns false if carrier holds no valid trace
+	// context.
+	Extract(carrier Carrier) (SpanContext, bool)
+	// Inject writes span into carrier. Invalid spans are skipped.
+	Inject(span SpanContext, carrier Carrier)
+}
+
+// PropagatorW3C is the W3C Trace Context implementation of Propagator (traceparent and tracestate headers).
+type PropagatorW3C struct{}
+
+var _ Propagator = PropagatorW3C{}
+
+func (p PropagatorW3C) Extract(carrier Carrier) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(carrier.Get(HeaderTraceParent)), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || !isHex(parts[0]) ||
+		(parts[0] == traceParentVersion && len(parts) != 4) || len(parts[3]) != 2 || !isHex(parts[3]) {
+		return SpanContext{}, false
+	}
+	span := SpanContext{
+		TraceID: parts[1],
+		SpanID:  parts[2],
+		// only the sampled bit (least significant) of trace flags is defined
+		Sampled:    strings.ContainsAny(parts[3][1:], "13579bdf"),
+		TraceState: strings.TrimSpace(carrier.Get(HeaderTraceState)),
+	}
+	return span, span.IsValid()
+}
+
+func (p PropagatorW3C) Inject(span SpanContext, carrier Carrier) {
+	if !span.IsValid() {
+		return
+	}
+	flags := traceParentFlagsNone
+	if span.Sampled {
+		flags = traceParentFlagsSampled
+	}
+	carrier.Set(HeaderTraceParent, fmt.Sprintf("%s-%s-%s-%s", traceParentVersion, span.TraceID, span.SpanID, flags))
+	if span.TraceState != "" {
+		carrier.Set(HeaderTraceState, span.TraceState)
+	}
+}
+
+// PropagatorB3 is the Zipkin B3 implementation of Propagator. Both single (b3) and multiple (X-B3-*) headers are
+// extracted, while SingleHeader selects the format injected.
+type PropagatorB3 struct {
+	SingleHeader bool
+}
+
+var _ Propagator = PropagatorB3{}
+
+func (p PropagatorB3) Extract(carrier Carrier) (SpanContext, bool) {
+	if single := strings.TrimSpace(carrier.Get(HeaderB3)); single != "" {
+		parts := strings.Split(single, "-")
+		if len(parts) < 2 {
+			// sampling-only header (e.g. b3: 0)
+			return SpanContext{}, false
+		}
+		span := SpanContext{
+			TraceID: padTraceID(parts[0]),
+			SpanID:  parts[1],
+			Sampled: len(parts) < 3 || parts[2] != "0",
+		}
+		return span, span.IsValid()
+	}
+	sampled := strings.ToLower(carrier.Get(HeaderB3Sampled))
+	span := SpanContext{
+		TraceID: padTraceID(carrier.Get(HeaderB3TraceID)),
+		SpanID:  carrier.Get(HeaderB3SpanID),
+		Sampled: carrier.Get(HeaderB3Flags) == "1" || (sampled != "0" && sampled != "false"),
+	}
+	return span, span.IsValid()
+}
+
+func (p PropagatorB3) Inject(span SpanContext, carrier Carrier) {
+	if !span.IsValid() {
+		return
+	}
+	sampled := "0"
+	if span.Sampled {
+		sampled = "1"
+	}
+	if p.SingleHeader {
+		carrier.Set(HeaderB3, span.TraceID+"-"+span.SpanID+"-"+sampled)
+		return
+	}
+	carrier.Set(HeaderB3TraceID, span.TraceID)
+	carrier.Set(HeaderB3SpanID, span.SpanID)
+	carrier.Set(HeaderB3Sampled, sampled)
+}
+
+// PropagatorComposite is a Propagator combining several formats. Extraction uses the first format holding a valid
+// trace context, while injection writes every format.
+type PropagatorComposite []Propagator
+
+var _ Propagator = PropagatorComposite{}
+
+func (p PropagatorComposite) Extract(carrier Carrier) (SpanContext, bool) {
+	for _, propagator := range p {
+		if span, ok := propagator.Extract(carrier); ok {
+			return span, true
+		}
+	}
+	return SpanContext{}, false
+}
+
+func (p PropagatorComposite) Inject(span SpanContext, carrier Carrier) {
+	for _, propagator := range p {
+		propagator.Inject(span, carrier)
+	}
+}
+
+// NewPropagator allocates a Propagator from the formats in Config (tracecontext, b3 and b3multi). Produces
+// ErrUnknownPropagator if a format is not supported.
+func NewPropagator(cfg Config) (Propagator, error) {
+	propagators := make(PropagatorComposite, 0, len(cfg.Propagators))
+	for _, name := range cfg.Propagators {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "tracecontext":
+			propagators = append(propagators, PropagatorW3C{})
+		case "b3":
+			propagators = append(propagators, PropagatorB3{SingleHeader: true})
+		case "b3multi":
+			propagators = append(propagators, PropagatorB3{})
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPropagator, name)
+		}
+	}
+	if len(propagators) == 1 {
+		return propagators[0], nil
+	}
+	return propagators, nil
+}
+
+// padTraceID left-pads 64-bit B3 trace identifiers to 128 bits.
+func padTraceID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if len(id) == spanIDLength {
+		return strings.Repeat("0", spanIDLength) + id
+	}
+	return id
+}
+
+func isHex(value string) bool {
+	for _, char := range value {
+		if (char < '0' || char > '9') && (char < 'a' || char > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/observability/tracing"
+)
+
+func TestPropagatorW3C(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceParent string
+		traceState  string
+		want        tracing.SpanContext
+		wantOk      bool
+	}{
+		{
+			name:        "sampled",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceState:  "congo=t61rcWkgMzE",
+			want: tracing.SpanContext{
+				TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:     "00f067aa0ba902b7",
+				Sampled:    true,
+				TraceState: "congo=t61rcWkgMzE",
+			},
+			wantOk: true,
+		},
+		{
+			name:        "not sampled",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			want: tracing.SpanContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+			},
+			wantOk: true,
+		},
+		{
+			name:        "future version",
+			traceParent: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-extra",
+			want: tracing.SpanContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+				Sampled: true,
+			},
+			wantOk: true,
+		},
+		{
+			name:        "zero trace id",
+			traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "uppercase",
+			traceParent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "invalid version",
+			traceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name:        "extra fields on version 00",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		},
+		{
+			name: "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(tracing.HeaderTraceParent, tt.traceParent)
+			header.Set(tracing.HeaderTraceState, tt.traceState)
+			got, ok := tracing.PropagatorW3C{}.Extract(header)
+			assert.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPropagator_Inject(t *testing.T) {
+	span := tracing.SpanContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE",
+	}
+	propagator, err := tracing.NewPropagator(tracing.Config{Propagators: []string{"tracecontext", "b3", "b3multi"}})
+	require.NoError(t, err)
+
+	header := http.Header{}
+	propagator.Inject(span, header)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", header.Get(tracing.HeaderTraceParent))
+	assert.Equal(t, "congo=t61rcWkgMzE", header.Get(tracing.HeaderTraceState))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1", header.Get(tracing.HeaderB3))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", header.Get(tracing.HeaderB3TraceID))
+	assert.Equal(t, "00f067aa0ba902b7", header.Get(tracing.HeaderB3SpanID))
+	assert.Equal(t, "1", header.Get(tracing.HeaderB3Sampled))
+
+	got, ok := propagator.Extract(header)
+	require.True(t, ok)
+	assert.Equal(t, span, got)
+
+	header = http.Header{}
+	propagator.Inject(tracing.SpanContext{TraceID: "foo", SpanID: "bar"}, header)
+	assert.Empty(t, header)
+
+	_, err = tracing.NewPropagator(tracing.Config{Propagators: []string{"jaeger"}})
+	assert.ErrorIs(t, err, tracing.ErrUnknownPropagator)
+}
+
+func TestPropagatorB3_Extract(t *testing.T) {
+	header := http.Header{}
+	header.Set(tracing.HeaderB3, "a3ce929d0e0e4736-00f067aa0ba902b7-0-a2fb4a1d1a96d312")
+	got, ok := tracing.PropagatorB3{}.Extract(header)
+	require.True(t, ok)
+	assert.Equal(t, tracing.SpanContext{
+		TraceID: "0000000000000000a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+	}, got)
+
+	header = http.Header{}
+	header.Set(tracing.HeaderB3TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	header.Set(tracing.HeaderB3SpanID, "00f067aa0ba902b7")
+	got, ok = tracing.PropagatorB3{}.Extract(header)
+	require.True(t, ok)
+	assert.True(t, got.Sampled)
+
+	header = http.Header{}
+	header.Set(tracing.HeaderB3, "0")
+	_, ok = tracing.PropagatorB3{}.Extract(header)
+	assert.False(t, ok)
+}
+
+func TestNewTracedContext(t *testing.T) {
+	ctx := tracing.NewTracedContext(context.Background())
+	root, err := tracing.GetSpanContextFromContext(ctx)
+	require.NoError(t, err)
+	assert.True(t, root.IsValid())
+	assert.True(t, root.Sampled)
+	assert.Empty(t, root.ParentSpanID)
+	spanID, err := tracing.GetSpanFromContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, root.SpanID, spanID)
+
+	child, err := tracing.GetSpanContextFromContext(tracing.NewTracedContext(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.Equal(t, root.SpanID, child.ParentSpanID)
+	assert.NotEqual(t, root.SpanID, child.SpanID)
+}
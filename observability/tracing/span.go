@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	traceIDLength = 32
+	spanIDLength  = 16
+)
+
+// SpanContext the trace context of an operation, as propagated between services (e.g. W3C traceparent and
+// tracestate headers).
+type SpanContext struct {
+	// TraceID identifier of the whole trace, 16 bytes encoded as 32 lowercase hex characters.
+	TraceID string
+	// SpanID identifier of the operation, 8 bytes encoded as 16 lowercase hex characters.
+	SpanID string
+	// ParentSpanID identifier of the caller operation. Empty if the span is the root of the trace.
+	ParentSpanID string
+	// Sampled indicates whether the caller may have recorded trace data.
+	Sampled bool
+	// TraceState vendor-specific trace data (tracestate header), propagated as-is.
+	TraceState string
+}
+
+// NewSpanContext allocates a child SpanContext of parent, keeping its trace identifier, sampling decision and
+// trace state. If parent is not valid, a new sampled trace is started.
+func NewSpanContext(parent SpanContext) SpanContext {
+	if !parent.IsValid() {
+		return SpanContext{
+			TraceID: NewTraceID(),
+			SpanID:  NewSpanID(),
+			Sampled: true,
+		}
+	}
+	return SpanContext{
+		TraceID:      parent.TraceID,
+		SpanID:       NewSpanID(),
+		ParentSpanID: parent.SpanID,
+		Sampled:      parent.Sampled,
+		TraceState:   parent.TraceState,
+	}
+}
+
+// IsValid indicates whether the trace and span identifiers follow the W3C Trace Context format.
+func (s SpanContext) IsValid() bool {
+	return isValidTraceIdentifier(s.TraceID, traceIDLength) && isValidTraceIdentifier(s.SpanID, spanIDLength)
+}
+
+// NewTraceID generates a random trace identifier.
+func NewTraceID() string {
+	return newRandomHex(traceIDLength / 2)
+}
+
+// NewSpanID generates a random span identifier.
+func NewSpanID() string {
+	return newRandomHex(spanIDLength / 2)
+}
+
+func newRandomHex(size int) string {
+	buf := make([]byte, size)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// isValidTraceIdentifier verifies id is a non-zero, lowercase hex string of the given length.
+func isValidTraceIdentifier(id string, length int) bool {
+	return len(id) == length && strings.Trim(id, "0") != "" && isHex(id)
+}
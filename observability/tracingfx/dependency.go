@@ -0,0 +1,17 @@
+package tracingfx
+
+import (
+	"github.com/caarlos0/env/v11"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/tracing"
+)
+
+// TracingModule provides the tracing.Propagator configured through tracing.Config. Transport components
+// (e.g. transport.NewTracerEcho, transport.ClientHTTP) use the W3C Trace Context format if not included.
+var TracingModule = fx.Module("tracing",
+	fx.Provide(
+		env.ParseAs[tracing.Config],
+		tracing.NewPropagator,
+	),
+)
@@ -0,0 +1,67 @@
+package operation
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// Authorizer restricts the Operation(s) principals are able to read, wait for and cancel through Manager.
+type Authorizer interface {
+	// Authorize verifies the principal of ctx is allowed to access op.
+	Authorize(ctx context.Context, op Operation) error
+	// Scope restricts criteria to the operations the principal of ctx is allowed to list.
+	Scope(ctx context.Context, criteria data.Criteria) (data.Criteria, error)
+}
+
+// AuthorizerOwner is the default Authorizer. Principals only access the operations they started
+// (Operation.CreateBy), while principals holding any of Authorities access every operation.
+//
+// Operations started without principal (e.g. by background jobs) are only accessible without principal as well.
+type AuthorizerOwner struct {
+	// Authorities authorities granting access to every operation (e.g. operations.admin).
+	Authorities []string
+}
+
+var _ Authorizer = AuthorizerOwner{}
+
+// NewAuthorizerOwner allocates a new AuthorizerOwner instance using Config.AdminAuthorities.
+func NewAuthorizerOwner(cfg Config) AuthorizerOwner {
+	return AuthorizerOwner{
+		Authorities: cfg.AdminAuthorities,
+	}
+}
+
+func (a AuthorizerOwner) Authorize(ctx context.Context, op Operation) error {
+	if owner, isAdmin := a.owner(ctx); !isAdmin && owner != op.CreateBy {
+		// existence of operations started by other principals is not disclosed
+		return systemerror.NewResourceNotFound[Operation](op.ID)
+	}
+	return nil
+}
+
+func (a AuthorizerOwner) Scope(ctx context.Context, criteria data.Criteria) (data.Criteria, error) {
+	owner, isAdmin := a.owner(ctx)
+	if isAdmin {
+		return criteria, nil
+	}
+	criteria.Filters = append(slices.Clone(criteria.Filters), data.CriteriaFilter{
+		Field:    FieldCreateBy,
+		Operator: data.OperatorEquals,
+		Value:    []any{owner},
+	})
+	return criteria, nil
+}
+
+// owner retrieves the Operation.CreateBy value of operations started by the principal of ctx (see
+// persistence.NewAuditable), and whether the principal holds any of Authorities.
+func (a AuthorizerOwner) owner(ctx context.Context) (string, bool) {
+	principal, err := security.GetPrincipalFromContext(ctx)
+	if err != nil {
+		return "", false
+	}
+	return principal.Username(), len(a.Authorities) > 0 && security.HasAnyAuthorities(ctx, a.Authorities) == nil
+}
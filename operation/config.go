@@ -0,0 +1,37 @@
+package operation
+
+import (
+	"errors"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// Config configuration structure for Manager and Repository instances.
+type Config struct {
+	// TTL time done operations are retained for. Retained indefinitely if zero.
+	TTL time.Duration `env:"OPERATION_TTL" envDefault:"24h"`
+	// CleanupInterval time between removals of expired operations. Disabled if zero.
+	CleanupInterval time.Duration `env:"OPERATION_CLEANUP_INTERVAL" envDefault:"1h"`
+	// WaitPollInterval time between status checks of Manager.Wait.
+	WaitPollInterval time.Duration `env:"OPERATION_WAIT_POLL_INTERVAL" envDefault:"250ms"`
+	// MaxWaitTimeout maximum time Manager.Wait blocks for.
+	MaxWaitTimeout time.Duration `env:"OPERATION_MAX_WAIT_TIMEOUT" envDefault:"30s"`
+	// AdminAuthorities authorities granting access to operations started by other principals (see AuthorizerOwner).
+	AdminAuthorities []string `env:"OPERATION_ADMIN_AUTHORITIES"`
+	// TableName table used by RepositoryPostgres.
+	TableName string `env:"OPERATION_TABLE_NAME" envDefault:"operations"`
+}
+
+// NewConfig allocates a new Config instance from environment variables. Returns an error if
+// Config.WaitPollInterval is not positive, as Manager.Wait would not be able to poll operations.
+func NewConfig() (Config, error) {
+	cfg, err := env.ParseAs[Config]()
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.WaitPollInterval <= 0 {
+		return Config{}, errors.New("operation: OPERATION_WAIT_POLL_INTERVAL must be positive")
+	}
+	return cfg, nil
+}
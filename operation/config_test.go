@@ -0,0 +1,21 @@
+package operation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/operation"
+)
+
+func TestNewConfig(t *testing.T) {
+	cfg, err := operation.NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, cfg.WaitPollInterval)
+
+	t.Setenv("OPERATION_WAIT_POLL_INTERVAL", "0s")
+	_, err = operation.NewConfig()
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package operation
+
+import (
+	"errors"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+var (
+	// ErrCancelled the operation was cancelled. Returned to workers of cancelled operations.
+	ErrCancelled = errors.New("operation: cancelled")
+	// errShutdown cancellation cause of workers interrupted by Manager shutdown.
+	errShutdown = errors.New("operation: manager shut down")
+)
+
+// newCancelledError allocates the Error of cancelled operations.
+func newCancelledError() *Error {
+	return &Error{
+		Status:  systemerror.StatusCancelled,
+		Reason:  "OPERATION_CANCELLED",
+		Message: "operation was cancelled",
+	}
+}
+
+// newInterruptedError allocates the Error of operations interrupted by Manager shutdown.
+func newInterruptedError() *Error {
+	return NewError(systemerror.NewUnavailable("OPERATION_INTERRUPTED",
+		"operation was interrupted by a service shutdown", nil))
+}
+
+// isVersionMismatch indicates if err was caused by a concurrent update (see systemerror.NewVersionMismatch).
+func isVersionMismatch(err error) bool {
+	var sysErr systemerror.Error
+	return errors.As(err, &sysErr) && sysErr.Reason() == "VERSION_MISMATCH"
+}
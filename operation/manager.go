@@ -0,0 +1,410 @@
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/shutdown"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// maxUpdateAttempts maximum number of attempts of updates conflicting with concurrent ones.
+const maxUpdateAttempts = 5
+
+// WorkerFunc performs the work of an Operation. The returned value is stored as the operation result (encoded as
+// JSON), while the returned error fails the operation.
+//
+// ctx is cancelled once the operation gets cancelled or the Manager shuts down, workers SHOULD return as soon as
+// possible then.
+type WorkerFunc func(ctx context.Context, worker *Worker) (any, error)
+
+// Worker is the handle of a running Operation, given to its WorkerFunc.
+type Worker struct {
+	manager     *Manager
+	operationID string
+	cancel      context.CancelCauseFunc
+}
+
+// OperationID retrieves the identifier of the running operation.
+func (w *Worker) OperationID() string {
+	return w.operationID
+}
+
+// UpdateProgress stores the completion progress of the operation. Returns ErrCancelled if the operation was
+// cancelled (e.g. by another replica), cancelling the worker context.Context as well.
+func (w *Worker) UpdateProgress(ctx context.Context, percent int, message string) error {
+	_, err := w.manager.update(ctx, w.operationID, func(op *Operation) (bool, error) {
+		if op.Done() {
+			return false, ErrCancelled
+		}
+		op.Progress = Progress{
+			Percent: min(max(percent, 0), 100),
+			Message: message,
+		}
+		return true, nil
+	})
+	if errors.Is(err, ErrCancelled) {
+		w.cancel(ErrCancelled)
+	}
+	return err
+}
+
+// Manager starts Operation(s), running their workers in the background and storing their state using
+// a Repository.
+//
+// Status changes are stored using optimistic concurrency, so operations might be read, waited for and cancelled
+// from any replica sharing the Repository. Workers are cancelled (and their operations failed) once the Manager
+// shuts down.
+//
+// Operations are read, waited for, listed and cancelled on behalf of the principal of the given context.Context,
+// restricted by an Authorizer (AuthorizerOwner by default).
+type Manager struct {
+	config     Config
+	repository Repository
+	authorizer Authorizer
+	factoryID  identifier.Factory
+	logger     logging.Logger
+
+	mu      sync.Mutex
+	closed  bool
+	running map[string]context.CancelCauseFunc
+	wg      sync.WaitGroup
+	stop    chan struct{}
+}
+
+// NewManagerParams Manager dependencies.
+type NewManagerParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	Config     Config
+	Repository Repository
+	// Authorizer restricts operations principals access to. Defaults to AuthorizerOwner.
+	Authorizer Authorizer `optional:"true"`
+	FactoryID  identifier.Factory
+	Logger     logging.Logger
+	// Coordinator stops the manager during shutdown.PhaseWorkers. The manager stops once the application stops if
+	// not provided.
+	Coordinator *shutdown.Coordinator `optional:"true"`
+}
+
+// NewManager allocates a new Manager instance. Expired operations are removed every Config.CleanupInterval.
+func NewManager(params NewManagerParams) *Manager {
+	authorizer := params.Authorizer
+	if authorizer == nil {
+		authorizer = NewAuthorizerOwner(params.Config)
+	}
+	m := &Manager{
+		config:     params.Config,
+		repository: params.Repository,
+		authorizer: authorizer,
+		factoryID:  params.FactoryID,
+		logger:     params.Logger,
+		running:    make(map[string]context.CancelCauseFunc),
+		stop:       make(chan struct{}),
+	}
+	shutdown.Append(params.Lifecycle, params.Coordinator, shutdown.PhaseWorkers, "operation_manager", fx.Hook{
+		OnStart: func(_ context.Context) error {
+			if m.config.CleanupInterval > 0 {
+				go m.runCleanup()
+			}
+			return nil
+		},
+		OnStop: m.shutdown,
+	})
+	return m
+}
+
+// Start creates an Operation, running fn in the background. The returned operation is pending, so handlers are able
+// to return it right away (e.g. HTTP 202 Accepted).
+//
+// fn context.Context holds ctx values (e.g. principal, request identifier) but not its cancellation.
+func (m *Manager) Start(ctx context.Context, name string, metadata map[string]string,
+	fn WorkerFunc) (Operation, error) {
+	id, err := m.factoryID.NewIdentifier()
+	if err != nil {
+		return Operation{}, err
+	}
+	op := Operation{
+		Auditable: persistence.NewAuditable(ctx),
+		ID:        id,
+		Name:      name,
+		Status:    StatusPending,
+		Metadata:  metadata,
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return Operation{}, systemerror.NewUnavailable("OPERATION_MANAGER_CLOSED",
+			"operation manager is shutting down", nil)
+	}
+	m.wg.Add(1)
+	m.mu.Unlock()
+	if err = m.repository.Save(ctx, op); err != nil {
+		m.wg.Done()
+		return Operation{}, err
+	}
+
+	workerCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	m.mu.Lock()
+	m.running[id] = cancel
+	if m.closed {
+		cancel(errShutdown)
+	}
+	m.mu.Unlock()
+	go m.run(workerCtx, &Worker{
+		manager:     m,
+		operationID: id,
+		cancel:      cancel,
+	}, fn)
+	return op, nil
+}
+
+func (m *Manager) run(ctx context.Context, worker *Worker, fn WorkerFunc) {
+	defer m.wg.Done()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, worker.operationID)
+		m.mu.Unlock()
+		worker.cancel(nil)
+	}()
+
+	op, err := m.update(ctx, worker.operationID, func(op *Operation) (bool, error) {
+		if op.Done() {
+			return false, ErrCancelled
+		}
+		op.Status = StatusRunning
+		return true, nil
+	})
+	if errors.Is(err, ErrCancelled) {
+		// cancelled before its worker started
+		return
+	} else if err != nil {
+		m.logger.WithError(err).
+			WithField("operation_id", worker.operationID).
+			WriteWithCtx(ctx, "failed to start operation worker")
+		return
+	}
+
+	result, err := m.runWorker(ctx, worker, fn)
+	op, errUpdate := m.update(context.WithoutCancel(ctx), worker.operationID, func(op *Operation) (bool, error) {
+		if op.Done() {
+			return false, nil
+		}
+		m.complete(ctx, op, result, err)
+		return true, nil
+	})
+	if errUpdate != nil {
+		m.logger.WithError(errUpdate).
+			WithField("operation_id", worker.operationID).
+			WriteWithCtx(ctx, "failed to store operation result")
+		return
+	}
+	m.logger.Debug().
+		WithField("operation_id", op.ID).
+		WithField("operation_name", op.Name).
+		WithField("status", op.Status).
+		WriteWithCtx(ctx, "operation done")
+}
+
+func (m *Manager) runWorker(ctx context.Context, worker *Worker, fn WorkerFunc) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("operation: worker panicked: %v", r)
+		}
+	}()
+	return fn(ctx, worker)
+}
+
+// complete sets the final status of op.
+func (m *Manager) complete(ctx context.Context, op *Operation, result any, err error) {
+	cause := context.Cause(ctx)
+	switch {
+	case err == nil:
+		raw, errMarshal := json.Marshal(result)
+		if errMarshal != nil {
+			op.Status, op.Error = StatusFailed, NewError(errMarshal)
+			break
+		}
+		op.Status, op.Result = StatusSucceeded, raw
+		op.Progress.Percent = 100
+	case errors.Is(cause, errShutdown):
+		op.Status, op.Error = StatusFailed, newInterruptedError()
+	case errors.Is(cause, ErrCancelled) || errors.Is(err, ErrCancelled):
+		op.Status, op.Error = StatusCancelled, newCancelledError()
+	default:
+		m.logger.WithError(err).
+			WithField("operation_id", op.ID).
+			WriteWithCtx(ctx, "operation failed")
+		op.Status, op.Error = StatusFailed, NewError(err)
+	}
+	if m.config.TTL > 0 {
+		op.ExpireTime = time.Now().UTC().Add(m.config.TTL)
+	}
+}
+
+// update applies mutate to the stored operation, retrying if concurrent updates took place. Nothing is stored if
+// mutate returns false.
+func (m *Manager) update(ctx context.Context, id string, mutate func(op *Operation) (bool, error)) (Operation,
+	error) {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		op, err := m.find(ctx, id)
+		if err != nil {
+			return Operation{}, err
+		}
+		if changed, errMutate := mutate(&op); errMutate != nil || !changed {
+			return op, errMutate
+		}
+		op.Update(ctx)
+		if err = m.repository.Save(ctx, op); err == nil {
+			return op, nil
+		} else if !isVersionMismatch(err) {
+			return Operation{}, err
+		}
+	}
+	return Operation{}, systemerror.NewAborted("OPERATION_CONFLICT",
+		"operation was concurrently modified", map[string]string{"operation_id": id})
+}
+
+// find retrieves the operation with the given identifier, regardless of the principal of ctx.
+func (m *Manager) find(ctx context.Context, id string) (Operation, error) {
+	op, err := m.repository.FindByKey(ctx, id)
+	if err != nil {
+		return Operation{}, err
+	} else if op == nil {
+		return Operation{}, systemerror.NewResourceNotFound[Operation](id)
+	}
+	return *op, nil
+}
+
+// Get retrieves the operation with the given identifier.
+func (m *Manager) Get(ctx context.Context, id string) (Operation, error) {
+	op, err := m.find(ctx, id)
+	if err != nil {
+		return Operation{}, err
+	} else if err = m.authorizer.Authorize(ctx, op); err != nil {
+		return Operation{}, err
+	}
+	return op, nil
+}
+
+// List retrieves operations matching criteria (see Repository for supported filters).
+func (m *Manager) List(ctx context.Context, criteria data.Criteria) (data.Page[Operation], error) {
+	criteria, err := m.authorizer.Scope(ctx, criteria)
+	if err != nil {
+		return data.Page[Operation]{}, err
+	}
+	return m.repository.FindAll(ctx, criteria)
+}
+
+// Cancel cancels the operation with the given identifier. Done operations are returned as-is.
+//
+// Workers running in this replica get their context.Context cancelled right away, workers running in other
+// replicas are notified on their next Worker.UpdateProgress call.
+func (m *Manager) Cancel(ctx context.Context, id string) (Operation, error) {
+	if _, err := m.Get(ctx, id); err != nil {
+		return Operation{}, err
+	}
+	op, err := m.update(ctx, id, func(op *Operation) (bool, error) {
+		if op.Done() {
+			return false, nil
+		}
+		op.Status, op.Error = StatusCancelled, newCancelledError()
+		if m.config.TTL > 0 {
+			op.ExpireTime = time.Now().UTC().Add(m.config.TTL)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return Operation{}, err
+	}
+
+	m.mu.Lock()
+	cancel, ok := m.running[id]
+	m.mu.Unlock()
+	if ok {
+		cancel(ErrCancelled)
+	}
+	return op, nil
+}
+
+// Wait blocks until the operation with the given identifier is done or timeout (capped by Config.MaxWaitTimeout)
+// elapses, returning its latest state. The returned operation is not done if timeout elapsed first.
+func (m *Manager) Wait(ctx context.Context, id string, timeout time.Duration) (Operation, error) {
+	if timeout <= 0 || (m.config.MaxWaitTimeout > 0 && timeout > m.config.MaxWaitTimeout) {
+		timeout = m.config.MaxWaitTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(m.config.WaitPollInterval)
+	defer ticker.Stop()
+	for {
+		op, err := m.Get(ctx, id)
+		if err != nil || op.Done() {
+			return op, err
+		}
+		select {
+		case <-ctx.Done():
+			return Operation{}, ctx.Err()
+		case <-timer.C:
+			return op, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) runCleanup() {
+	ticker := time.NewTicker(m.config.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+		total, err := m.repository.RemoveExpired(context.Background(), time.Now().UTC())
+		if err != nil {
+			m.logger.WithError(err).Write("failed to remove expired operations")
+			continue
+		}
+		m.logger.Debug().WithField("total_operations", total).Write("removed expired operations")
+	}
+}
+
+// shutdown cancels running workers, waiting for them to store their operations.
+func (m *Manager) shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.stop)
+	for _, cancel := range m.running {
+		cancel(errShutdown)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
@@ -0,0 +1,223 @@
+package operation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/emirpasic/gods/v2/sets/hashset"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/operation"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+func newTestManager(t *testing.T, adminAuthorities ...string) (*operation.Manager, *fxtest.Lifecycle) {
+	lifecycle := fxtest.NewLifecycle(t)
+	manager := operation.NewManager(operation.NewManagerParams{
+		Lifecycle: lifecycle,
+		Config: operation.Config{
+			TTL:              time.Hour,
+			WaitPollInterval: time.Millisecond,
+			MaxWaitTimeout:   time.Second,
+			AdminAuthorities: adminAuthorities,
+		},
+		Repository: operation.NewRepositoryMemory(encryption.NewEncryptorAES(encryption.ConfigEncryptor{
+			SecretKey: data.PageTokenDefaultEncryptionKey,
+		})),
+		FactoryID: identifier.NewFactoryUUID(),
+		Logger:    logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+	lifecycle.RequireStart()
+	return manager, lifecycle
+}
+
+func TestManager(t *testing.T) {
+	manager, lifecycle := newTestManager(t)
+	defer lifecycle.RequireStop()
+	ctx := context.Background()
+
+	t.Run("succeeded", func(t *testing.T) {
+		op, err := manager.Start(ctx, "reports.generate", map[string]string{"report_id": "123"},
+			func(ctx context.Context, worker *operation.Worker) (any, error) {
+				if err := worker.UpdateProgress(ctx, 50, "half way"); err != nil {
+					return nil, err
+				}
+				return map[string]string{"url": "https://example.com/report.pdf"}, nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, operation.StatusPending, op.Status)
+
+		op, err = manager.Wait(ctx, op.ID, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, operation.StatusSucceeded, op.Status)
+		assert.Equal(t, 100, op.Progress.Percent)
+		assert.False(t, op.ExpireTime.IsZero())
+		var result map[string]string
+		require.NoError(t, op.DecodeResult(&result))
+		assert.Equal(t, "https://example.com/report.pdf", result["url"])
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		op, err := manager.Start(ctx, "reports.generate", nil,
+			func(_ context.Context, _ *operation.Worker) (any, error) {
+				return nil, systemerror.NewResourceNotFound[operation.Operation]("123")
+			})
+		require.NoError(t, err)
+		op, err = manager.Wait(ctx, op.ID, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, operation.StatusFailed, op.Status)
+		require.NotNil(t, op.Error)
+		assert.Equal(t, systemerror.StatusNotFound, op.Error.Status)
+		assert.Equal(t, "RESOURCE_NOT_FOUND", op.Error.Reason)
+
+		op, err = manager.Start(ctx, "reports.generate", nil,
+			func(_ context.Context, _ *operation.Worker) (any, error) {
+				return nil, errors.New("connection refused")
+			})
+		require.NoError(t, err)
+		op, err = manager.Wait(ctx, op.ID, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, systemerror.StatusInternal, op.Error.Status)
+		assert.NotContains(t, op.Error.Message, "connection refused")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		started := make(chan struct{})
+		op, err := manager.Start(ctx, "reports.generate", nil,
+			func(ctx context.Context, _ *operation.Worker) (any, error) {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			})
+		require.NoError(t, err)
+		<-started
+		op, err = manager.Cancel(ctx, op.ID)
+		require.NoError(t, err)
+		assert.Equal(t, operation.StatusCancelled, op.Status)
+
+		op, err = manager.Wait(ctx, op.ID, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, operation.StatusCancelled, op.Status)
+		assert.Equal(t, systemerror.StatusCancelled, op.Error.Status)
+	})
+
+	t.Run("wait timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		op, err := manager.Start(ctx, "reports.generate", nil,
+			func(_ context.Context, _ *operation.Worker) (any, error) {
+				<-release
+				return nil, nil
+			})
+		require.NoError(t, err)
+		op, err = manager.Wait(ctx, op.ID, 10*time.Millisecond)
+		require.NoError(t, err)
+		assert.False(t, op.Done())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		page, err := manager.List(ctx, data.Criteria{
+			PageSize: 2,
+			Filters: []data.CriteriaFilter{
+				{Field: operation.FieldStatus, Operator: data.OperatorEquals, Value: []any{operation.StatusFailed}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, page.TotalItems)
+		assert.Len(t, page.Items, 2)
+		assert.Empty(t, page.NextPageToken)
+
+		_, err = manager.List(ctx, data.Criteria{
+			Filters: []data.CriteriaFilter{{Field: "owner", Operator: data.OperatorEquals, Value: []any{"1"}}},
+		})
+		assert.ErrorIs(t, err, systemerror.ErrInvalidArgument)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := manager.Get(ctx, "unknown")
+		assert.ErrorIs(t, err, systemerror.ErrNotFound)
+	})
+}
+
+func TestManager_CrossPrincipal(t *testing.T) {
+	manager, lifecycle := newTestManager(t, "operations.admin")
+	defer lifecycle.RequireStop()
+	newContext := func(username string, authorities ...string) context.Context {
+		return context.WithValue(context.Background(), security.PrincipalContextKey, security.PrincipalTemplate{
+			Identifier:   username,
+			User:         username,
+			AuthoritySet: hashset.New[string](authorities...),
+		})
+	}
+	aliceCtx, bobCtx := newContext("alice"), newContext("bob")
+	adminCtx := newContext("carol", "operations.admin")
+
+	release := make(chan struct{})
+	defer close(release)
+	op, err := manager.Start(aliceCtx, "reports.generate", nil,
+		func(_ context.Context, _ *operation.Worker) (any, error) {
+			<-release
+			return nil, nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", op.CreateBy)
+
+	_, err = manager.Get(bobCtx, op.ID)
+	assert.ErrorIs(t, err, systemerror.ErrNotFound)
+	_, err = manager.Get(context.Background(), op.ID)
+	assert.ErrorIs(t, err, systemerror.ErrNotFound)
+	_, err = manager.Wait(bobCtx, op.ID, time.Millisecond)
+	assert.ErrorIs(t, err, systemerror.ErrNotFound)
+	_, err = manager.Cancel(bobCtx, op.ID)
+	assert.ErrorIs(t, err, systemerror.ErrNotFound)
+	page, err := manager.List(bobCtx, data.Criteria{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+
+	got, err := manager.Get(aliceCtx, op.ID)
+	require.NoError(t, err)
+	assert.Equal(t, op.ID, got.ID)
+	page, err = manager.List(aliceCtx, data.Criteria{})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+
+	page, err = manager.List(adminCtx, data.Criteria{})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	got, err = manager.Cancel(adminCtx, op.ID)
+	require.NoError(t, err)
+	assert.Equal(t, operation.StatusCancelled, got.Status)
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	manager, lifecycle := newTestManager(t)
+	ctx := context.Background()
+	started := make(chan struct{})
+	op, err := manager.Start(ctx, "reports.generate", nil,
+		func(ctx context.Context, _ *operation.Worker) (any, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	require.NoError(t, err)
+	<-started
+	lifecycle.RequireStop()
+
+	op, err = manager.Get(ctx, op.ID)
+	require.NoError(t, err)
+	assert.Equal(t, operation.StatusFailed, op.Status)
+	assert.Equal(t, "OPERATION_INTERRUPTED", op.Error.Reason)
+
+	_, err = manager.Start(ctx, "reports.generate", nil, nil)
+	assert.ErrorIs(t, err, systemerror.ErrUnavailable)
+}
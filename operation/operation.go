@@ -0,0 +1,116 @@
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// Status the execution status of an Operation.
+type Status string
+
+const (
+	// StatusPending the operation was created, but its worker has not started yet.
+	StatusPending Status = "PENDING"
+	// StatusRunning the worker of the operation is running.
+	StatusRunning Status = "RUNNING"
+	// StatusSucceeded the operation finished successfully. Operation.Result holds its result.
+	StatusSucceeded Status = "SUCCEEDED"
+	// StatusFailed the operation failed. Operation.Error holds its error.
+	StatusFailed Status = "FAILED"
+	// StatusCancelled the operation was cancelled before finishing.
+	StatusCancelled Status = "CANCELLED"
+)
+
+// IsDone indicates if the status is final.
+func (s Status) IsDone() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// Progress the completion progress of an Operation.
+type Progress struct {
+	// Percent completion percentage (0-100).
+	Percent int `json:"percent"`
+	// Message human-readable description of the current step (e.g. exporting page 3 of 10).
+	Message string `json:"message,omitempty"`
+}
+
+// Error the error of a failed Operation. Holds systemerror.Error fields so errors keep their status once stored.
+type Error struct {
+	Status   systemerror.Status `json:"status"`
+	Reason   string             `json:"reason"`
+	Message  string             `json:"message"`
+	Metadata map[string]string  `json:"metadata,omitempty"`
+}
+
+// NewError allocates an Error from err. Errors other than systemerror.Error are stored as internal errors, hiding
+// their messages.
+func NewError(err error) *Error {
+	var sysErr systemerror.Error
+	if !errors.As(err, &sysErr) {
+		return &Error{
+			Status:  systemerror.StatusInternal,
+			Reason:  "INTERNAL",
+			Message: "internal error",
+		}
+	}
+	return &Error{
+		Status:   sysErr.Status(),
+		Reason:   sysErr.Reason(),
+		Message:  sysErr.Message(),
+		Metadata: sysErr.Metadata(),
+	}
+}
+
+// SystemError converts e into a systemerror.SystemError.
+func (e Error) SystemError() systemerror.SystemError {
+	return systemerror.SystemError{
+		ErrStatus:   e.Status,
+		ErrReason:   e.Reason,
+		ErrMessage:  e.Message,
+		ErrMetadata: e.Metadata,
+	}
+}
+
+// Operation is a long-running operation (Google AIP-151). Handlers start work taking too long for a request
+// (e.g. report generation) and return an Operation, so clients can poll its status, wait for it or cancel it.
+type Operation struct {
+	persistence.Auditable
+	// ID unique identifier of the operation.
+	ID string
+	// Name the kind of work performed (e.g. reports.generate).
+	Name     string
+	Status   Status
+	Progress Progress
+	// Metadata custom parameters describing the operation (e.g. the report identifier).
+	Metadata map[string]string
+	// Result JSON-encoded result of a succeeded operation.
+	Result json.RawMessage
+	// Error error of a failed or cancelled operation.
+	Error *Error
+	// ExpireTime time the operation is removed after being done. Zero if not done or retained indefinitely.
+	ExpireTime time.Time
+}
+
+var _ persistence.Persistable = Operation{}
+
+// Done indicates if the operation is finished (i.e. succeeded, failed or cancelled).
+func (o Operation) Done() bool {
+	return o.Status.IsDone()
+}
+
+// IsExpired indicates if the operation has expired at the given time.
+func (o Operation) IsExpired(at time.Time) bool {
+	return !o.ExpireTime.IsZero() && !at.Before(o.ExpireTime)
+}
+
+// DecodeResult decodes the result of a succeeded operation into out.
+func (o Operation) DecodeResult(out any) error {
+	if len(o.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(o.Result, out)
+}
@@ -0,0 +1,78 @@
+package operation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+const (
+	// FieldName Operation.Name field, used by data.Criteria filters.
+	FieldName = "name"
+	// FieldStatus Operation.Status field, used by data.Criteria filters.
+	FieldStatus = "status"
+	// FieldCreateBy Operation.CreateBy field, used by data.Criteria filters.
+	FieldCreateBy = "create_by"
+
+	defaultPageSize = 50
+	maxPageSize     = 250
+)
+
+// Repository persists Operation(s).
+//
+// Save implements optimistic concurrency: operations with zero version are inserted, while the rest are updated
+// only if the stored version is the previous one (otherwise, systemerror.NewVersionMismatch is returned).
+//
+// FindAll supports equality filters of FieldName, FieldStatus and FieldCreateBy fields. Operations are ordered by
+// creation time, newest first.
+type Repository interface {
+	persistence.PagingCrudRepository[Operation, string]
+	// RemoveExpired removes operations expired at the given time. Returns the number of removed operations.
+	RemoveExpired(ctx context.Context, at time.Time) (int64, error)
+}
+
+// criteriaFilters equality filters of a data.Criteria.
+type criteriaFilters struct {
+	Name   string
+	Status Status
+	// CreateBy nil if not filtered, as operations started without principal have no creator.
+	CreateBy *string
+}
+
+func (f criteriaFilters) matches(op Operation) bool {
+	return (f.Name == "" || f.Name == op.Name) && (f.Status == "" || f.Status == op.Status) &&
+		(f.CreateBy == nil || *f.CreateBy == op.CreateBy)
+}
+
+func parseCriteriaFilters(criteria data.Criteria) (criteriaFilters, error) {
+	filters := criteriaFilters{}
+	for _, filter := range criteria.Filters {
+		if filter.Operator != data.OperatorEquals || len(filter.Value) != 1 {
+			return criteriaFilters{}, systemerror.NewArgumentNotOneOf(filter.Field+".operator",
+				data.OperatorEquals.String())
+		}
+		value := fmt.Sprintf("%v", filter.Value[0])
+		switch filter.Field {
+		case FieldName:
+			filters.Name = value
+		case FieldStatus:
+			filters.Status = Status(value)
+		case FieldCreateBy:
+			filters.CreateBy = &value
+		default:
+			return criteriaFilters{}, systemerror.NewArgumentNotOneOf("filter", FieldName, FieldStatus, FieldCreateBy)
+		}
+	}
+	return filters, nil
+}
+
+func newPageSize(criteria data.Criteria) int {
+	if criteria.PageSize <= 0 {
+		return defaultPageSize
+	}
+	return int(min(criteria.PageSize, maxPageSize))
+}
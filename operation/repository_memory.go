@@ -0,0 +1,133 @@
+package operation
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// RepositoryMemory is the in-process Repository implementation. Operations are lost on restarts and not shared
+// across replicas, useful for single-replica deployments and testing.
+type RepositoryMemory struct {
+	Encryptor encryption.Encryptor
+
+	mu         *sync.RWMutex
+	operations map[string]Operation
+}
+
+var _ Repository = RepositoryMemory{}
+
+// NewRepositoryMemory allocates a new RepositoryMemory instance.
+func NewRepositoryMemory(encryptor encryption.Encryptor) RepositoryMemory {
+	return RepositoryMemory{
+		Encryptor:  encryptor,
+		mu:         &sync.RWMutex{},
+		operations: make(map[string]Operation),
+	}
+}
+
+func (r RepositoryMemory) Save(_ context.Context, entity Operation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, exists := r.operations[entity.ID]
+	switch {
+	case entity.Version == 0 && exists:
+		return systemerror.NewResourceAlreadyExists[Operation](entity.ID)
+	case entity.Version > 0 && !exists:
+		return systemerror.NewResourceNotFound[Operation](entity.ID)
+	case entity.Version > 0 && current.Version != entity.Version-1:
+		return systemerror.NewVersionMismatch(strconv.FormatInt(entity.Version-1, 10),
+			strconv.FormatInt(current.Version, 10))
+	}
+	r.operations[entity.ID] = entity
+	return nil
+}
+
+func (r RepositoryMemory) SaveMany(ctx context.Context, entities []Operation) error {
+	errs := make([]error, 0, len(entities))
+	for _, entity := range entities {
+		if err := r.Save(ctx, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r RepositoryMemory) Remove(_ context.Context, entity Operation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.operations, entity.ID)
+	return nil
+}
+
+func (r RepositoryMemory) FindByKey(_ context.Context, key string) (*Operation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	op, ok := r.operations[key]
+	if !ok || op.IsExpired(time.Now().UTC()) {
+		return nil, nil
+	}
+	return &op, nil
+}
+
+func (r RepositoryMemory) FindAll(_ context.Context, criteria data.Criteria) (data.Page[Operation], error) {
+	filters, err := parseCriteriaFilters(criteria)
+	if err != nil {
+		return data.Page[Operation]{}, err
+	}
+
+	now := time.Now().UTC()
+	r.mu.RLock()
+	items := make([]Operation, 0, len(r.operations))
+	for _, op := range r.operations {
+		if !op.IsExpired(now) && filters.matches(op) {
+			items = append(items, op)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].CreateTime.Equal(items[j].CreateTime) {
+			return items[i].ID > items[j].ID
+		}
+		return items[i].CreateTime.After(items[j].CreateTime)
+	})
+
+	offset := min(data.ConvertOffsetSafe(criteria.PageToken, r.Encryptor), len(items))
+	end := min(offset+newPageSize(criteria), len(items))
+	page := data.Page[Operation]{
+		TotalItems: len(items),
+		Items:      items[offset:end],
+	}
+	if end < len(items) {
+		if page.NextPageToken, err = data.NewPageTokenOffset(r.Encryptor, end); err != nil {
+			return data.Page[Operation]{}, err
+		}
+	}
+	if offset > 0 {
+		prevOffset := max(offset-newPageSize(criteria), 0)
+		if page.PreviousPageToken, err = data.NewPageTokenOffset(r.Encryptor, prevOffset); err != nil {
+			return data.Page[Operation]{}, err
+		}
+	}
+	return page, nil
+}
+
+func (r RepositoryMemory) RemoveExpired(_ context.Context, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	for id, op := range r.operations {
+		if op.IsExpired(at) {
+			delete(r.operations, id)
+			total++
+		}
+	}
+	return total, nil
+}
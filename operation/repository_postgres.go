@@ -0,0 +1,264 @@
+package operation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hadroncorp/geck/data"
+	gecksql "github.com/hadroncorp/geck/data/sql"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+const postgresOperationColumns = "operation_id,operation_name,status,progress_percent,progress_message,metadata," +
+	"result,error,expire_time,create_time,create_by,last_update_time,last_update_by,is_active,version"
+
+// RepositoryPostgres is the Repository implementation using a PostgreSQL table with the following structure:
+//
+//	CREATE TABLE operations (
+//		operation_id     VARCHAR(128) PRIMARY KEY,
+//		operation_name   VARCHAR(256) NOT NULL,
+//		status           VARCHAR(32) NOT NULL,
+//		progress_percent INT NOT NULL DEFAULT 0,
+//		progress_message TEXT NOT NULL DEFAULT '',
+//		metadata         JSONB,
+//		result           JSONB,
+//		error            JSONB,
+//		expire_time      TIMESTAMPTZ,
+//		create_time      TIMESTAMPTZ NOT NULL,
+//		create_by        VARCHAR(256) NOT NULL,
+//		last_update_time TIMESTAMPTZ NOT NULL,
+//		last_update_by   VARCHAR(256) NOT NULL,
+//		is_active        BOOLEAN NOT NULL,
+//		version          BIGINT NOT NULL
+//	);
+//	CREATE INDEX operations_create_time_idx ON operations (create_time DESC, operation_id DESC);
+//
+// Table name is set by Config.TableName.
+type RepositoryPostgres struct {
+	Config    Config
+	Client    gecksql.Client
+	Encryptor encryption.Encryptor
+}
+
+var _ Repository = RepositoryPostgres{}
+
+// NewRepositoryPostgres allocates a new RepositoryPostgres instance.
+func NewRepositoryPostgres(cfg Config, client gecksql.Client, encryptor encryption.Encryptor) RepositoryPostgres {
+	return RepositoryPostgres{
+		Config:    cfg,
+		Client:    client,
+		Encryptor: encryptor,
+	}
+}
+
+func (r RepositoryPostgres) Save(ctx context.Context, entity Operation) error {
+	metadata, err := marshalNullableJSON(entity.Metadata)
+	if err != nil {
+		return err
+	}
+	opErr, err := marshalNullableJSON(entity.Error)
+	if err != nil {
+		return err
+	}
+	var result any
+	if len(entity.Result) > 0 {
+		result = []byte(entity.Result)
+	}
+	var expireTime sql.NullTime
+	if !entity.ExpireTime.IsZero() {
+		expireTime = sql.NullTime{Time: entity.ExpireTime, Valid: true}
+	}
+
+	if entity.Version == 0 {
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)",
+			r.Config.TableName, postgresOperationColumns)
+		_, err = r.Client.ExecContext(ctx, stmt, entity.ID, entity.Name, entity.Status, entity.Progress.Percent,
+			entity.Progress.Message, metadata, result, opErr, expireTime, entity.CreateTime, entity.CreateBy,
+			entity.LastUpdateTime, entity.LastUpdateBy, entity.IsActive, entity.Version)
+		if err != nil && strings.HasPrefix(err.Error(), "ERROR: duplicate key") {
+			return systemerror.NewResourceAlreadyExists[Operation](entity.ID)
+		}
+		return err
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %s SET status=$2,progress_percent=$3,progress_message=$4,metadata=$5,result=$6,
+error=$7,expire_time=$8,last_update_time=$9,last_update_by=$10,is_active=$11,version=$12
+WHERE operation_id=$1 AND version=$13`, r.Config.TableName)
+	res, err := r.Client.ExecContext(ctx, stmt, entity.ID, entity.Status, entity.Progress.Percent,
+		entity.Progress.Message, metadata, result, opErr, expireTime, entity.LastUpdateTime, entity.LastUpdateBy,
+		entity.IsActive, entity.Version, entity.Version-1)
+	if err != nil {
+		return err
+	}
+	if affected, errRows := res.RowsAffected(); errRows != nil {
+		return errRows
+	} else if affected > 0 {
+		return nil
+	}
+
+	var currentVersion int64
+	stmt = fmt.Sprintf("SELECT version FROM %s WHERE operation_id=$1", r.Config.TableName)
+	err = r.Client.QueryRowContext(ctx, stmt, entity.ID).Scan(&currentVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return systemerror.NewResourceNotFound[Operation](entity.ID)
+	} else if err != nil {
+		return err
+	}
+	return systemerror.NewVersionMismatch(strconv.FormatInt(entity.Version-1, 10),
+		strconv.FormatInt(currentVersion, 10))
+}
+
+func marshalNullableJSON(v any) (any, error) {
+	switch val := v.(type) {
+	case map[string]string:
+		if len(val) == 0 {
+			return nil, nil
+		}
+	case *Error:
+		if val == nil {
+			return nil, nil
+		}
+	}
+	return json.Marshal(v)
+}
+
+func (r RepositoryPostgres) SaveMany(ctx context.Context, entities []Operation) error {
+	errs := make([]error, 0, len(entities))
+	for _, entity := range entities {
+		if err := r.Save(ctx, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r RepositoryPostgres) Remove(ctx context.Context, entity Operation) error {
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE operation_id=$1", r.Config.TableName)
+	_, err := r.Client.ExecContext(ctx, stmt, entity.ID)
+	return err
+}
+
+func (r RepositoryPostgres) FindByKey(ctx context.Context, key string) (*Operation, error) {
+	stmt := fmt.Sprintf(`SELECT %s FROM %s WHERE operation_id=$1 AND (expire_time IS NULL OR expire_time > $2)`,
+		postgresOperationColumns, r.Config.TableName)
+	op, err := scanOperation(r.Client.QueryRowContext(ctx, stmt, key, time.Now().UTC()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+func (r RepositoryPostgres) FindAll(ctx context.Context, criteria data.Criteria) (data.Page[Operation], error) {
+	filters, err := parseCriteriaFilters(criteria)
+	if err != nil {
+		return data.Page[Operation]{}, err
+	}
+	where := "(expire_time IS NULL OR expire_time > $1)"
+	args := []any{time.Now().UTC()}
+	if filters.Name != "" {
+		args = append(args, filters.Name)
+		where += fmt.Sprintf(" AND operation_name=$%d", len(args))
+	}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		where += fmt.Sprintf(" AND status=$%d", len(args))
+	}
+	if filters.CreateBy != nil {
+		args = append(args, *filters.CreateBy)
+		where += fmt.Sprintf(" AND create_by=$%d", len(args))
+	}
+
+	var total int
+	stmt := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", r.Config.TableName, where)
+	if err = r.Client.QueryRowContext(ctx, stmt, args...).Scan(&total); err != nil {
+		return data.Page[Operation]{}, err
+	}
+
+	pageSize := newPageSize(criteria)
+	offset := data.ConvertOffsetSafe(criteria.PageToken, r.Encryptor)
+	stmt = fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY create_time DESC, operation_id DESC LIMIT %d OFFSET %d",
+		postgresOperationColumns, r.Config.TableName, where, pageSize, offset)
+	rows, err := r.Client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return data.Page[Operation]{}, err
+	}
+	defer rows.Close()
+	page := data.Page[Operation]{
+		TotalItems: total,
+		Items:      make([]Operation, 0, pageSize),
+	}
+	for rows.Next() {
+		op, errScan := scanOperation(rows)
+		if errScan != nil {
+			return data.Page[Operation]{}, errScan
+		}
+		page.Items = append(page.Items, op)
+	}
+	if err = rows.Err(); err != nil {
+		return data.Page[Operation]{}, err
+	}
+
+	if next := offset + len(page.Items); next < total {
+		if page.NextPageToken, err = data.NewPageTokenOffset(r.Encryptor, next); err != nil {
+			return data.Page[Operation]{}, err
+		}
+	}
+	if offset > 0 {
+		if page.PreviousPageToken, err = data.NewPageTokenOffset(r.Encryptor, max(offset-pageSize, 0)); err != nil {
+			return data.Page[Operation]{}, err
+		}
+	}
+	return page, nil
+}
+
+func (r RepositoryPostgres) RemoveExpired(ctx context.Context, at time.Time) (int64, error) {
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE expire_time <= $1", r.Config.TableName)
+	res, err := r.Client.ExecContext(ctx, stmt, at)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOperation(row rowScanner) (Operation, error) {
+	op := Operation{}
+	var (
+		metadata, result, opErr []byte
+		expireTime              sql.NullTime
+	)
+	err := row.Scan(&op.ID, &op.Name, &op.Status, &op.Progress.Percent, &op.Progress.Message, &metadata, &result,
+		&opErr, &expireTime, &op.CreateTime, &op.CreateBy, &op.LastUpdateTime, &op.LastUpdateBy, &op.IsActive,
+		&op.Version)
+	if err != nil {
+		return Operation{}, err
+	}
+	if len(metadata) > 0 {
+		if err = json.Unmarshal(metadata, &op.Metadata); err != nil {
+			return Operation{}, err
+		}
+	}
+	if len(opErr) > 0 {
+		op.Error = &Error{}
+		if err = json.Unmarshal(opErr, op.Error); err != nil {
+			return Operation{}, err
+		}
+	}
+	if len(result) > 0 {
+		op.Result = result
+	}
+	op.ExpireTime = expireTime.Time
+	return op, nil
+}
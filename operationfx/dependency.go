@@ -0,0 +1,36 @@
+package operationfx
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/loggingfx"
+	"github.com/hadroncorp/geck/operation"
+)
+
+var OperationMemoryModule = fx.Module("operation_memory",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("operation"),
+	),
+	fx.Provide(
+		operation.NewConfig,
+		fx.Annotate(
+			operation.NewRepositoryMemory,
+			fx.As(new(operation.Repository)),
+		),
+		operation.NewManager,
+	),
+)
+
+var OperationPostgresModule = fx.Module("operation_postgres",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("operation"),
+	),
+	fx.Provide(
+		operation.NewConfig,
+		fx.Annotate(
+			operation.NewRepositoryPostgres,
+			fx.As(new(operation.Repository)),
+		),
+		operation.NewManager,
+	),
+)
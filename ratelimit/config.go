@@ -0,0 +1,11 @@
+package ratelimit
+
+// Config configuration structure for Limiter instances.
+type Config struct {
+	// Algorithm the rate limiting algorithm to use (token_bucket, sliding_window).
+	Algorithm Algorithm `env:"RATE_LIMIT_ALGORITHM" envDefault:"token_bucket"`
+	// KeyPrefix prefix appended to every Store key.
+	KeyPrefix string `env:"RATE_LIMIT_KEY_PREFIX" envDefault:"geck.ratelimit#"`
+	// MaxUpdateAttempts maximum number of attempts to update shared state when concurrent writers are detected.
+	MaxUpdateAttempts int `env:"RATE_LIMIT_MAX_UPDATE_ATTEMPTS" envDefault:"8"`
+}
@@ -0,0 +1,12 @@
+package ratelimit
+
+import "errors"
+
+var (
+	// ErrStoreContention state could not be updated after several attempts due to concurrent writers.
+	ErrStoreContention = errors.New("ratelimit: store contention")
+	// ErrInvalidLimit the given limit has an invalid format.
+	ErrInvalidLimit = errors.New("ratelimit: invalid limit")
+	// ErrUnknownAlgorithm the given algorithm is not supported.
+	ErrUnknownAlgorithm = errors.New("ratelimit: unknown algorithm")
+)
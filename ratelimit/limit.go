@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit a rate limit specification.
+type Limit struct {
+	// Requests maximum number of requests allowed per Period.
+	Requests int64
+	// Period the time window Requests are counted in.
+	Period time.Duration
+	// Burst maximum number of requests allowed at once. Only used by token bucket algorithm, defaults to Requests.
+	Burst int64
+}
+
+var _ fmt.Stringer = Limit{}
+
+// ParseLimit parses a Limit using the nomenclature: REQUESTS/PERIOD[/BURST].
+//
+// For example:
+//
+//   - 100/1m
+//   - 10/1s/20
+func ParseLimit(value string) (Limit, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Limit{}, ErrInvalidLimit
+	}
+	requests, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || requests <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+	limit := Limit{
+		Requests: requests,
+		Period:   period,
+	}
+	if len(parts) == 3 {
+		if limit.Burst, err = strconv.ParseInt(parts[2], 10, 64); err != nil || limit.Burst <= 0 {
+			return Limit{}, ErrInvalidLimit
+		}
+	}
+	return limit, nil
+}
+
+// String returns the Limit using the nomenclature accepted by ParseLimit.
+func (l Limit) String() string {
+	if l.Burst > 0 {
+		return fmt.Sprintf("%d/%s/%d", l.Requests, l.Period, l.Burst)
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// UnmarshalText decodes a Limit using ParseLimit.
+func (l *Limit) UnmarshalText(text []byte) (err error) {
+	*l, err = ParseLimit(string(text))
+	return
+}
+
+func (l Limit) capacity() int64 {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Algorithm a rate limiting algorithm.
+type Algorithm string
+
+const (
+	// AlgorithmTokenBucket token bucket algorithm. Allows bursts up to Limit.Burst while refilling tokens at a
+	// constant rate.
+	AlgorithmTokenBucket Algorithm = "token_bucket"
+	// AlgorithmSlidingWindow sliding window (counter) algorithm. Smooths fixed window boundaries by weighting
+	// the previous window count.
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+)
+
+// Result the outcome of a Limiter evaluation.
+type Result struct {
+	// Allowed indicates if the request is allowed.
+	Allowed bool
+	// Limit the evaluated Limit.
+	Limit Limit
+	// Remaining number of requests still allowed.
+	Remaining int64
+	// ResetAfter time until the limit gets fully restored.
+	ResetAfter time.Duration
+	// RetryAfter time until the next request would be allowed. Zero if Allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter evaluates (and consumes) rate limits for a given key (e.g. principal, API key, IP address).
+type Limiter interface {
+	// Allow consumes a request from the given key quota.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// NewLimiter allocates a Limiter based on Config.Algorithm.
+func NewLimiter(cfg Config, store Store) (Limiter, error) {
+	switch cfg.Algorithm {
+	case AlgorithmTokenBucket:
+		return NewTokenBucketLimiter(cfg, store), nil
+	case AlgorithmSlidingWindow:
+		return NewSlidingWindowLimiter(cfg, store), nil
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+}
@@ -0,0 +1,99 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/caching"
+	"github.com/hadroncorp/geck/ratelimit"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("10/1s/20")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 10, Period: time.Second, Burst: 20}, limit)
+	assert.Equal(t, "10/1s/20", limit.String())
+
+	_, err = ratelimit.ParseLimit("10")
+	assert.ErrorIs(t, err, ratelimit.ErrInvalidLimit)
+	_, err = ratelimit.ParseLimit("-1/1s")
+	assert.ErrorIs(t, err, ratelimit.ErrInvalidLimit)
+}
+
+func TestLimiter(t *testing.T) {
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	require.NoError(t, err)
+	defer db.Close()
+
+	cfg := ratelimit.Config{
+		KeyPrefix:         "rl#",
+		MaxUpdateAttempts: 4,
+	}
+	stores := map[string]ratelimit.Store{
+		"memory": ratelimit.NewStoreMemory(),
+		"cache":  ratelimit.NewStoreCache(cfg, caching.NewCacheEmbedded(db, caching.BigCacheConfig{}, nil)),
+	}
+	for storeName, store := range stores {
+		limiters := map[string]ratelimit.Limiter{
+			"token_bucket":   ratelimit.NewTokenBucketLimiter(cfg, store),
+			"sliding_window": ratelimit.NewSlidingWindowLimiter(cfg, store),
+		}
+		for name, limiter := range limiters {
+			t.Run(storeName+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				limit := ratelimit.Limit{Requests: 3, Period: time.Hour}
+				for i := int64(0); i < limit.Requests; i++ {
+					result, errAllow := limiter.Allow(ctx, name+"#client", limit)
+					require.NoError(t, errAllow)
+					assert.True(t, result.Allowed)
+					assert.Equal(t, limit.Requests-i-1, result.Remaining)
+				}
+
+				result, err := limiter.Allow(ctx, name+"#client", limit)
+				require.NoError(t, err)
+				assert.False(t, result.Allowed)
+				assert.Zero(t, result.Remaining)
+				assert.Greater(t, result.RetryAfter, time.Duration(0))
+				assert.LessOrEqual(t, result.RetryAfter, limit.Period)
+
+				// other clients keep their own quota
+				result, err = limiter.Allow(ctx, name+"#other_client", limit)
+				require.NoError(t, err)
+				assert.True(t, result.Allowed)
+			})
+		}
+	}
+}
+
+func TestStoreCache_Update(t *testing.T) {
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := ratelimit.NewStoreCache(ratelimit.Config{MaxUpdateAttempts: 4},
+		caching.NewCacheEmbedded(db, caching.BigCacheConfig{}, nil))
+	ctx := context.Background()
+	err = store.Update(ctx, "key", time.Millisecond, func(state []byte) ([]byte, error) {
+		assert.Nil(t, state)
+		return []byte("state"), nil
+	})
+	require.NoError(t, err)
+
+	// state expires after ttl even if the cache still holds it
+	time.Sleep(5 * time.Millisecond)
+	err = store.Update(ctx, "key", time.Minute, func(state []byte) ([]byte, error) {
+		assert.Nil(t, state)
+		return []byte("next_state"), nil
+	})
+	require.NoError(t, err)
+	err = store.Update(ctx, "key", time.Minute, func(state []byte) ([]byte, error) {
+		assert.Equal(t, []byte("next_state"), state)
+		return state, nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// SlidingWindowLimiter is the Limiter implementation using the sliding window (counter) algorithm.
+//
+// Requests are counted in fixed windows of Limit.Period. The estimated count is the current window count plus
+// the previous window count weighted by the remaining overlap of the sliding window.
+type SlidingWindowLimiter struct {
+	Config Config
+	Store  Store
+}
+
+var _ Limiter = (*SlidingWindowLimiter)(nil)
+
+// NewSlidingWindowLimiter allocates a new SlidingWindowLimiter instance.
+func NewSlidingWindowLimiter(cfg Config, store Store) SlidingWindowLimiter {
+	return SlidingWindowLimiter{
+		Config: cfg,
+		Store:  store,
+	}
+}
+
+// Allow consumes a request from the given key quota.
+func (s SlidingWindowLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	period := int64(limit.Period)
+	var result Result
+	err := s.Store.Update(ctx, s.Config.KeyPrefix+key, limit.Period*2, func(state []byte) ([]byte, error) {
+		now := time.Now().UnixNano()
+		windowStart := now - now%period
+		var prevCount, currCount int64
+		if len(state) == 24 {
+			storedStart := int64(binary.BigEndian.Uint64(state[:8]))
+			storedPrev := int64(binary.BigEndian.Uint64(state[8:16]))
+			storedCurr := int64(binary.BigEndian.Uint64(state[16:]))
+			switch windowStart - storedStart {
+			case 0:
+				prevCount, currCount = storedPrev, storedCurr
+			case period:
+				prevCount = storedCurr
+			}
+		}
+
+		elapsed := now - windowStart
+		weight := 1 - float64(elapsed)/float64(period)
+		estimated := float64(prevCount)*weight + float64(currCount)
+		result = Result{
+			Limit:      limit,
+			ResetAfter: time.Duration(period - elapsed + period),
+		}
+		if estimated+1 <= float64(limit.Requests) {
+			currCount++
+			estimated++
+			result.Allowed = true
+		} else {
+			result.RetryAfter = newSlidingWindowRetryAfter(limit.Requests, prevCount, currCount, elapsed, period)
+		}
+		result.Remaining = max(0, limit.Requests-int64(math.Ceil(estimated)))
+
+		next := make([]byte, 24)
+		binary.BigEndian.PutUint64(next[:8], uint64(windowStart))
+		binary.BigEndian.PutUint64(next[8:16], uint64(prevCount))
+		binary.BigEndian.PutUint64(next[16:], uint64(currCount))
+		return next, nil
+	})
+	return result, err
+}
+
+// newSlidingWindowRetryAfter computes the time until the estimated count leaves room for a new request.
+func newSlidingWindowRetryAfter(limit, prevCount, currCount, elapsed, period int64) time.Duration {
+	untilNextWindow := time.Duration(period - elapsed)
+	if currCount+1 > limit || prevCount == 0 {
+		// current window is full, only next window will have room (previous count weighted)
+		return untilNextWindow
+	}
+	// solve prevCount*(1-t/period) + currCount + 1 <= limit for t
+	allowedAt := float64(period) * (1 - float64(limit-currCount-1)/float64(prevCount))
+	return time.Duration(math.Ceil(allowedAt)) - time.Duration(elapsed)
+}
@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hadroncorp/geck/data/caching"
+)
+
+// UpdateFunc computes the next state of a key from its current state. State is nil if key has no state yet.
+type UpdateFunc func(state []byte) ([]byte, error)
+
+// Store persists Limiter state.
+type Store interface {
+	// Update atomically replaces the state stored in key with the one computed by updateFunc. State is kept
+	// at least ttl since its last update.
+	//
+	// updateFunc might be called several times if concurrent writers are detected.
+	Update(ctx context.Context, key string, ttl time.Duration, updateFunc UpdateFunc) error
+}
+
+type memoryStoreEntry struct {
+	state      []byte
+	expireTime time.Time
+}
+
+// the number of updates between sweeps of expired StoreMemory entries.
+const memoryStoreSweepInterval = 1024
+
+// StoreMemory is the in-process Store implementation. Limits only hold within the running process.
+type StoreMemory struct {
+	mu      *sync.Mutex
+	entries map[string]memoryStoreEntry
+	updates *int
+}
+
+var _ Store = (*StoreMemory)(nil)
+
+// NewStoreMemory allocates a new StoreMemory instance.
+func NewStoreMemory() StoreMemory {
+	return StoreMemory{
+		mu:      &sync.Mutex{},
+		entries: make(map[string]memoryStoreEntry),
+		updates: new(int),
+	}
+}
+
+// Update atomically replaces the state stored in key with the one computed by updateFunc.
+func (s StoreMemory) Update(_ context.Context, key string, ttl time.Duration, updateFunc UpdateFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	*s.updates++
+	if *s.updates%memoryStoreSweepInterval == 0 {
+		for k, entry := range s.entries {
+			if now.After(entry.expireTime) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	var current []byte
+	if entry, ok := s.entries[key]; ok && !now.After(entry.expireTime) {
+		current = entry.state
+	}
+	next, err := updateFunc(current)
+	if err != nil {
+		return err
+	}
+	s.entries[key] = memoryStoreEntry{
+		state:      next,
+		expireTime: now.Add(ttl),
+	}
+	return nil
+}
+
+// the size of the expire time header prepended to StoreCache states.
+const cacheStoreHeaderSize = 8
+
+// StoreCache is the Store implementation using a caching.AtomicCache. Limits hold across replicas only if
+// Cache is shared between them (e.g. Redis); embedded caches behave like StoreMemory.
+//
+// Uses optimistic concurrency (compare-and-swap). States hold their own expire time, so ttl is honoured
+// regardless of cache expiration policies. Nevertheless, Cache MUST keep entries at least as long as the longest
+// Limit period as evicted states reset their limits.
+type StoreCache struct {
+	Config Config
+	Cache  caching.AtomicCache
+}
+
+var _ Store = (*StoreCache)(nil)
+
+// NewStoreCache allocates a new StoreCache instance.
+func NewStoreCache(cfg Config, cache caching.AtomicCache) StoreCache {
+	return StoreCache{
+		Config: cfg,
+		Cache:  cache,
+	}
+}
+
+// Update atomically replaces the state stored in key with the one computed by updateFunc.
+// Returns ErrStoreContention if state could not be replaced after Config.MaxUpdateAttempts.
+func (s StoreCache) Update(ctx context.Context, key string, ttl time.Duration, updateFunc UpdateFunc) error {
+	for attempt := 0; attempt < s.Config.MaxUpdateAttempts; attempt++ {
+		current, err := s.Cache.Get(ctx, key)
+		if err != nil && !errors.Is(err, caching.ErrEntryNotFound) {
+			return err
+		}
+		now := time.Now().UTC()
+		next, err := updateFunc(decodeCacheStoreState(current, now))
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		encoded := encodeCacheStoreState(next, now.Add(ttl))
+		if current == nil {
+			ok, err = s.Cache.SetIfAbsent(ctx, key, encoded)
+		} else {
+			ok, err = s.Cache.CompareAndSwap(ctx, key, current, encoded)
+		}
+		if err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+	}
+	return ErrStoreContention
+}
+
+func encodeCacheStoreState(state []byte, expireTime time.Time) []byte {
+	buf := make([]byte, cacheStoreHeaderSize, cacheStoreHeaderSize+len(state))
+	binary.BigEndian.PutUint64(buf, uint64(expireTime.UnixNano()))
+	return append(buf, state...)
+}
+
+// decodeCacheStoreState returns nil if raw is expired or malformed.
+func decodeCacheStoreState(raw []byte, now time.Time) []byte {
+	if len(raw) < cacheStoreHeaderSize {
+		return nil
+	}
+	expireTime := time.Unix(0, int64(binary.BigEndian.Uint64(raw[:cacheStoreHeaderSize])))
+	if now.After(expireTime) {
+		return nil
+	}
+	return raw[cacheStoreHeaderSize:]
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// TokenBucketLimiter is the Limiter implementation using the token bucket algorithm.
+//
+// Buckets hold up to Limit.Burst tokens (Limit.Requests if not set) and get refilled at a rate of
+// Limit.Requests per Limit.Period. Each request consumes a token.
+type TokenBucketLimiter struct {
+	Config Config
+	Store  Store
+}
+
+var _ Limiter = (*TokenBucketLimiter)(nil)
+
+// NewTokenBucketLimiter allocates a new TokenBucketLimiter instance.
+func NewTokenBucketLimiter(cfg Config, store Store) TokenBucketLimiter {
+	return TokenBucketLimiter{
+		Config: cfg,
+		Store:  store,
+	}
+}
+
+// Allow consumes a request from the given key quota.
+func (t TokenBucketLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	capacity := float64(limit.capacity())
+	ratePerNano := float64(limit.Requests) / float64(limit.Period)
+	ttl := time.Duration(capacity / ratePerNano)
+
+	var result Result
+	err := t.Store.Update(ctx, t.Config.KeyPrefix+key, ttl, func(state []byte) ([]byte, error) {
+		now := time.Now().UnixNano()
+		tokens, last := capacity, now
+		if len(state) == 16 {
+			tokens = math.Float64frombits(binary.BigEndian.Uint64(state[:8]))
+			last = int64(binary.BigEndian.Uint64(state[8:]))
+		}
+		tokens = math.Min(capacity, tokens+float64(now-last)*ratePerNano)
+
+		result = Result{
+			Limit: limit,
+		}
+		if tokens >= 1 {
+			tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = time.Duration(math.Ceil((1 - tokens) / ratePerNano))
+		}
+		result.Remaining = int64(math.Floor(tokens))
+		result.ResetAfter = time.Duration(math.Ceil((capacity - tokens) / ratePerNano))
+
+		next := make([]byte, 16)
+		binary.BigEndian.PutUint64(next[:8], math.Float64bits(tokens))
+		binary.BigEndian.PutUint64(next[8:], uint64(now))
+		return next, nil
+	})
+	return result, err
+}
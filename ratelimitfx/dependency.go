@@ -0,0 +1,30 @@
+package ratelimitfx
+
+import (
+	"github.com/caarlos0/env/v11"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/ratelimit"
+)
+
+var RateLimiterMemoryModule = fx.Module("rate_limiter_memory",
+	fx.Provide(
+		env.ParseAs[ratelimit.Config],
+		fx.Annotate(
+			ratelimit.NewStoreMemory,
+			fx.As(new(ratelimit.Store)),
+		),
+		ratelimit.NewLimiter,
+	),
+)
+
+var RateLimiterCacheModule = fx.Module("rate_limiter_cache",
+	fx.Provide(
+		env.ParseAs[ratelimit.Config],
+		fx.Annotate(
+			ratelimit.NewStoreCache,
+			fx.As(new(ratelimit.Store)),
+		),
+		ratelimit.NewLimiter,
+	),
+)
@@ -17,3 +17,23 @@ func GetPrincipalFromContext(ctx context.Context) (Principal, error) {
 	}
 	return principal, nil
 }
+
+type TokenContextType string
+
+// TokenContextKey context key holding the raw bearer token of the current caller.
+const TokenContextKey TokenContextType = "geck.security.token"
+
+// NewContextWithToken appends the raw bearer token of the current caller to the given context.Context. Used to
+// forward caller credentials to other services.
+func NewContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, TokenContextKey, token)
+}
+
+// GetTokenFromContext retrieves the raw bearer token of the current caller from the given context.Context.
+func GetTokenFromContext(ctx context.Context) (string, error) {
+	token, ok := ctx.Value(TokenContextKey).(string)
+	if !ok || token == "" {
+		return "", systemerror.NewUnauthenticated()
+	}
+	return token, nil
+}
@@ -0,0 +1,19 @@
+package shutdown
+
+import "time"
+
+// Config configuration structure for Coordinator instances.
+//
+// The sum of every duration SHOULD be lower than both, the application stop timeout (see fx.StopTimeout, 15 seconds
+// by default) and the orchestrator grace period (e.g. Kubernetes terminationGracePeriodSeconds, 30 seconds by
+// default). Defaults add up to 14 seconds, raise fx.StopTimeout before raising them.
+type Config struct {
+	// PreStopDelay time readiness reports DOWN before servers stop accepting connections, so load balancers stop
+	// routing new requests to the instance.
+	PreStopDelay time.Duration `env:"SHUTDOWN_PRE_STOP_DELAY" envDefault:"2s"`
+	// DrainTimeout maximum time in-flight requests and streams are waited for. Remaining connections are closed
+	// afterward.
+	DrainTimeout time.Duration `env:"SHUTDOWN_DRAIN_TIMEOUT" envDefault:"8s"`
+	// WorkerTimeout maximum time background workers (e.g. outbox relays, schedulers) are waited for.
+	WorkerTimeout time.Duration `env:"SHUTDOWN_WORKER_TIMEOUT" envDefault:"4s"`
+}
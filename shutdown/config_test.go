@@ -0,0 +1,24 @@
+package shutdown_test
+
+import (
+	"testing"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/shutdown"
+)
+
+func TestConfig_Defaults(t *testing.T) {
+	var cfg shutdown.Config
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(env.ParseAs[shutdown.Config]),
+		fx.Populate(&cfg),
+	)
+	require.NoError(t, app.Err())
+	// every phase MUST complete before the application stop timeout, otherwise, workers would never be stopped
+	assert.Less(t, cfg.PreStopDelay+cfg.DrainTimeout+cfg.WorkerTimeout, app.StopTimeout())
+}
@@ -0,0 +1,218 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/actuator"
+	"github.com/hadroncorp/geck/observability/logging"
+)
+
+// Phase is a stage of the shutdown sequence. Phases run in ascending order.
+type Phase uint8
+
+const (
+	// PhaseServers servers stop accepting connections and drain in-flight requests and streams
+	// (bounded by Config.DrainTimeout).
+	PhaseServers Phase = iota + 1
+	// PhaseWorkers background workers (e.g. outbox relays, schedulers, dispatchers) stop
+	// (bounded by Config.WorkerTimeout).
+	PhaseWorkers
+)
+
+var phaseTextMap = map[Phase]string{
+	PhaseServers: "servers",
+	PhaseWorkers: "workers",
+}
+
+func (p Phase) String() string {
+	return phaseTextMap[p]
+}
+
+// StopFunc stops a component. ctx is done once the phase deadline is exceeded.
+type StopFunc func(ctx context.Context) error
+
+type stopHook struct {
+	name string
+	stop StopFunc
+}
+
+// Coordinator sequences the application shutdown, so instances stop without dropping requests:
+//
+//  1. Readiness flips to DOWN (Coordinator is an actuator.Actuator) and Draining channel gets closed.
+//  2. Waits Config.PreStopDelay, so load balancers stop routing new requests.
+//  3. PhaseServers: servers stop accepting connections, draining in-flight requests and streams.
+//  4. PhaseWorkers: background workers stop.
+//
+// Within a phase, components stop in reverse registration order. As components are registered while being
+// allocated (i.e. after their dependencies), dependents stop before their dependencies. Every phase is logged.
+//
+// Components register their stop hooks using Append. The Coordinator stop hook MUST run before any other hook,
+// use shutdownfx.ShutdownModule (included after every other module) to do so.
+type Coordinator struct {
+	config Config
+	logger logging.Logger
+
+	mu       sync.Mutex
+	hooks    map[Phase][]stopHook
+	draining chan struct{}
+	once     sync.Once
+	err      error
+}
+
+var _ actuator.Actuator = (*Coordinator)(nil)
+
+// NewCoordinatorParams Coordinator dependencies.
+type NewCoordinatorParams struct {
+	fx.In
+
+	Config Config
+	Logger logging.Logger
+}
+
+// NewCoordinator allocates a new Coordinator instance.
+func NewCoordinator(params NewCoordinatorParams) *Coordinator {
+	return &Coordinator{
+		config:   params.Config,
+		logger:   params.Logger,
+		hooks:    make(map[Phase][]stopHook),
+		draining: make(chan struct{}),
+	}
+}
+
+// Register registers a stop hook of component name for the given phase.
+func (c *Coordinator) Register(phase Phase, name string, stop StopFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks[phase] = append(c.hooks[phase], stopHook{name: name, stop: stop})
+}
+
+// Draining returns a channel closed once the shutdown sequence starts. Long-lived work (e.g. streams, long
+// polling) SHOULD finish once it is closed.
+func (c *Coordinator) Draining() <-chan struct{} {
+	return c.draining
+}
+
+// IsDraining indicates if the shutdown sequence has started.
+func (c *Coordinator) IsDraining() bool {
+	select {
+	case <-c.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// State reports StatusDown once the shutdown sequence has started, failing readiness checks.
+func (c *Coordinator) State(_ context.Context) (actuator.State, error) {
+	if c.IsDraining() {
+		return actuator.State{
+			Status:      actuator.StatusDown,
+			Description: "instance is shutting down",
+		}, nil
+	}
+	return actuator.State{
+		Status: actuator.StatusUp,
+	}, nil
+}
+
+// Shutdown runs the shutdown sequence. Subsequent calls return the first call result.
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.once.Do(func() {
+		c.err = c.shutdown(ctx)
+	})
+	return c.err
+}
+
+func (c *Coordinator) shutdown(ctx context.Context) error {
+	startTime := time.Now()
+	close(c.draining)
+	c.logger.Info().
+		WithField("pre_stop_delay", c.config.PreStopDelay).
+		WriteWithCtx(ctx, "shutdown started, readiness is down")
+	if c.config.PreStopDelay > 0 {
+		timer := time.NewTimer(c.config.PreStopDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+
+	errs := make([]error, 0, 2)
+	if err := c.runPhase(ctx, PhaseServers, c.config.DrainTimeout); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.runPhase(ctx, PhaseWorkers, c.config.WorkerTimeout); err != nil {
+		errs = append(errs, err)
+	}
+	err := errors.Join(errs...)
+	c.logger.Info().
+		WithField("duration", time.Since(startTime)).
+		WithField("error", err).
+		WriteWithCtx(ctx, "shutdown finished")
+	return err
+}
+
+func (c *Coordinator) runPhase(ctx context.Context, phase Phase, timeout time.Duration) error {
+	c.mu.Lock()
+	hooks := c.hooks[phase]
+	c.mu.Unlock()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	c.logger.Info().
+		WithField("phase", phase.String()).
+		WithField("total_components", len(hooks)).
+		WriteWithCtx(ctx, "shutdown phase started")
+	errs := make([]error, 0, len(hooks))
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hookStartTime := time.Now()
+		err := hooks[i].stop(ctx)
+		logEvent := c.logger.Debug()
+		if err != nil {
+			logEvent = c.logger.WithError(err)
+			errs = append(errs, fmt.Errorf("shutdown: %s: %w", hooks[i].name, err))
+		}
+		logEvent.
+			WithField("phase", phase.String()).
+			WithField("component", hooks[i].name).
+			WithField("duration", time.Since(hookStartTime)).
+			WriteWithCtx(ctx, "stopped component")
+	}
+	c.logger.Info().
+		WithField("phase", phase.String()).
+		WithField("duration", time.Since(startTime)).
+		WriteWithCtx(ctx, "shutdown phase finished")
+	return errors.Join(errs...)
+}
+
+// Append appends hook to lifecycle. If coordinator is not nil, hook.OnStop is registered as a stop hook of
+// component name for the given phase instead. Use it to allocate components optionally depending on a Coordinator.
+func Append(lifecycle fx.Lifecycle, coordinator *Coordinator, phase Phase, name string, hook fx.Hook) {
+	if coordinator == nil || hook.OnStop == nil {
+		lifecycle.Append(hook)
+		return
+	}
+	coordinator.Register(phase, name, hook.OnStop)
+	hook.OnStop = nil
+	if hook.OnStart != nil {
+		lifecycle.Append(hook)
+	}
+}
+
+// RegisterCoordinator appends the Coordinator stop hook to lifecycle.
+func RegisterCoordinator(lifecycle fx.Lifecycle, coordinator *Coordinator) {
+	lifecycle.Append(fx.Hook{
+		OnStop: coordinator.Shutdown,
+	})
+}
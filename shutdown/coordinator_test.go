@@ -0,0 +1,105 @@
+package shutdown_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/hadroncorp/geck/actuator"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/shutdown"
+)
+
+func TestCoordinator(t *testing.T) {
+	coordinator := shutdown.NewCoordinator(shutdown.NewCoordinatorParams{
+		Config: shutdown.Config{
+			PreStopDelay:  10 * time.Millisecond,
+			DrainTimeout:  20 * time.Millisecond,
+			WorkerTimeout: time.Second,
+		},
+		Logger: logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	stopped := make([]string, 0)
+	record := func(name string, err error) shutdown.StopFunc {
+		return func(ctx context.Context) error {
+			assert.True(t, coordinator.IsDraining())
+			mu.Lock()
+			stopped = append(stopped, name)
+			mu.Unlock()
+			return err
+		}
+	}
+	var startTime time.Time
+	coordinator.Register(shutdown.PhaseWorkers, "relay", record("relay", nil))
+	coordinator.Register(shutdown.PhaseServers, "http_server", func(ctx context.Context) error {
+		assert.GreaterOrEqual(t, time.Since(startTime), 10*time.Millisecond)
+		// slow drain, exceeding deadline
+		<-ctx.Done()
+		return record("http_server", ctx.Err())(ctx)
+	})
+	coordinator.Register(shutdown.PhaseWorkers, "scheduler", record("scheduler", nil))
+	coordinator.Register(shutdown.PhaseServers, "websocket_registry", record("websocket_registry", nil))
+
+	state, err := coordinator.State(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actuator.StatusUp, state.Status)
+
+	startTime = time.Now()
+	errShutdown := coordinator.Shutdown(ctx)
+	assert.True(t, errors.Is(errShutdown, context.DeadlineExceeded))
+	assert.Equal(t, []string{"websocket_registry", "http_server", "scheduler", "relay"}, stopped)
+	select {
+	case <-coordinator.Draining():
+	default:
+		t.Fatal("expected draining channel to be closed")
+	}
+	state, err = coordinator.State(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, actuator.StatusDown, state.Status)
+
+	// runs once
+	assert.Equal(t, errShutdown, coordinator.Shutdown(ctx))
+	assert.Len(t, stopped, 4)
+}
+
+func TestAppend(t *testing.T) {
+	lifecycle := fxtest.NewLifecycle(t)
+	coordinator := shutdown.NewCoordinator(shutdown.NewCoordinatorParams{
+		Logger: logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+	started, stoppedByLifecycle, stoppedByCoordinator := false, false, false
+	shutdown.Append(lifecycle, coordinator, shutdown.PhaseWorkers, "worker", fx.Hook{
+		OnStart: func(_ context.Context) error {
+			started = true
+			return nil
+		},
+		OnStop: func(_ context.Context) error {
+			stoppedByCoordinator = true
+			return nil
+		},
+	})
+	shutdown.Append(lifecycle, nil, shutdown.PhaseWorkers, "standalone", fx.Hook{
+		OnStop: func(_ context.Context) error {
+			stoppedByLifecycle = true
+			return nil
+		},
+	})
+	shutdown.RegisterCoordinator(lifecycle, coordinator)
+
+	lifecycle.RequireStart()
+	assert.True(t, started)
+	lifecycle.RequireStop()
+	assert.True(t, stoppedByLifecycle)
+	assert.True(t, stoppedByCoordinator)
+}
@@ -0,0 +1,32 @@
+package shutdownfx
+
+import (
+	"github.com/caarlos0/env/v11"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/actuator"
+	"github.com/hadroncorp/geck/actuatorfx"
+	"github.com/hadroncorp/geck/observability/loggingfx"
+	"github.com/hadroncorp/geck/shutdown"
+)
+
+// ShutdownModule provides a shutdown.Coordinator, registered as an actuator.Actuator so readiness flips once the
+// application starts shutting down.
+//
+// Include it after every other module: lifecycle stop hooks run in reverse order, so the coordinator stop hook
+// must be appended last to run before the rest.
+var ShutdownModule = fx.Module("shutdown",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("shutdown"),
+	),
+	fx.Provide(
+		env.ParseAs[shutdown.Config],
+		shutdown.NewCoordinator,
+		actuatorfx.AsActuator(func(coordinator *shutdown.Coordinator) actuator.Actuator {
+			return coordinator
+		}),
+	),
+	fx.Invoke(
+		shutdown.RegisterCoordinator,
+	),
+)
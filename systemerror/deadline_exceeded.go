@@ -0,0 +1,39 @@
+package systemerror
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeadlineExceeded the operation could not be completed before its deadline.
+var ErrDeadlineExceeded = errors.New("deadline exceeded")
+
+// NewDeadlineExceeded allocates a SystemError with StatusDeadlineExceeded and ErrDeadlineExceeded.
+//
+// The deadline expired before the operation could complete. The operation might have completed
+// successfully anyway.
+func NewDeadlineExceeded(reason, message string, metadata map[string]string) SystemError {
+	return SystemError{
+		ErrStatus:   StatusDeadlineExceeded,
+		ErrReason:   reason,
+		ErrMessage:  message,
+		ErrMetadata: metadata,
+		StaticError: ErrDeadlineExceeded,
+	}
+}
+
+// NewTimeout allocates a SystemError with StatusDeadlineExceeded and ErrDeadlineExceeded.
+//
+// The operation took longer than the given timeout. Attaches 'TIMEOUT' reason.
+func NewTimeout(timeout time.Duration) SystemError {
+	return SystemError{
+		ErrStatus:  StatusDeadlineExceeded,
+		ErrReason:  "TIMEOUT",
+		ErrMessage: fmt.Sprintf("operation timed out after %s", timeout),
+		ErrMetadata: map[string]string{
+			"timeout": timeout.String(),
+		},
+		StaticError: ErrDeadlineExceeded,
+	}
+}
@@ -0,0 +1,39 @@
+package systemerror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFailedPrecondition the system is not in a state required for the operation's execution.
+var ErrFailedPrecondition = errors.New("failed precondition")
+
+// NewFailedPrecondition allocates a SystemError with StatusFailedPrecondition and ErrFailedPrecondition.
+//
+// The system is not in a state required for the operation's execution.
+func NewFailedPrecondition(reason, message string, metadata map[string]string) SystemError {
+	return SystemError{
+		ErrStatus:   StatusFailedPrecondition,
+		ErrReason:   reason,
+		ErrMessage:  message,
+		ErrMetadata: metadata,
+		StaticError: ErrFailedPrecondition,
+	}
+}
+
+// NewVersionMismatch allocates a SystemError with StatusFailedPrecondition and ErrFailedPrecondition.
+//
+// The resource was modified since the client read it (optimistic concurrency).
+// Attaches 'VERSION_MISMATCH' reason.
+func NewVersionMismatch(expectedVersion, currentVersion string) SystemError {
+	return SystemError{
+		ErrStatus:  StatusFailedPrecondition,
+		ErrReason:  "VERSION_MISMATCH",
+		ErrMessage: fmt.Sprintf("resource version [%s] does not match expected version [%s]", currentVersion, expectedVersion),
+		ErrMetadata: map[string]string{
+			"expected_version": expectedVersion,
+			"current_version":  currentVersion,
+		},
+		StaticError: ErrFailedPrecondition,
+	}
+}
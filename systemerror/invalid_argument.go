@@ -55,6 +55,22 @@ func NewInvalidFormatArgument(argumentName, expectedFormat string) SystemError {
 	}
 }
 
+// NewMalformedArgument allocates a new SystemError using StatusInvalidArgument and ErrInvalidArgument.
+//
+// An invalid argument has been detected.
+// Attaches 'MALFORMED_ARGUMENT' reason. Use it when an argument could not be parsed (e.g. request binding).
+func NewMalformedArgument(argumentName, cause string) SystemError {
+	return SystemError{
+		ErrStatus:  StatusInvalidArgument,
+		ErrReason:  "MALFORMED_ARGUMENT",
+		ErrMessage: fmt.Sprintf("argument '%s' could not be parsed", argumentName),
+		ErrMetadata: map[string]string{
+			"cause": cause,
+		},
+		StaticError: ErrInvalidArgument,
+	}
+}
+
 // NewArgumentNotOneOf allocates a new SystemError using StatusInvalidArgument and ErrInvalidArgument.
 //
 // An invalid argument has been detected.
@@ -88,6 +104,20 @@ func NewNotEqualsArgument(argumentName, expectedValue string) SystemError {
 	}
 }
 
+// NewImmutableArgument allocates a new SystemError using StatusInvalidArgument and ErrInvalidArgument.
+//
+// An invalid argument has been detected.
+// Attaches 'IMMUTABLE_ARGUMENT' reason. Use it when a request attempts to modify a read-only field.
+func NewImmutableArgument(argumentName string) SystemError {
+	return SystemError{
+		ErrStatus:   StatusInvalidArgument,
+		ErrReason:   "IMMUTABLE_ARGUMENT",
+		ErrMessage:  fmt.Sprintf("argument '%s' cannot be modified", argumentName),
+		ErrMetadata: nil,
+		StaticError: ErrInvalidArgument,
+	}
+}
+
 func newInvalidPrefixSuffix(argumentName, expectedType, expectedValue string) SystemError {
 	return SystemError{
 		ErrStatus:  StatusInvalidArgument,
@@ -0,0 +1,42 @@
+package systemerror
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrResourceExhausted a resource quota (e.g. rate limit) has been exhausted.
+var ErrResourceExhausted = errors.New("resource exhausted")
+
+// NewResourceExhausted allocates a SystemError with StatusResourceExhausted and ErrResourceExhausted.
+//
+// Some resource quota has been exhausted.
+func NewResourceExhausted(reason, message string, metadata map[string]string) SystemError {
+	return SystemError{
+		ErrStatus:   StatusResourceExhausted,
+		ErrReason:   reason,
+		ErrMessage:  message,
+		ErrMetadata: metadata,
+		StaticError: ErrResourceExhausted,
+	}
+}
+
+// NewRateLimitExceeded allocates a SystemError with StatusResourceExhausted and ErrResourceExhausted.
+//
+// Rate limit has been exceeded, client should retry after the given duration.
+// Attaches 'RATE_LIMIT_EXCEEDED' reason.
+func NewRateLimitExceeded(limit int64, period, retryAfter time.Duration) SystemError {
+	return SystemError{
+		ErrStatus:  StatusResourceExhausted,
+		ErrReason:  "RATE_LIMIT_EXCEEDED",
+		ErrMessage: fmt.Sprintf("rate limit exceeded, expected at most %d requests per %s", limit, period),
+		ErrMetadata: map[string]string{
+			"limit":               strconv.FormatInt(limit, 10),
+			"period":              period.String(),
+			"retry_after_seconds": strconv.FormatInt(int64(retryAfter.Round(time.Second)/time.Second), 10),
+		},
+		StaticError: ErrResourceExhausted,
+	}
+}
@@ -28,28 +28,43 @@ const (
 	StatusUnavailable
 	StatusDeadlineExceeded
 	StatusDomain
+	// StatusUnprocessableContent the request is well-formed but cannot be processed with its current content
+	// (e.g. an idempotency key reused with a different payload).
+	StatusUnprocessableContent
 )
 
 var statusStringMap = map[Status]string{
-	StatusInvalidArgument:    "INVALID_ARGUMENT",
-	StatusFailedPrecondition: "FAILED_PRECONDITION",
-	StatusOutOfRange:         "OUT_OF_RANGE",
-	StatusUnauthenticated:    "UNAUTHENTICATED",
-	StatusPermissionDenied:   "PERMISSION_DENIED",
-	StatusNotFound:           "NOT_FOUND",
-	StatusAborted:            "ABORTED",
-	StatusAlreadyExists:      "ALREADY_EXISTS",
-	StatusResourceExhausted:  "RESOURCE_EXHAUSTED",
-	StatusCancelled:          "CANCELLED",
-	StatusDataLoss:           "DATA_LOSS",
-	StatusUnknown:            "UNKNOWN",
-	StatusInternal:           "INTERNAL",
-	StatusNotImplemented:     "NOT_IMPLEMENTED",
-	StatusUnavailable:        "UNAVAILABLE",
-	StatusDeadlineExceeded:   "DEADLINE_EXCEEDED",
-	StatusDomain:             "DOMAIN",
+	StatusInvalidArgument:      "INVALID_ARGUMENT",
+	StatusFailedPrecondition:   "FAILED_PRECONDITION",
+	StatusOutOfRange:           "OUT_OF_RANGE",
+	StatusUnauthenticated:      "UNAUTHENTICATED",
+	StatusPermissionDenied:     "PERMISSION_DENIED",
+	StatusNotFound:             "NOT_FOUND",
+	StatusAborted:              "ABORTED",
+	StatusAlreadyExists:        "ALREADY_EXISTS",
+	StatusResourceExhausted:    "RESOURCE_EXHAUSTED",
+	StatusCancelled:            "CANCELLED",
+	StatusDataLoss:             "DATA_LOSS",
+	StatusUnknown:              "UNKNOWN",
+	StatusInternal:             "INTERNAL",
+	StatusNotImplemented:       "NOT_IMPLEMENTED",
+	StatusUnavailable:          "UNAVAILABLE",
+	StatusDeadlineExceeded:     "DEADLINE_EXCEEDED",
+	StatusDomain:               "DOMAIN",
+	StatusUnprocessableContent: "UNPROCESSABLE_CONTENT",
 }
 
 func (s Status) String() string {
 	return statusStringMap[s]
 }
+
+// ParseStatus converts a Status full name (e.g. "NOT_FOUND") into a Status. Returns StatusUnknown if name is not
+// recognized.
+func ParseStatus(name string) Status {
+	for status, statusName := range statusStringMap {
+		if statusName == name {
+			return status
+		}
+	}
+	return StatusUnknown
+}
@@ -0,0 +1,19 @@
+package systemerror
+
+import "errors"
+
+// ErrUnavailable the service is currently unavailable.
+var ErrUnavailable = errors.New("unavailable")
+
+// NewUnavailable allocates a SystemError with StatusUnavailable and ErrUnavailable.
+//
+// The service is currently unavailable, most likely a transient condition. Clients might retry later.
+func NewUnavailable(reason, message string, metadata map[string]string) SystemError {
+	return SystemError{
+		ErrStatus:   StatusUnavailable,
+		ErrReason:   reason,
+		ErrMessage:  message,
+		ErrMetadata: metadata,
+		StaticError: ErrUnavailable,
+	}
+}
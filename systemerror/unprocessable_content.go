@@ -0,0 +1,19 @@
+package systemerror
+
+import "errors"
+
+// ErrUnprocessableContent the request content cannot be processed.
+var ErrUnprocessableContent = errors.New("unprocessable content")
+
+// NewUnprocessableContent allocates a SystemError with StatusUnprocessableContent and ErrUnprocessableContent.
+//
+// The request is well-formed but its content cannot be processed.
+func NewUnprocessableContent(reason, message string, metadata map[string]string) SystemError {
+	return SystemError{
+		ErrStatus:   StatusUnprocessableContent,
+		ErrReason:   reason,
+		ErrMessage:  message,
+		ErrMetadata: metadata,
+		StaticError: ErrUnprocessableContent,
+	}
+}
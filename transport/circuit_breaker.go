@@ -0,0 +1,109 @@
+package transport
+
+import (
+	"sync"
+	"time"
+)
+
+type circuitBreakerState uint8
+
+const (
+	circuitBreakerClosed circuitBreakerState = iota
+	circuitBreakerOpen
+	circuitBreakerHalfOpen
+)
+
+// circuitBreaker stops calling a failing dependency once failureThreshold consecutive failures were recorded.
+// After openTimeout, a single probe call is allowed (half-open state): its success closes the circuit, its failure
+// opens it again.
+type circuitBreaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+
+	mu            sync.Mutex
+	state         circuitBreakerState
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+func newCircuitBreaker(failureThreshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+	}
+}
+
+// allow indicates if a call might be performed. Callers MUST record the result of allowed calls.
+func (b *circuitBreaker) allow() bool {
+	if b.failureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case circuitBreakerOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = circuitBreakerHalfOpen
+		b.probeInFlight = true
+		return true
+	case circuitBreakerHalfOpen:
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record registers the result of an allowed call.
+func (b *circuitBreaker) record(success bool) {
+	if b.failureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeInFlight = false
+	if success {
+		b.state = circuitBreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == circuitBreakerHalfOpen || b.failures >= b.failureThreshold {
+		b.state = circuitBreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// circuitBreakerRegistry holds a circuitBreaker per key (e.g. hosts).
+type circuitBreakerRegistry struct {
+	failureThreshold int
+	openTimeout      time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func newCircuitBreakerRegistry(failureThreshold int, openTimeout time.Duration) *circuitBreakerRegistry {
+	return &circuitBreakerRegistry{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		breakers:         make(map[string]*circuitBreaker),
+	}
+}
+
+func (r *circuitBreakerRegistry) get(key string) *circuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	breaker, ok := r.breakers[key]
+	if !ok {
+		breaker = newCircuitBreaker(r.failureThreshold, r.openTimeout)
+		r.breakers[key] = breaker
+	}
+	return breaker
+}
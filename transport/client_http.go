@@ -0,0 +1,324 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/observability/tracing"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// TokenSourceHTTP provides bearer tokens for outbound requests. Implement it to exchange caller tokens
+// (e.g. OAuth 2.0 token exchange) or to use service credentials.
+type TokenSourceHTTP interface {
+	// Token retrieves a bearer token for ctx. An empty token skips the Authorization header.
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceForwardHTTP is a TokenSourceHTTP forwarding bearer tokens of callers (see security.GetTokenFromContext).
+//
+// ClientHTTP only forwards tokens to ConfigClientHTTP.ForwardTokenHosts, as callers' credentials must not leak to
+// third parties.
+type TokenSourceForwardHTTP struct{}
+
+var _ TokenSourceHTTP = TokenSourceForwardHTTP{}
+
+func (s TokenSourceForwardHTTP) Token(ctx context.Context) (string, error) {
+	token, _ := security.GetTokenFromContext(ctx)
+	return token, nil
+}
+
+// idempotentMethodsHTTP methods retried by ClientHTTP (RFC 9110 section 9.2.2).
+var idempotentMethodsHTTP = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete,
+}
+
+// ClientHTTP is an HTTP client calling other services.
+//
+// Outbound requests carry the request identifier, trace context (e.g. W3C traceparent) and bearer token of the
+// caller (only to ConfigClientHTTP.ForwardTokenHosts). Idempotent requests failing with transient errors are
+// retried using exponential backoff, and hosts failing consecutively get their calls short-circuited
+// (systemerror.StatusUnavailable) for a while. Failed responses are decoded back into systemerror.Error values
+// (see DecodeErrorResponseHTTP).
+type ClientHTTP struct {
+	config      ConfigClientHTTP
+	tokenSource TokenSourceHTTP
+	propagator  tracing.Propagator
+	breakers    *circuitBreakerRegistry
+	client      *http.Client
+	logger      logging.Logger
+}
+
+var _ http.RoundTripper = (*ClientHTTP)(nil)
+
+// NewClientHTTPParams ClientHTTP dependencies.
+type NewClientHTTPParams struct {
+	fx.In
+
+	Config      ConfigClientHTTP
+	TokenSource TokenSourceHTTP   `optional:"true"`
+	Transport   http.RoundTripper `optional:"true"`
+	// Propagator trace context format of outbound requests. Defaults to tracing.PropagatorW3C.
+	Propagator tracing.Propagator `optional:"true"`
+	Logger     logging.Logger
+}
+
+// NewClientHTTP allocates a new ClientHTTP instance. Uses http.DefaultTransport if no http.RoundTripper
+// was given.
+func NewClientHTTP(params NewClientHTTPParams) *ClientHTTP {
+	tokenSource := params.TokenSource
+	if tokenSource == nil && params.Config.ForwardToken {
+		tokenSource = TokenSourceForwardHTTP{}
+	}
+	base := params.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	propagator := params.Propagator
+	if propagator == nil {
+		propagator = tracing.PropagatorW3C{}
+	}
+	c := &ClientHTTP{
+		config:      params.Config,
+		tokenSource: tokenSource,
+		propagator:  propagator,
+		breakers:    newCircuitBreakerRegistry(params.Config.BreakerFailureThreshold, params.Config.BreakerOpenTimeout),
+		logger:      params.Logger,
+	}
+	c.client = &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return c.roundTrip(base, req)
+		}),
+	}
+	return c
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Client retrieves an http.Client using ClientHTTP capabilities, except error decoding. Use it to integrate
+// third-party SDKs.
+func (c *ClientHTTP) Client() *http.Client {
+	return c.client
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *ClientHTTP) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.client.Transport.RoundTrip(req)
+}
+
+// Do sends req, returning a systemerror.Error if the response status code is 400 or greater.
+func (c *ClientHTTP) Do(req *http.Request) (*http.Response, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	} else if res.StatusCode >= http.StatusBadRequest {
+		return nil, DecodeErrorResponseHTTP(res)
+	}
+	return res, nil
+}
+
+// DoJSON sends a request with body encoded as JSON (skipped if nil), decoding the response body into out
+// (skipped if nil).
+func (c *ClientHTTP) DoJSON(ctx context.Context, method, url string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON+", "+MIMEApplicationProblemJSON)
+	if body != nil {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (c *ClientHTTP) roundTrip(base http.RoundTripper, srcReq *http.Request) (*http.Response, error) {
+	ctx := srcReq.Context()
+	req, err := c.newOutboundRequest(srcReq)
+	if err != nil {
+		return nil, err
+	}
+
+	host := req.URL.Host
+	breaker := c.breakers.get(host)
+	retryable := isIdempotentRequestHTTP(req)
+	for attempt := 0; ; attempt++ {
+		if !breaker.allow() {
+			return nil, systemerror.NewUnavailable("CIRCUIT_OPEN", "circuit breaker is open for host "+host,
+				map[string]string{"host": host})
+		}
+		if attempt > 0 && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		res, errAttempt := c.doAttempt(base, req)
+		breaker.record(errAttempt == nil && res.StatusCode < http.StatusInternalServerError)
+		if !retryable || attempt >= c.config.MaxRetries || !isRetryableResponseHTTP(res, errAttempt) ||
+			ctx.Err() != nil {
+			return res, errAttempt
+		}
+
+		delay := c.newRetryDelay(attempt, res)
+		if res != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBodySize))
+			_ = res.Body.Close()
+		}
+		c.logger.Debug().
+			WithField("host", host).
+			WithField("attempt", attempt+1).
+			WithField("delay", delay).
+			WithField("error", errAttempt).
+			WriteWithCtx(ctx, "retrying http request")
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// newOutboundRequest clones req, attaching propagated headers.
+func (c *ClientHTTP) newOutboundRequest(srcReq *http.Request) (*http.Request, error) {
+	ctx := srcReq.Context()
+	req := srcReq.Clone(ctx)
+	if requestID := GetRequestIDFromContext(ctx); requestID != "" && req.Header.Get(c.config.RequestIDHeader) == "" {
+		req.Header.Set(c.config.RequestIDHeader, requestID)
+	}
+	if spanID, err := tracing.GetSpanFromContext(ctx); err == nil && req.Header.Get(HeaderSpanID) == "" {
+		req.Header.Set(HeaderSpanID, spanID)
+	}
+	if span, err := tracing.GetSpanContextFromContext(ctx); err == nil &&
+		req.Header.Get(tracing.HeaderTraceParent) == "" {
+		c.propagator.Inject(span, req.Header)
+	}
+	if c.tokenSource != nil && req.Header.Get(echo.HeaderAuthorization) == "" && c.isTokenHost(req.URL) {
+		token, err := c.tokenSource.Token(ctx)
+		if err != nil {
+			return nil, err
+		} else if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+	}
+	return req, nil
+}
+
+// isTokenHost indicates if the token source applies to target. Forwarded tokens are restricted to
+// ConfigClientHTTP.ForwardTokenHosts.
+func (c *ClientHTTP) isTokenHost(target *url.URL) bool {
+	if _, forward := c.tokenSource.(TokenSourceForwardHTTP); !forward {
+		return true
+	}
+	return slices.Contains(c.config.ForwardTokenHosts, target.Host) ||
+		slices.Contains(c.config.ForwardTokenHosts, target.Hostname())
+}
+
+func (c *ClientHTTP) doAttempt(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	timeout, ok := c.config.HostTimeoutValues[req.URL.Host]
+	if !ok {
+		timeout = c.config.Timeout
+	}
+	if timeout <= 0 {
+		return base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	res, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// timeout covers body reads as well
+	res.Body = cancelOnCloseReader{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// newRetryDelay computes the delay before the next attempt using exponential backoff with full jitter. Retry-After
+// headers take precedence.
+func (c *ClientHTTP) newRetryDelay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get(echo.HeaderRetryAfter)); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, c.config.RetryMaxDelay)
+		}
+	}
+	delay := c.config.RetryBaseDelay << attempt
+	if delay <= 0 || delay > c.config.RetryMaxDelay {
+		delay = c.config.RetryMaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay) + 1
+}
+
+func isIdempotentRequestHTTP(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// body cannot be replayed
+		return false
+	}
+	return slices.Contains(idempotentMethodsHTTP, req.Method) || req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+func isRetryableResponseHTTP(res *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return !errors.Is(err, context.Canceled) && (errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, context.DeadlineExceeded))
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+type cancelOnCloseReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r cancelOnCloseReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
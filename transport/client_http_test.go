@@ -0,0 +1,155 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/observability/tracing"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func newTestClientHTTP(breakerThreshold int) *transport.ClientHTTP {
+	return transport.NewClientHTTP(transport.NewClientHTTPParams{
+		Config: transport.ConfigClientHTTP{
+			Timeout:                 time.Second,
+			MaxRetries:              2,
+			RetryBaseDelay:          time.Millisecond,
+			RetryMaxDelay:           5 * time.Millisecond,
+			BreakerFailureThreshold: breakerThreshold,
+			BreakerOpenTimeout:      time.Minute,
+			RequestIDHeader:         echo.HeaderXRequestID,
+			ForwardToken:            true,
+			ForwardTokenHosts:       []string{"127.0.0.1"},
+		},
+		Logger: logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+}
+
+func TestClientHTTP_DoJSON(t *testing.T) {
+	var attempts atomic.Int32
+	var gotHeader http.Header
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	e.GET("/flaky", func(c echo.Context) error {
+		if attempts.Add(1) < 3 {
+			return c.NoContent(http.StatusServiceUnavailable)
+		}
+		gotHeader = c.Request().Header.Clone()
+		return c.JSON(http.StatusOK, transport.Data{Data: "ok"})
+	})
+	e.POST("/flaky", func(c echo.Context) error {
+		attempts.Add(1)
+		return c.NoContent(http.StatusServiceUnavailable)
+	})
+	e.GET("/tasks/:id", func(c echo.Context) error {
+		return systemerror.NewResourceNotFound[transport.Data](c.Param("id"))
+	})
+	e.GET("/problem", func(c echo.Context) error {
+		errHandler := transport.NewErrorHandlerEcho(transport.ConfigHTTP{ErrorFormat: transport.ErrorFormatProblem})
+		errHandler(systemerror.NewArgumentOutOfRange("page_size", 1, 100), c)
+		return nil
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	ctx := transport.NewContextWithRequestID(context.Background(), "req-1")
+	ctx = context.WithValue(ctx, tracing.SpanContextKey, "span-1")
+	ctx = security.NewContextWithToken(ctx, "some-token")
+	client := newTestClientHTTP(0)
+
+	t.Run("retries idempotent requests", func(t *testing.T) {
+		attempts.Store(0)
+		var out transport.Data
+		require.NoError(t, client.DoJSON(ctx, http.MethodGet, server.URL+"/flaky", nil, &out))
+		assert.Equal(t, "ok", out.Data)
+		assert.EqualValues(t, 3, attempts.Load())
+		assert.Equal(t, "req-1", gotHeader.Get(echo.HeaderXRequestID))
+		assert.Equal(t, "span-1", gotHeader.Get(transport.HeaderSpanID))
+		assert.Equal(t, "Bearer some-token", gotHeader.Get(echo.HeaderAuthorization))
+	})
+
+	t.Run("skips token of non-listed hosts", func(t *testing.T) {
+		attempts.Store(2)
+		target := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+		require.NoError(t, client.DoJSON(ctx, http.MethodGet, target+"/flaky", nil, nil))
+		assert.Equal(t, "req-1", gotHeader.Get(echo.HeaderXRequestID))
+		assert.Empty(t, gotHeader.Get(echo.HeaderAuthorization))
+	})
+
+	t.Run("skips retries of unsafe requests", func(t *testing.T) {
+		attempts.Store(0)
+		err := client.DoJSON(ctx, http.MethodPost, server.URL+"/flaky", map[string]string{"name": "task"}, nil)
+		assert.ErrorIs(t, err, systemerror.ErrUnavailable)
+		assert.EqualValues(t, 1, attempts.Load())
+	})
+
+	t.Run("decodes errors", func(t *testing.T) {
+		err := client.DoJSON(ctx, http.MethodGet, server.URL+"/tasks/123", nil, nil)
+		assert.ErrorIs(t, err, systemerror.ErrNotFound)
+		var sysErr systemerror.Error
+		require.True(t, errors.As(err, &sysErr))
+		assert.Equal(t, "RESOURCE_NOT_FOUND", sysErr.Reason())
+		assert.Equal(t, "123", sysErr.Metadata()["resource_key"])
+	})
+
+	t.Run("decodes problem details", func(t *testing.T) {
+		err := client.DoJSON(ctx, http.MethodGet, server.URL+"/problem", nil, nil)
+		assert.ErrorIs(t, err, systemerror.ErrInvalidArgument)
+		var sysErr systemerror.Error
+		require.True(t, errors.As(err, &sysErr))
+		assert.Equal(t, "ARGUMENT_OUT_OF_RANGE", sysErr.Reason())
+	})
+}
+
+func TestNewConfigClientHTTP(t *testing.T) {
+	cfg, err := transport.NewConfigClientHTTP()
+	require.NoError(t, err)
+	assert.False(t, cfg.ForwardToken)
+
+	t.Setenv("HTTP_CLIENT_FORWARD_TOKEN", "true")
+	_, err = transport.NewConfigClientHTTP()
+	assert.Error(t, err)
+
+	t.Setenv("HTTP_CLIENT_FORWARD_TOKEN_HOSTS", "tasks.internal,reports.internal:8080")
+	cfg, err = transport.NewConfigClientHTTP()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tasks.internal", "reports.internal:8080"}, cfg.ForwardTokenHosts)
+}
+
+func TestClientHTTP_CircuitBreaker(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClientHTTP(2)
+	for i := 0; i < 2; i++ {
+		err := client.DoJSON(context.Background(), http.MethodGet, server.URL, nil, nil)
+		var sysErr systemerror.Error
+		require.True(t, errors.As(err, &sysErr))
+		assert.Equal(t, systemerror.StatusInternal, sysErr.Status())
+	}
+	err := client.DoJSON(context.Background(), http.MethodGet, server.URL, nil, nil)
+	var sysErr systemerror.Error
+	require.True(t, errors.As(err, &sysErr))
+	assert.ErrorIs(t, err, systemerror.ErrUnavailable)
+	assert.Equal(t, "CIRCUIT_OPEN", sysErr.Reason())
+	assert.True(t, strings.HasPrefix(sysErr.Metadata()["host"], "127.0.0.1"))
+	assert.EqualValues(t, 2, attempts.Load())
+}
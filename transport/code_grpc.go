@@ -0,0 +1,29 @@
+package transport
+
+import (
+	"google.golang.org/grpc/codes"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// took from: https://cloud.google.com/apis/design/errors
+var statusCodeGRPCMap = map[systemerror.Status]codes.Code{
+	systemerror.StatusInvalidArgument:      codes.InvalidArgument,
+	systemerror.StatusFailedPrecondition:   codes.FailedPrecondition,
+	systemerror.StatusOutOfRange:           codes.OutOfRange,
+	systemerror.StatusUnauthenticated:      codes.Unauthenticated,
+	systemerror.StatusPermissionDenied:     codes.PermissionDenied,
+	systemerror.StatusNotFound:             codes.NotFound,
+	systemerror.StatusAborted:              codes.Aborted,
+	systemerror.StatusAlreadyExists:        codes.AlreadyExists,
+	systemerror.StatusResourceExhausted:    codes.ResourceExhausted,
+	systemerror.StatusCancelled:            codes.Canceled,
+	systemerror.StatusDataLoss:             codes.DataLoss,
+	systemerror.StatusUnknown:              codes.Unknown,
+	systemerror.StatusInternal:             codes.Internal,
+	systemerror.StatusNotImplemented:       codes.Unimplemented,
+	systemerror.StatusUnavailable:          codes.Unavailable,
+	systemerror.StatusDeadlineExceeded:     codes.DeadlineExceeded,
+	systemerror.StatusDomain:               codes.FailedPrecondition,
+	systemerror.StatusUnprocessableContent: codes.InvalidArgument,
+}
@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// ErrInvalidVersionETag the entity tag was not generated by NewVersionETag.
+var ErrInvalidVersionETag = errors.New("transport: invalid version entity tag")
+
+// VersionedResource a resource exposing validators for conditional requests. Implemented by
+// persistence.Auditable and persistence.AuditableView (thus, every view embedding it).
+type VersionedResource interface {
+	GetVersion() int64
+	GetLastUpdateTime() time.Time
+}
+
+// NewVersionETag allocates a strong entity tag from a resource version (e.g. 3 -> "3").
+func NewVersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseVersionETag parses an entity tag allocated by NewVersionETag. Weak tags are rejected.
+func ParseVersionETag(etag string) (int64, error) {
+	etag = strings.TrimSpace(etag)
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, ErrInvalidVersionETag
+	}
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidVersionETag
+	}
+	return version, nil
+}
+
+// GetIfMatchVersionEcho retrieves the resource version expected by the client (If-Match header). Use it to
+// enforce optimistic concurrency within repositories (e.g. UPDATE ... WHERE version = ?). Returns false if
+// the header is absent, a wildcard or a list of entity tags.
+func GetIfMatchVersionEcho(c echo.Context) (int64, bool, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if ifMatch == "" || ifMatch == "*" || strings.Contains(ifMatch, ",") {
+		return 0, false, nil
+	}
+	version, err := ParseVersionETag(ifMatch)
+	if err != nil {
+		return 0, false, systemerror.NewInvalidFormatArgument(HeaderIfMatch, "strong entity tag")
+	}
+	return version, true, nil
+}
+
+// SetValidatorsEcho writes the ETag (from version) and Last-Modified headers of resource.
+func SetValidatorsEcho(c echo.Context, resource VersionedResource) {
+	header := c.Response().Header()
+	header.Set(HeaderETag, NewVersionETag(resource.GetVersion()))
+	if lastUpdateTime := resource.GetLastUpdateTime(); !lastUpdateTime.IsZero() {
+		header.Set(echo.HeaderLastModified, lastUpdateTime.UTC().Format(http.TimeFormat))
+	}
+}
+
+// JSONConditionalEcho writes resource validators and sends a JSON response. If the client representation is
+// still valid (If-None-Match, If-Modified-Since), responds with http.StatusNotModified instead.
+//
+// Conditional headers are only evaluated for GET and HEAD requests.
+func JSONConditionalEcho(c echo.Context, code int, resource VersionedResource, body any) error {
+	SetValidatorsEcho(c, resource)
+	method := c.Request().Method
+	if (method == http.MethodGet || method == http.MethodHead) &&
+		isNotModified(c.Request(), NewVersionETag(resource.GetVersion()), resource.GetLastUpdateTime()) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(code, body)
+}
+
+// CheckPreconditionsEcho evaluates If-Match and If-Unmodified-Since headers against the current state of
+// resource. Call it before modifying resources (e.g. PUT, PATCH, DELETE).
+//
+// Returns a systemerror StatusFailedPrecondition error (thus, http.StatusPreconditionFailed) if the resource
+// was modified since the client read it.
+func CheckPreconditionsEcho(c echo.Context, resource VersionedResource) error {
+	header := c.Request().Header
+	etag := NewVersionETag(resource.GetVersion())
+	if ifMatch := header.Get(HeaderIfMatch); ifMatch != "" {
+		if !matchesETagStrong(ifMatch, etag) {
+			return systemerror.NewVersionMismatch(ifMatch, etag)
+		}
+		// If-Unmodified-Since is ignored if If-Match is present, as stated by RFC 9110
+		return nil
+	}
+
+	ifUnmodifiedSince, err := http.ParseTime(header.Get(HeaderIfUnmodifiedSince))
+	lastUpdateTime := resource.GetLastUpdateTime()
+	if err != nil || lastUpdateTime.IsZero() {
+		return nil
+	} else if lastUpdateTime.Truncate(time.Second).After(ifUnmodifiedSince) {
+		return systemerror.NewVersionMismatch(ifUnmodifiedSince.Format(http.TimeFormat),
+			lastUpdateTime.UTC().Format(http.TimeFormat))
+	}
+	return nil
+}
+
+// matchesETagStrong performs a strong comparison of etag against a list of entity tags (e.g. If-Match header
+// value). Weak entity tags never match.
+func matchesETagStrong(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
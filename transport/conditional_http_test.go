@@ -0,0 +1,134 @@
+package transport_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestJSONConditionalEcho(t *testing.T) {
+	lastUpdateTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	resource := persistence.ConvertAuditableView(persistence.Auditable{
+		LastUpdateTime: lastUpdateTime,
+		Version:        3,
+	})
+	tests := []struct {
+		name     string
+		method   string
+		header   http.Header
+		wantCode int
+	}{
+		{
+			name:     "no conditions",
+			method:   http.MethodGet,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "matching etag",
+			method:   http.MethodGet,
+			header:   http.Header{transport.HeaderIfNoneMatch: []string{`"3"`}},
+			wantCode: http.StatusNotModified,
+		},
+		{
+			name:     "stale etag",
+			method:   http.MethodGet,
+			header:   http.Header{transport.HeaderIfNoneMatch: []string{`"2"`}},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "not modified since",
+			method:   http.MethodGet,
+			header:   http.Header{echo.HeaderIfModifiedSince: []string{lastUpdateTime.Format(http.TimeFormat)}},
+			wantCode: http.StatusNotModified,
+		},
+		{
+			name:     "unsafe method",
+			method:   http.MethodPut,
+			header:   http.Header{transport.HeaderIfNoneMatch: []string{`"3"`}},
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, "/tasks/1", nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			err := transport.JSONConditionalEcho(e.NewContext(req, rec), http.StatusOK, resource,
+				transport.Data{Data: resource})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, `"3"`, rec.Header().Get(transport.HeaderETag))
+			assert.Equal(t, lastUpdateTime.Format(http.TimeFormat), rec.Header().Get(echo.HeaderLastModified))
+		})
+	}
+}
+
+func TestCheckPreconditionsEcho(t *testing.T) {
+	lastUpdateTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	resource := persistence.Auditable{
+		LastUpdateTime: lastUpdateTime,
+		Version:        3,
+	}
+	tests := []struct {
+		name    string
+		header  http.Header
+		wantErr bool
+	}{
+		{
+			name: "no conditions",
+		},
+		{
+			name:   "matching etag",
+			header: http.Header{transport.HeaderIfMatch: []string{`"2", "3"`}},
+		},
+		{
+			name:    "stale etag",
+			header:  http.Header{transport.HeaderIfMatch: []string{`"2"`}},
+			wantErr: true,
+		},
+		{
+			name:    "weak etag",
+			header:  http.Header{transport.HeaderIfMatch: []string{`W/"3"`}},
+			wantErr: true,
+		},
+		{
+			name:   "unmodified since",
+			header: http.Header{transport.HeaderIfUnmodifiedSince: []string{lastUpdateTime.Format(http.TimeFormat)}},
+		},
+		{
+			name: "modified since",
+			header: http.Header{transport.HeaderIfUnmodifiedSince: []string{
+				lastUpdateTime.Add(-time.Hour).Format(http.TimeFormat),
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/tasks/1", nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			err := transport.CheckPreconditionsEcho(e.NewContext(req, httptest.NewRecorder()), resource)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, systemerror.ErrFailedPrecondition))
+		})
+	}
+}
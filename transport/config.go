@@ -1,29 +1,212 @@
 package transport
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
 	"github.com/caarlos0/env/v11"
 	"github.com/emirpasic/gods/v2/sets"
 	"github.com/emirpasic/gods/v2/sets/hashset"
+	"github.com/labstack/gommon/bytes"
+
+	"github.com/hadroncorp/geck/ratelimit"
 )
 
 type ConfigHTTP struct {
-	Address                 string   `env:"HTTP_SERVER_ADDRESS" envDefault:":8080"`
+	Address string `env:"HTTP_SERVER_ADDRESS" envDefault:":8080"`
+	// AuthenticationWhitelist route patterns skipping authentication, using RouteMatcher nomenclature
+	// (e.g. /healthz,/public/**,GET /v1/catalog/**).
 	AuthenticationWhitelist []string `env:"HTTP_SERVER_AUTHN_WHITELIST" envDefault:"/healthz,/readiness"`
 	RequestIDTargetHeader   string   `env:"HTTP_REQ_ID_TARGET_HEADER" envDefault:"X-Request-ID"`
+	// ErrorFormat how errors are rendered: errors (Errors structure), problem (RFC 9457 problem details) or
+	// negotiate (problem details only if requested through the Accept header).
+	ErrorFormat ErrorFormatHTTP `env:"HTTP_ERROR_FORMAT" envDefault:"errors"`
+	// ProblemTypeBaseURI base URI of problem detail types. Error reasons are appended to it
+	// (e.g. RESOURCE_NOT_FOUND -> urn:problem-type:resource-not-found).
+	ProblemTypeBaseURI string `env:"HTTP_PROBLEM_TYPE_BASE_URI" envDefault:"urn:problem-type:"`
+	// CORS cross-origin resource sharing configuration.
+	CORS ConfigCORSHTTP `envPrefix:"HTTP_CORS_"`
+	// SecurityHeaders security response headers configuration.
+	SecurityHeaders ConfigSecurityHeadersHTTP `envPrefix:"HTTP_SECURITY_HEADERS_"`
+	// BodyLimit maximum request body size (e.g. 512K, 4M). Request bodies are not limited if empty.
+	BodyLimit string `env:"HTTP_SERVER_BODY_LIMIT"`
+	// BodyLimitRoutes body limits per route, using the nomenclature: ROUTE_PATTERN=SIZE, where ROUTE_PATTERN follows
+	// RouteMatcher nomenclature (e.g. POST /v1/documents=50M). Route-specific limits take precedence over BodyLimit.
+	BodyLimitRoutes map[string]string `env:"HTTP_SERVER_BODY_LIMIT_ROUTES" envKeyValSeparator:"="`
+	// ReadTimeout maximum duration for reading entire requests, including bodies. Zero means no timeout.
+	ReadTimeout time.Duration `env:"HTTP_SERVER_READ_TIMEOUT" envDefault:"0s"`
+	// ReadHeaderTimeout maximum duration for reading request headers. Zero means ReadTimeout is used.
+	ReadHeaderTimeout time.Duration `env:"HTTP_SERVER_READ_HEADER_TIMEOUT" envDefault:"0s"`
+	// WriteTimeout maximum duration before timing out writes of responses. Zero means no timeout.
+	//
+	// Keep it disabled if streaming responses are served.
+	WriteTimeout time.Duration `env:"HTTP_SERVER_WRITE_TIMEOUT" envDefault:"0s"`
+	// IdleTimeout maximum duration to wait for the next request when keep-alives are enabled. Zero means
+	// ReadTimeout is used.
+	IdleTimeout time.Duration `env:"HTTP_SERVER_IDLE_TIMEOUT" envDefault:"0s"`
+	// HandlerTimeout maximum duration of request handling. Handlers are notified through request context
+	// cancellation. Zero means no timeout.
+	HandlerTimeout time.Duration `env:"HTTP_SERVER_HANDLER_TIMEOUT" envDefault:"0s"`
+	// HandlerTimeoutRoutes handler timeouts per route, using the nomenclature: ROUTE_PATTERN=DURATION
+	// (e.g. POST /v1/reports=2m,GET /v1/events/**=0s). Route-specific timeouts take precedence over HandlerTimeout.
+	HandlerTimeoutRoutes map[string]string `env:"HTTP_SERVER_HANDLER_TIMEOUT_ROUTES" envKeyValSeparator:"="`
+	// TrustedProxies IP addresses or CIDR ranges (e.g. 10.0.0.0/8) of reverse proxies allowed to forward client
+	// IP addresses through the X-Forwarded-For header. Client IP addresses are taken from connections if empty.
+	TrustedProxies []string `env:"HTTP_SERVER_TRUSTED_PROXIES"`
+
+	// Deprecated: Use AuthenticationWhitelistMatcher instead, as it supports wildcards, path parameters and
+	// method qualifiers.
+	AuthenticationWhitelistSet     sets.Set[string]
+	AuthenticationWhitelistMatcher *RouteMatcher[struct{}]
+	BodyLimitMatcher               *RouteMatcher[string]
+	HandlerTimeoutMatcher          *RouteMatcher[time.Duration]
+	TrustedProxyRanges             []*net.IPNet
+}
+
+// ConfigCORSHTTP configuration structure for cross-origin resource sharing.
+type ConfigCORSHTTP struct {
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// AllowOrigins origins allowed to access resources. Wildcards (*, ?) are supported.
+	AllowOrigins     []string      `env:"ALLOW_ORIGINS" envDefault:"*"`
+	AllowMethods     []string      `env:"ALLOW_METHODS" envDefault:"GET,HEAD,PUT,PATCH,POST,DELETE"`
+	AllowHeaders     []string      `env:"ALLOW_HEADERS"`
+	ExposeHeaders    []string      `env:"EXPOSE_HEADERS"`
+	AllowCredentials bool          `env:"ALLOW_CREDENTIALS" envDefault:"false"`
+	MaxAge           time.Duration `env:"MAX_AGE" envDefault:"0s"`
+}
 
-	AuthenticationWhitelistSet sets.Set[string]
+// ConfigSecurityHeadersHTTP configuration structure for security response headers. Empty values skip
+// their headers.
+type ConfigSecurityHeadersHTTP struct {
+	Enabled            bool   `env:"ENABLED" envDefault:"false"`
+	ContentTypeNosniff string `env:"CONTENT_TYPE_NOSNIFF" envDefault:"nosniff"`
+	FrameOptions       string `env:"FRAME_OPTIONS" envDefault:"DENY"`
+	// HSTSMaxAge Strict-Transport-Security max-age. Only sent over TLS (or X-Forwarded-Proto https). Zero skips
+	// the header.
+	HSTSMaxAge            time.Duration `env:"HSTS_MAX_AGE" envDefault:"0s"`
+	HSTSIncludeSubdomains bool          `env:"HSTS_INCLUDE_SUBDOMAINS" envDefault:"true"`
+	HSTSPreload           bool          `env:"HSTS_PRELOAD" envDefault:"false"`
+	ContentSecurityPolicy string        `env:"CONTENT_SECURITY_POLICY"`
+	// CSPReportOnly sends ContentSecurityPolicy using Content-Security-Policy-Report-Only header.
+	CSPReportOnly  bool   `env:"CSP_REPORT_ONLY" envDefault:"false"`
+	ReferrerPolicy string `env:"REFERRER_POLICY" envDefault:"strict-origin-when-cross-origin"`
 }
 
 func NewConfigHTTP() (ConfigHTTP, error) {
 	cfg, err := env.ParseAs[ConfigHTTP]()
 	if err != nil {
 		return ConfigHTTP{}, err
+	} else if err = cfg.ErrorFormat.validate(); err != nil {
+		return ConfigHTTP{}, err
 	}
 
 	cfg.AuthenticationWhitelistSet = hashset.New(cfg.AuthenticationWhitelist...)
+	cfg.AuthenticationWhitelistMatcher, err = NewRouteMatcherSet(cfg.AuthenticationWhitelist...)
+	if err != nil {
+		return ConfigHTTP{}, err
+	}
+
+	if cfg.BodyLimit != "" {
+		if _, err = bytes.Parse(cfg.BodyLimit); err != nil {
+			return ConfigHTTP{}, fmt.Errorf("transport: invalid body limit %q: %w", cfg.BodyLimit, err)
+		}
+	}
+	cfg.BodyLimitMatcher = NewRouteMatcher[string]()
+	for route, limit := range cfg.BodyLimitRoutes {
+		if _, err = bytes.Parse(limit); err != nil {
+			return ConfigHTTP{}, fmt.Errorf("transport: invalid body limit %q: %w", limit, err)
+		} else if err = cfg.BodyLimitMatcher.Add(route, limit); err != nil {
+			return ConfigHTTP{}, err
+		}
+	}
+	cfg.HandlerTimeoutMatcher = NewRouteMatcher[time.Duration]()
+	for route, timeoutRaw := range cfg.HandlerTimeoutRoutes {
+		timeout, errParse := time.ParseDuration(timeoutRaw)
+		if errParse != nil {
+			return ConfigHTTP{}, errParse
+		} else if err = cfg.HandlerTimeoutMatcher.Add(route, timeout); err != nil {
+			return ConfigHTTP{}, err
+		}
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		ipRange, errParse := parseIPRange(proxy)
+		if errParse != nil {
+			return ConfigHTTP{}, fmt.Errorf("transport: invalid trusted proxy %q: %w", proxy, errParse)
+		}
+		cfg.TrustedProxyRanges = append(cfg.TrustedProxyRanges, ipRange)
+	}
+	return cfg, nil
+}
+
+// parseIPRange parses either a CIDR range or a single IP address.
+func parseIPRange(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, ipRange, err := net.ParseCIDR(value)
+		return ipRange, err
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, errors.New("invalid IP address")
+	}
+	bits := net.IPv6len * 8
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, net.IPv4len*8
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// ConfigVersioningHTTP configuration structure for API version routing.
+type ConfigVersioningHTTP struct {
+	// DefaultVersion API version routed to if requests specify none (e.g. GET /tasks -> GET /v2/tasks). Requests
+	// without version are not routed if empty.
+	DefaultVersion string `env:"HTTP_API_DEFAULT_VERSION"`
+	// VersionHeader request header selecting an API version (e.g. Accept-Version: v2).
+	VersionHeader string `env:"HTTP_API_VERSION_HEADER" envDefault:"Accept-Version"`
+	// MediaTypeVendor vendor of media types selecting an API version (e.g. acme -> application/vnd.acme.v2+json or
+	// application/vnd.acme+json; version=v2). Vendor media types are ignored if empty.
+	MediaTypeVendor string `env:"HTTP_API_MEDIA_TYPE_VENDOR"`
+	// DeprecatedVersions deprecation dates (RFC 3339) per API version, using the nomenclature: VERSION=DATE
+	// (e.g. v1=2025-01-01T00:00:00Z).
+	DeprecatedVersions map[string]string `env:"HTTP_API_DEPRECATED_VERSIONS" envKeyValSeparator:"="`
+	// SunsetVersions dates (RFC 3339) API versions will stop being served, using the nomenclature: VERSION=DATE
+	// (e.g. v1=2025-06-30T00:00:00Z).
+	SunsetVersions map[string]string `env:"HTTP_API_SUNSET_VERSIONS" envKeyValSeparator:"="`
+	// DeprecationLink URL of migration documentation, linked from responses of deprecated versions.
+	DeprecationLink string `env:"HTTP_API_DEPRECATION_LINK"`
+
+	DeprecationTimes map[string]time.Time
+	SunsetTimes      map[string]time.Time
+}
+
+func NewConfigVersioningHTTP() (ConfigVersioningHTTP, error) {
+	cfg, err := env.ParseAs[ConfigVersioningHTTP]()
+	if err != nil {
+		return ConfigVersioningHTTP{}, err
+	}
+
+	if cfg.DeprecationTimes, err = parseVersionTimes(cfg.DeprecatedVersions); err != nil {
+		return ConfigVersioningHTTP{}, err
+	} else if cfg.SunsetTimes, err = parseVersionTimes(cfg.SunsetVersions); err != nil {
+		return ConfigVersioningHTTP{}, err
+	}
 	return cfg, nil
 }
 
+func parseVersionTimes(rawTimes map[string]string) (map[string]time.Time, error) {
+	out := make(map[string]time.Time, len(rawTimes))
+	for version, rawTime := range rawTimes {
+		parsedTime, err := time.Parse(time.RFC3339, rawTime)
+		if err != nil {
+			return nil, err
+		}
+		out[version] = parsedTime
+	}
+	return out, nil
+}
+
 type ConfigActuatorHTTP struct {
 	ActuatorRoleAllowlist []string `env:"HTTP_SERVER_ACTUATOR_ROLE_ALLOWLIST"`
 }
@@ -35,3 +218,233 @@ func NewConfigActuatorHTTP() (ConfigActuatorHTTP, error) {
 	}
 	return cfg, nil
 }
+
+// ConfigResponseCacheHTTP configuration structure for ResponseCacheHTTP instances.
+type ConfigResponseCacheHTTP struct {
+	// TTL default time a cached response is considered fresh. Responses specifying Cache-Control max-age
+	// (or s-maxage) directives override this value.
+	TTL time.Duration `env:"HTTP_RESPONSE_CACHE_TTL" envDefault:"1m"`
+	// VaryByPrincipal appends the current security.Principal to cache keys. Required to cache private responses.
+	VaryByPrincipal bool `env:"HTTP_RESPONSE_CACHE_VARY_BY_PRINCIPAL" envDefault:"true"`
+	// MaxBodySize maximum size (in bytes) of a response body to be cached.
+	MaxBodySize int `env:"HTTP_RESPONSE_CACHE_MAX_BODY_SIZE" envDefault:"1048576"`
+}
+
+// ConfigSSE configuration structure for StreamerSSE instances.
+type ConfigSSE struct {
+	// HeartbeatInterval interval between heartbeat comments sent to idle clients. Zero disables heartbeats.
+	HeartbeatInterval time.Duration `env:"HTTP_SSE_HEARTBEAT_INTERVAL" envDefault:"15s"`
+	// BufferSize number of produced events buffered before producers get blocked.
+	BufferSize int `env:"HTTP_SSE_BUFFER_SIZE" envDefault:"16"`
+	// WriteTimeout maximum duration of a single event write. Slow clients are disconnected once exceeded.
+	// Zero means no timeout.
+	WriteTimeout time.Duration `env:"HTTP_SSE_WRITE_TIMEOUT" envDefault:"10s"`
+	// Retry reconnection time hint sent to clients when streams are opened. Zero skips the hint.
+	Retry time.Duration `env:"HTTP_SSE_RETRY" envDefault:"0s"`
+}
+
+// ConfigWebSocket configuration structure for ServerWebSocket instances.
+type ConfigWebSocket struct {
+	// PingInterval interval between keepalive pings. Zero disables keepalive.
+	PingInterval time.Duration `env:"HTTP_WS_PING_INTERVAL" envDefault:"30s"`
+	// PongTimeout time to wait for client traffic (e.g. pongs) after a ping before closing the connection.
+	PongTimeout time.Duration `env:"HTTP_WS_PONG_TIMEOUT" envDefault:"10s"`
+	// WriteTimeout maximum duration of a single message write. Zero means no timeout.
+	WriteTimeout time.Duration `env:"HTTP_WS_WRITE_TIMEOUT" envDefault:"10s"`
+	// MaxMessageSize maximum size (in bytes) of received messages.
+	MaxMessageSize int `env:"HTTP_WS_MAX_MESSAGE_SIZE" envDefault:"1048576"`
+	// SendBufferSize number of outgoing messages queued per connection. Connections exceeding it while
+	// broadcasting are closed.
+	SendBufferSize int `env:"HTTP_WS_SEND_BUFFER_SIZE" envDefault:"32"`
+	// AllowedOrigins origins (e.g. https://app.example.com) allowed to open connections from browsers. Every
+	// origin is allowed if empty or *.
+	AllowedOrigins []string `env:"HTTP_WS_ALLOWED_ORIGINS"`
+}
+
+// ConfigRateLimitHTTP configuration structure for rate limiting middlewares.
+type ConfigRateLimitHTTP struct {
+	// DefaultLimit limit applied to routes with no specific limit, using ratelimit.ParseLimit nomenclature
+	// (e.g. 100/1m). Routes are not limited by default if empty.
+	DefaultLimit string `env:"HTTP_RATE_LIMIT_DEFAULT"`
+	// KeyStrategy how clients are identified (ip, principal, header:HEADER_NAME).
+	KeyStrategy string `env:"HTTP_RATE_LIMIT_KEY" envDefault:"ip"`
+	// Routes limits per route, using the nomenclature: ROUTE_PATTERN=LIMIT, where ROUTE_PATTERN follows
+	// RouteMatcher nomenclature (e.g. POST /v1/tasks=10/1m,/v1/tasks/:task_id=100/1m,GET /v1/catalog/**=500/1m).
+	Routes map[string]string `env:"HTTP_RATE_LIMIT_ROUTES" envKeyValSeparator:"="`
+
+	DefaultLimitValue *ratelimit.Limit
+	RouteLimits       *RouteMatcher[ratelimit.Limit]
+}
+
+func NewConfigRateLimitHTTP() (ConfigRateLimitHTTP, error) {
+	cfg, err := env.ParseAs[ConfigRateLimitHTTP]()
+	if err != nil {
+		return ConfigRateLimitHTTP{}, err
+	}
+
+	if cfg.DefaultLimit != "" {
+		limit, errParse := ratelimit.ParseLimit(cfg.DefaultLimit)
+		if errParse != nil {
+			return ConfigRateLimitHTTP{}, errParse
+		}
+		cfg.DefaultLimitValue = &limit
+	}
+	cfg.RouteLimits = NewRouteMatcher[ratelimit.Limit]()
+	for route, limitRaw := range cfg.Routes {
+		limit, errParse := ratelimit.ParseLimit(limitRaw)
+		if errParse != nil {
+			return ConfigRateLimitHTTP{}, errParse
+		}
+		if err = cfg.RouteLimits.Add(route, limit); err != nil {
+			return ConfigRateLimitHTTP{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// ConfigClientHTTP configuration structure for ClientHTTP instances.
+type ConfigClientHTTP struct {
+	// Timeout maximum duration of a single request attempt, including reading the response body.
+	// Zero means no timeout.
+	Timeout time.Duration `env:"HTTP_CLIENT_TIMEOUT" envDefault:"30s"`
+	// HostTimeouts timeouts per host, using the nomenclature: HOST=DURATION (e.g. reports.internal:8080=2m).
+	// Host-specific timeouts take precedence over Timeout.
+	HostTimeouts map[string]string `env:"HTTP_CLIENT_HOST_TIMEOUTS" envKeyValSeparator:"="`
+	// MaxRetries maximum number of retries of idempotent requests (safe methods, PUT, DELETE or requests
+	// holding an Idempotency-Key header) failing with transient errors.
+	MaxRetries int `env:"HTTP_CLIENT_MAX_RETRIES" envDefault:"2"`
+	// RetryBaseDelay base delay of exponential backoff between retries.
+	RetryBaseDelay time.Duration `env:"HTTP_CLIENT_RETRY_BASE_DELAY" envDefault:"100ms"`
+	// RetryMaxDelay maximum delay between retries.
+	RetryMaxDelay time.Duration `env:"HTTP_CLIENT_RETRY_MAX_DELAY" envDefault:"2s"`
+	// BreakerFailureThreshold consecutive failures opening a host circuit breaker. Zero disables circuit breakers.
+	BreakerFailureThreshold int `env:"HTTP_CLIENT_BREAKER_FAILURE_THRESHOLD" envDefault:"5"`
+	// BreakerOpenTimeout time an open circuit breaker waits before letting a probe request through.
+	BreakerOpenTimeout time.Duration `env:"HTTP_CLIENT_BREAKER_OPEN_TIMEOUT" envDefault:"30s"`
+	// RequestIDHeader header propagating request identifiers.
+	RequestIDHeader string `env:"HTTP_CLIENT_REQ_ID_HEADER" envDefault:"X-Request-ID"`
+	// ForwardToken forwards bearer tokens of callers to ForwardTokenHosts if no TokenSourceHTTP was set.
+	ForwardToken bool `env:"HTTP_CLIENT_FORWARD_TOKEN" envDefault:"false"`
+	// ForwardTokenHosts hosts (HOST or HOST:PORT) receiving bearer tokens of callers (e.g. tasks.internal,
+	// reports.internal:8080). Required if ForwardToken is set, tokens are never forwarded to other hosts.
+	ForwardTokenHosts []string `env:"HTTP_CLIENT_FORWARD_TOKEN_HOSTS"`
+
+	HostTimeoutValues map[string]time.Duration
+}
+
+func NewConfigClientHTTP() (ConfigClientHTTP, error) {
+	cfg, err := env.ParseAs[ConfigClientHTTP]()
+	if err != nil {
+		return ConfigClientHTTP{}, err
+	}
+
+	cfg.HostTimeoutValues = make(map[string]time.Duration, len(cfg.HostTimeouts))
+	for host, timeoutRaw := range cfg.HostTimeouts {
+		timeout, errParse := time.ParseDuration(timeoutRaw)
+		if errParse != nil {
+			return ConfigClientHTTP{}, errParse
+		}
+		cfg.HostTimeoutValues[host] = timeout
+	}
+	if cfg.ForwardToken && len(cfg.ForwardTokenHosts) == 0 {
+		return ConfigClientHTTP{}, errors.New("transport: forwarding tokens requires HTTP_CLIENT_FORWARD_TOKEN_HOSTS")
+	}
+	return cfg, nil
+}
+
+// ConfigGRPC configuration structure for gRPC servers.
+type ConfigGRPC struct {
+	Address string `env:"GRPC_SERVER_ADDRESS" envDefault:":9090"`
+	// AuthenticationWhitelist full method name patterns skipping authentication, using RouteMatcher nomenclature
+	// without method qualifiers (e.g. /grpc.health.v1.Health/Check, /grpc.health.v1.Health/*).
+	AuthenticationWhitelist []string `env:"GRPC_SERVER_AUTHN_WHITELIST" envDefault:"/grpc.health.v1.Health/Check,/grpc.health.v1.Health/Watch"`
+	// RequestIDMetadataKey metadata key holding request identifiers. Echoed in response headers.
+	RequestIDMetadataKey string `env:"GRPC_REQ_ID_METADATA_KEY" envDefault:"x-request-id"`
+	// HealthWatchInterval interval used by health Watch streams to poll actuator.Manager.
+	HealthWatchInterval time.Duration `env:"GRPC_HEALTH_WATCH_INTERVAL" envDefault:"5s"`
+
+	AuthenticationWhitelistMatcher *RouteMatcher[struct{}]
+}
+
+func NewConfigGRPC() (ConfigGRPC, error) {
+	cfg, err := env.ParseAs[ConfigGRPC]()
+	if err != nil {
+		return ConfigGRPC{}, err
+	}
+
+	cfg.AuthenticationWhitelistMatcher, err = NewRouteMatcherSet(cfg.AuthenticationWhitelist...)
+	if err != nil {
+		return ConfigGRPC{}, err
+	}
+	return cfg, nil
+}
+
+// ConfigOpenAPI configuration structure for OpenAPI document generation.
+type ConfigOpenAPI struct {
+	// Route path serving the OpenAPI document. Add it to HTTP_SERVER_AUTHN_WHITELIST to make it public.
+	Route string `env:"OPENAPI_ROUTE" envDefault:"/openapi.json"`
+	// Title document title. Defaults to application.Config.ApplicationName.
+	Title       string   `env:"OPENAPI_TITLE"`
+	Description string   `env:"OPENAPI_DESCRIPTION"`
+	ServerURLs  []string `env:"OPENAPI_SERVER_URLS"`
+}
+
+// ConfigIdempotencyHTTP configuration structure for idempotency middlewares.
+type ConfigIdempotencyHTTP struct {
+	// TTL time a completed response is replayed for the same idempotency key.
+	TTL time.Duration `env:"HTTP_IDEMPOTENCY_TTL" envDefault:"24h"`
+	// InFlightTTL time an in-flight request holds its idempotency key. Releases keys of crashed requests.
+	InFlightTTL time.Duration `env:"HTTP_IDEMPOTENCY_IN_FLIGHT_TTL" envDefault:"1m"`
+	// Methods HTTP methods honouring the Idempotency-Key header.
+	Methods []string `env:"HTTP_IDEMPOTENCY_METHODS" envDefault:"POST,PATCH"`
+	// KeyRequired rejects requests using Methods without an Idempotency-Key header.
+	KeyRequired  bool `env:"HTTP_IDEMPOTENCY_KEY_REQUIRED" envDefault:"false"`
+	MaxKeyLength int  `env:"HTTP_IDEMPOTENCY_MAX_KEY_LENGTH" envDefault:"255"`
+	// TableName table used by IdempotencyStorePostgres.
+	TableName string `env:"HTTP_IDEMPOTENCY_TABLE" envDefault:"idempotency_keys"`
+	// MaxBodySize maximum request body size (e.g. 512K, 4M) buffered to fingerprint requests. Request bodies are
+	// not limited if empty.
+	MaxBodySize string `env:"HTTP_IDEMPOTENCY_MAX_BODY_SIZE" envDefault:"4M"`
+
+	MaxBodySizeBytes int64
+}
+
+func NewConfigIdempotencyHTTP() (ConfigIdempotencyHTTP, error) {
+	cfg, err := env.ParseAs[ConfigIdempotencyHTTP]()
+	if err != nil {
+		return ConfigIdempotencyHTTP{}, err
+	}
+
+	if cfg.MaxBodySize != "" {
+		if cfg.MaxBodySizeBytes, err = bytes.Parse(cfg.MaxBodySize); err != nil {
+			return ConfigIdempotencyHTTP{}, fmt.Errorf("transport: invalid idempotency body size %q: %w",
+				cfg.MaxBodySize, err)
+		}
+	}
+	return cfg, nil
+}
+
+// ConfigJSONSchemaHTTP configuration structure for JSON Schema validation of HTTP routes (see JSONSchemaHTTP).
+type ConfigJSONSchemaHTTP struct {
+	// ValidateResponses validates response bodies of routes with response schemas. Responses are never validated in
+	// production environments (see application.Config).
+	ValidateResponses bool `env:"HTTP_JSON_SCHEMA_VALIDATE_RESPONSES" envDefault:"true"`
+}
+
+// ConfigUploadHTTP configuration structure for multipart uploads (see UploaderHTTP).
+type ConfigUploadHTTP struct {
+	// MaxFileSize maximum size of a single file, in bytes.
+	MaxFileSize int64 `env:"HTTP_UPLOAD_MAX_FILE_SIZE" envDefault:"10485760"`
+	// MaxRequestSize maximum size of an upload request body (every part included), in bytes.
+	MaxRequestSize int64 `env:"HTTP_UPLOAD_MAX_REQUEST_SIZE" envDefault:"52428800"`
+	// MaxFiles maximum number of files per request.
+	MaxFiles int `env:"HTTP_UPLOAD_MAX_FILES" envDefault:"10"`
+	// MaxFieldSize maximum size of a single non-file form field, in bytes.
+	MaxFieldSize int64 `env:"HTTP_UPLOAD_MAX_FIELD_SIZE" envDefault:"65536"`
+	// AllowedContentTypes media types files are accepted for, checked against the type detected from file content
+	// rather than the declared one. Wildcard subtypes are supported (e.g. image/*). Every media type is accepted if
+	// empty.
+	AllowedContentTypes []string `env:"HTTP_UPLOAD_ALLOWED_CONTENT_TYPES"`
+	// KeyPrefix prefix of the storage.Bucket keys uploaded files are stored under.
+	KeyPrefix string `env:"HTTP_UPLOAD_KEY_PREFIX" envDefault:"uploads/"`
+}
@@ -0,0 +1,20 @@
+package transport
+
+import "context"
+
+type RequestIDContextType string
+
+// RequestIDContextKey context key holding the identifier of the current request.
+const RequestIDContextKey RequestIDContextType = "geck.transport.request_id"
+
+// NewContextWithRequestID appends a request identifier to the given context.Context.
+func NewContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, RequestIDContextKey, requestID)
+}
+
+// GetRequestIDFromContext retrieves the request identifier from the given context.Context. Returns an empty
+// string if not found.
+func GetRequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDContextKey).(string)
+	return requestID
+}
@@ -0,0 +1,145 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// maxErrorBodySize maximum size of remote error bodies decoded by DecodeErrorResponseHTTP.
+const maxErrorBodySize = 1 << 20
+
+// httpStatusCodeMap is the inverse of statusCodeHTTPMap. HTTP status codes shared by more than one
+// systemerror.Status map to the most general one.
+var httpStatusCodeMap = map[int]systemerror.Status{
+	http.StatusBadRequest:          systemerror.StatusInvalidArgument,
+	http.StatusUnauthorized:        systemerror.StatusUnauthenticated,
+	http.StatusForbidden:           systemerror.StatusPermissionDenied,
+	http.StatusNotFound:            systemerror.StatusNotFound,
+	http.StatusNotAcceptable:       systemerror.StatusDomain,
+	http.StatusConflict:            systemerror.StatusAborted,
+	http.StatusPreconditionFailed:  systemerror.StatusFailedPrecondition,
+	http.StatusUnprocessableEntity: systemerror.StatusUnprocessableContent,
+	http.StatusTooManyRequests:     systemerror.StatusResourceExhausted,
+	499:                            systemerror.StatusCancelled,
+	http.StatusInternalServerError: systemerror.StatusInternal,
+	http.StatusNotImplemented:      systemerror.StatusNotImplemented,
+	http.StatusBadGateway:          systemerror.StatusUnavailable,
+	http.StatusServiceUnavailable:  systemerror.StatusUnavailable,
+	http.StatusGatewayTimeout:      systemerror.StatusDeadlineExceeded,
+}
+
+// staticErrorMap static errors of every systemerror.Status, letting errors.Is checks work across services.
+var staticErrorMap = map[systemerror.Status]error{
+	systemerror.StatusInvalidArgument:      systemerror.ErrInvalidArgument,
+	systemerror.StatusFailedPrecondition:   systemerror.ErrFailedPrecondition,
+	systemerror.StatusOutOfRange:           systemerror.ErrOutOfRange,
+	systemerror.StatusUnauthenticated:      systemerror.ErrUnauthenticated,
+	systemerror.StatusPermissionDenied:     systemerror.ErrPermissionDenied,
+	systemerror.StatusNotFound:             systemerror.ErrNotFound,
+	systemerror.StatusAborted:              systemerror.ErrAborted,
+	systemerror.StatusAlreadyExists:        systemerror.ErrAlreadyExists,
+	systemerror.StatusResourceExhausted:    systemerror.ErrResourceExhausted,
+	systemerror.StatusUnavailable:          systemerror.ErrUnavailable,
+	systemerror.StatusDeadlineExceeded:     systemerror.ErrDeadlineExceeded,
+	systemerror.StatusDomain:               systemerror.ErrDomain,
+	systemerror.StatusUnprocessableContent: systemerror.ErrUnprocessableContent,
+}
+
+func newStatusFromHTTPCode(code int) systemerror.Status {
+	if status, ok := httpStatusCodeMap[code]; ok {
+		return status
+	} else if code >= http.StatusInternalServerError {
+		return systemerror.StatusInternal
+	}
+	return systemerror.StatusUnknown
+}
+
+func newRemoteSystemError(status systemerror.Status, reason, message string,
+	metadata map[string]string) systemerror.SystemError {
+	return systemerror.SystemError{
+		ErrStatus:   status,
+		ErrReason:   reason,
+		ErrMessage:  message,
+		ErrMetadata: metadata,
+		StaticError: staticErrorMap[status],
+	}
+}
+
+// DecodeErrorResponseHTTP decodes a failed response (status code >= 400) into systemerror.Error values, so errors
+// keep their status across services. Errors and problem details (RFC 9457) bodies are supported, any other body is
+// decoded from the response status code. Multiple errors are joined (see systemerror.Container).
+//
+// Closes the response body.
+func DecodeErrorResponseHTTP(res *http.Response) error {
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil {
+		return err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case MIMEApplicationProblemJSON:
+		var problem Problem
+		if err = json.Unmarshal(body, &problem); err == nil {
+			return newErrorFromProblem(problem, res.StatusCode)
+		}
+	case echo.MIMEApplicationJSON:
+		var errs Errors
+		if err = json.Unmarshal(body, &errs); err == nil && len(errs.Errors) > 0 {
+			return newErrorFromErrors(errs)
+		}
+	}
+	reason := strings.ToUpper(strings.ReplaceAll(http.StatusText(res.StatusCode), " ", "_"))
+	return newRemoteSystemError(newStatusFromHTTPCode(res.StatusCode), reason, http.StatusText(res.StatusCode), nil)
+}
+
+func newErrorFromErrors(errs Errors) error {
+	out := make([]error, 0, len(errs.Errors))
+	for _, errRemote := range errs.Errors {
+		status := systemerror.ParseStatus(errRemote.Status)
+		var (
+			reason   string
+			metadata map[string]string
+		)
+		if len(errRemote.Details) > 0 {
+			reason = errRemote.Details[0].Reason
+			metadata = errRemote.Details[0].Metadata
+		}
+		out = append(out, newRemoteSystemError(status, reason, errRemote.Message, metadata))
+	}
+	if len(out) == 1 {
+		return out[0]
+	}
+	return errors.Join(out...)
+}
+
+func newErrorFromProblem(problem Problem, code int) error {
+	if len(problem.Errors) > 0 {
+		out := make([]error, 0, len(problem.Errors))
+		for _, item := range problem.Errors {
+			out = append(out, newErrorFromProblem(item, code))
+		}
+		if len(out) == 1 {
+			return out[0]
+		}
+		return errors.Join(out...)
+	}
+
+	if problem.Status != 0 {
+		code = problem.Status
+	}
+	message := problem.Detail
+	if message == "" {
+		message = problem.Title
+	}
+	return newRemoteSystemError(newStatusFromHTTPCode(code), problem.Reason, message, problem.Metadata)
+}
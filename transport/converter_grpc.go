@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"github.com/hadroncorp/geck/internal/reflection"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// convertErrorGRPC converts err into a gRPC status error. systemerror.Error(s) are mapped using statusCodeGRPCMap
+// and their details are attached as errdetails.ErrorInfo (keeping the same shape as ErrorDetail).
+//
+// Errors already carrying a gRPC status are returned as-is.
+func convertErrorGRPC(err error) error {
+	if err == nil {
+		return nil
+	} else if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var srcErrs []error
+	var containerErr systemerror.Container
+	if errors.As(err, &containerErr) {
+		srcErrs = containerErr.Unwrap()
+	} else {
+		srcErrs = []error{err}
+	}
+
+	code := codes.OK
+	httpCode := 0
+	messages := make([]string, 0, len(srcErrs))
+	details := make([]protoadapt.MessageV1, 0, len(srcErrs))
+	for _, srcErr := range srcErrs {
+		var sysErr systemerror.Error
+		if !errors.As(srcErr, &sysErr) {
+			// same precedence as HTTP errors, the most severe error sets the status code
+			if httpCode < statusCodeHTTPMap[systemerror.StatusInternal] {
+				code, httpCode = codes.Internal, statusCodeHTTPMap[systemerror.StatusInternal]
+			}
+			messages = append(messages, codes.Internal.String())
+			continue
+		}
+
+		if httpCode < statusCodeHTTPMap[sysErr.Status()] {
+			code, httpCode = statusCodeGRPCMap[sysErr.Status()], statusCodeHTTPMap[sysErr.Status()]
+		}
+		messages = append(messages, sysErr.LocalizedMessage())
+		details = append(details, protoadapt.MessageV1Of(&errdetails.ErrorInfo{
+			Reason:   sysErr.Reason(),
+			Domain:   reflection.NewTypeFullNameAny(sysErr),
+			Metadata: sysErr.Metadata(),
+		}))
+	}
+
+	st := status.New(code, strings.Join(messages, "; "))
+	if len(details) == 0 {
+		return st.Err()
+	}
+	stWithDetails, errDetails := st.WithDetails(details...)
+	if errDetails != nil {
+		return st.Err()
+	}
+	return stWithDetails.Err()
+}
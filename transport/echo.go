@@ -11,6 +11,7 @@ import (
 
 	"github.com/hadroncorp/geck/application"
 	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/shutdown"
 )
 
 type NewEchoParams struct {
@@ -19,12 +20,20 @@ type NewEchoParams struct {
 	Lifecycle fx.Lifecycle
 	Config    ConfigHTTP
 	Logger    logging.Logger
+	// Coordinator drains the server during shutdown.PhaseServers. The server is shut down once the application
+	// stops if not provided.
+	Coordinator *shutdown.Coordinator `optional:"true"`
 }
 
 func NewEcho(params NewEchoParams) *echo.Echo {
 	e := echo.New()
-	e.HTTPErrorHandler = HandleEchoError
-	params.Lifecycle.Append(fx.Hook{
+	e.HTTPErrorHandler = NewErrorHandlerEcho(params.Config)
+	e.IPExtractor = NewIPExtractorEcho(params.Config)
+	e.Server.ReadTimeout = params.Config.ReadTimeout
+	e.Server.ReadHeaderTimeout = params.Config.ReadHeaderTimeout
+	e.Server.WriteTimeout = params.Config.WriteTimeout
+	e.Server.IdleTimeout = params.Config.IdleTimeout
+	shutdown.Append(params.Lifecycle, params.Coordinator, shutdown.PhaseServers, "http_server", fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			go func() {
 				if err := e.Start(params.Config.Address); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -35,12 +44,34 @@ func NewEcho(params NewEchoParams) *echo.Echo {
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			return e.Shutdown(ctx)
+			if err := e.Shutdown(ctx); err != nil {
+				// in-flight requests did not finish on time, closing remaining connections
+				return errors.Join(err, e.Close())
+			}
+			return nil
 		},
 	})
 	return e
 }
 
+// NewIPExtractorEcho allocates an echo.IPExtractor honouring X-Forwarded-For headers only if forwarded by
+// ConfigHTTP.TrustedProxyRanges. Otherwise, client IP addresses are taken from connections, so clients cannot
+// spoof them (e.g. to evade rate limits).
+func NewIPExtractorEcho(cfg ConfigHTTP) echo.IPExtractor {
+	if len(cfg.TrustedProxyRanges) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, ipRange := range cfg.TrustedProxyRanges {
+		opts = append(opts, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
+
 type RegisterMiddlewaresEchoParams struct {
 	fx.In
 
@@ -48,6 +79,9 @@ type RegisterMiddlewaresEchoParams struct {
 	Logger           logging.Logger
 	GroupMiddlewares [][]echo.MiddlewareFunc `group:"middlewares_groups_http"`
 	Middlewares      []echo.MiddlewareFunc   `group:"middlewares_http"`
+	// AuthenticatedMiddlewares middlewares registered after Middlewares (e.g. authenticators), so they are able
+	// to rely on the security.Principal of the request.
+	AuthenticatedMiddlewares []echo.MiddlewareFunc `group:"authenticated_middlewares_http"`
 }
 
 func RegisterMiddlewaresEcho(params RegisterMiddlewaresEchoParams) {
@@ -61,20 +95,24 @@ func RegisterMiddlewaresEcho(params RegisterMiddlewaresEchoParams) {
 		}
 	}
 	params.Echo.Use(params.Middlewares...)
-	params.Logger.Info().WithField("total_middlewares", groupCount+len(params.Middlewares)).Write("registered http middlewares")
+	params.Echo.Use(params.AuthenticatedMiddlewares...)
+	total := groupCount + len(params.Middlewares) + len(params.AuthenticatedMiddlewares)
+	params.Logger.Info().WithField("total_middlewares", total).Write("registered http middlewares")
 }
 
 type RegisterControllersEchoParams struct {
 	fx.In
 
-	Echo                 *echo.Echo
-	Config               application.Config
-	Logger               logging.Logger
-	RootControllers      []ControllerHTTP          `group:"root_controllers_http"`
-	VersionedControllers []VersionedControllerHTTP `group:"versioned_controllers_http"`
+	Echo                    *echo.Echo
+	Config                  application.Config
+	Logger                  logging.Logger
+	VersionRouter           *APIVersionRouterHTTP        `optional:"true"`
+	RootControllers         []ControllerHTTP             `group:"root_controllers_http"`
+	VersionedControllers    []VersionedControllerHTTP    `group:"versioned_controllers_http"`
+	MultiVersionControllers []MultiVersionControllerHTTP `group:"multiversion_controllers_http"`
 }
 
-func RegisterControllersEcho(params RegisterControllersEchoParams) {
+func RegisterControllersEcho(params RegisterControllersEchoParams) error {
 	params.Logger.Info().
 		WithField("total_controllers", len(params.RootControllers)).
 		Write("registering http root controllers")
@@ -91,4 +129,33 @@ func RegisterControllersEcho(params RegisterControllersEchoParams) {
 		controller.SetRoutes(params.Echo)
 		controller.SetVersionedRoutes(g)
 	}
+
+	versions := []string{params.Config.Semver.Major}
+	versionGroups := make(map[string]*echo.Group)
+	params.Logger.Info().
+		WithField("total_controllers", len(params.MultiVersionControllers)).
+		Write("registering http multi-version controllers")
+	for _, controller := range params.MultiVersionControllers {
+		controller.SetRoutes(params.Echo)
+		for _, version := range controller.APIVersions() {
+			versionGroup, ok := versionGroups[version]
+			if !ok {
+				versionGroup = params.Echo.Group("/" + version)
+				versionGroups[version] = versionGroup
+				versions = append(versions, version)
+			}
+			controller.SetVersionRoutes(version, versionGroup)
+		}
+	}
+	if params.VersionRouter == nil {
+		return nil
+	}
+	if err := params.VersionRouter.RegisterRoutes(params.Echo, versions); err != nil {
+		return err
+	}
+	params.Echo.Pre(params.VersionRouter.Pre())
+	params.Logger.Info().
+		WithField("versions", params.VersionRouter.Versions()).
+		Write("registered http api version router")
+	return nil
 }
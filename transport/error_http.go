@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// MIMEApplicationProblemJSON RFC 9457 problem details media type.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ErrorFormatHTTP how HTTP error responses are rendered.
+type ErrorFormatHTTP string
+
+const (
+	// ErrorFormatErrors renders errors using the Errors structure.
+	ErrorFormatErrors ErrorFormatHTTP = "errors"
+	// ErrorFormatProblem renders errors using RFC 9457 problem details (Problem structure).
+	ErrorFormatProblem ErrorFormatHTTP = "problem"
+	// ErrorFormatNegotiate renders errors using RFC 9457 problem details only if clients prefer
+	// application/problem+json over application/json (Accept header). Uses ErrorFormatErrors otherwise.
+	ErrorFormatNegotiate ErrorFormatHTTP = "negotiate"
+)
+
+// ErrUnknownErrorFormat the given ErrorFormatHTTP is not supported.
+var ErrUnknownErrorFormat = errors.New("transport: unknown error format")
+
+func (f ErrorFormatHTTP) validate() error {
+	switch f {
+	case ErrorFormatErrors, ErrorFormatProblem, ErrorFormatNegotiate:
+		return nil
+	default:
+		return ErrUnknownErrorFormat
+	}
+}
+
+// NewErrorHandlerEcho allocates an echo.HTTPErrorHandler rendering errors with ConfigHTTP.ErrorFormat.
+func NewErrorHandlerEcho(cfg ConfigHTTP) echo.HTTPErrorHandler {
+	switch cfg.ErrorFormat {
+	case ErrorFormatProblem:
+		return func(srcErr error, c echo.Context) {
+			if c.Response().Committed {
+				return
+			}
+			_ = renderProblemEcho(c, cfg.ProblemTypeBaseURI, srcErr)
+		}
+	case ErrorFormatNegotiate:
+		return func(srcErr error, c echo.Context) {
+			if c.Response().Committed {
+				return
+			}
+			mediaType := negotiateMediaType(c.Request().Header.Get(echo.HeaderAccept),
+				echo.MIMEApplicationJSON, MIMEApplicationProblemJSON)
+			if mediaType == MIMEApplicationProblemJSON {
+				_ = renderProblemEcho(c, cfg.ProblemTypeBaseURI, srcErr)
+				return
+			}
+			HandleEchoError(srcErr, c)
+		}
+	default:
+		return HandleEchoError
+	}
+}
+
+func renderProblemEcho(c echo.Context, typeBaseURI string, srcErr error) error {
+	problem := newProblem(convertContainerErrorsEcho(srcErr), typeBaseURI, c.Request().URL.Path)
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(problem.Status, problem)
+}
+
+// newProblem converts errs into a Problem. If errs holds more than one error, every error is set as an extension
+// member (Problem.Errors) while the top-level problem uses the generic about:blank type.
+func newProblem(errs Errors, typeBaseURI, instance string) Problem {
+	if len(errs.Errors) == 1 {
+		problem := newProblemFromError(errs.Errors[0], typeBaseURI)
+		problem.Instance = instance
+		return problem
+	}
+
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(errs.Code),
+		Status:   errs.Code,
+		Detail:   "multiple errors occurred",
+		Instance: instance,
+		Errors:   make([]Problem, 0, len(errs.Errors)),
+	}
+	for _, err := range errs.Errors {
+		problem.Errors = append(problem.Errors, newProblemFromError(err, typeBaseURI))
+	}
+	return problem
+}
+
+func newProblemFromError(err Error, typeBaseURI string) Problem {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(err.Code),
+		Status: err.Code,
+		Detail: err.Message,
+	}
+	if len(err.Details) == 0 || err.Details[0].Reason == "" {
+		return problem
+	}
+
+	reason := err.Details[0].Reason
+	problem.Type = newProblemType(typeBaseURI, reason)
+	problem.Title = newProblemTitle(reason)
+	problem.Reason = reason
+	problem.Metadata = err.Details[0].Metadata
+	return problem
+}
+
+// newProblemType derives a problem type URI from an error reason (e.g. RESOURCE_NOT_FOUND -> resource-not-found).
+func newProblemType(baseURI, reason string) string {
+	if baseURI == "" {
+		return "about:blank"
+	}
+	return baseURI + strings.ToLower(strings.ReplaceAll(reason, "_", "-"))
+}
+
+// newProblemTitle derives a human-readable title from an error reason
+// (e.g. RESOURCE_NOT_FOUND -> Resource Not Found).
+func newProblemTitle(reason string) string {
+	return cases.Title(language.English).String(strings.ReplaceAll(reason, "_", " "))
+}
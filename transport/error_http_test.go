@@ -0,0 +1,99 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestNewErrorHandlerEcho(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          transport.ErrorFormatHTTP
+		accept          string
+		err             error
+		wantContentType string
+		wantCode        int
+		wantProblem     *transport.Problem
+	}{
+		{
+			name:            "errors",
+			format:          transport.ErrorFormatErrors,
+			accept:          transport.MIMEApplicationProblemJSON,
+			err:             systemerror.NewResourceNotFound[string]("123"),
+			wantContentType: echo.MIMEApplicationJSON,
+			wantCode:        http.StatusNotFound,
+		},
+		{
+			name:            "problem",
+			format:          transport.ErrorFormatProblem,
+			err:             systemerror.NewResourceNotFound[string]("123"),
+			wantContentType: transport.MIMEApplicationProblemJSON,
+			wantCode:        http.StatusNotFound,
+			wantProblem: &transport.Problem{
+				Type:     "urn:problem-type:resource-not-found",
+				Title:    "Resource Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   "resource 'string' not found",
+				Instance: "/tasks/123",
+				Reason:   "RESOURCE_NOT_FOUND",
+				Metadata: map[string]string{
+					"resource_key": "123",
+				},
+			},
+		},
+		{
+			name:            "negotiate json",
+			format:          transport.ErrorFormatNegotiate,
+			accept:          "application/json, application/problem+json;q=0.5",
+			err:             systemerror.NewResourceNotFound[string]("123"),
+			wantContentType: echo.MIMEApplicationJSON,
+			wantCode:        http.StatusNotFound,
+		},
+		{
+			name:            "negotiate problem",
+			format:          transport.ErrorFormatNegotiate,
+			accept:          "application/problem+json, application/json;q=0.9",
+			err:             errors.Join(systemerror.NewMissingArgument("name"), systemerror.NewUnauthenticated()),
+			wantContentType: transport.MIMEApplicationProblemJSON,
+			wantCode:        http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/tasks/123", nil)
+			req.Header.Set(echo.HeaderAccept, tt.accept)
+			rec := httptest.NewRecorder()
+			handler := transport.NewErrorHandlerEcho(transport.ConfigHTTP{
+				ErrorFormat:        tt.format,
+				ProblemTypeBaseURI: "urn:problem-type:",
+			})
+			handler(tt.err, e.NewContext(req, rec))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Contains(t, rec.Header().Get(echo.HeaderContentType), tt.wantContentType)
+			if tt.wantContentType != transport.MIMEApplicationProblemJSON {
+				return
+			}
+			problem := transport.Problem{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			if tt.wantProblem != nil {
+				assert.Equal(t, *tt.wantProblem, problem)
+				return
+			}
+			assert.Equal(t, "about:blank", problem.Type)
+			assert.Equal(t, tt.wantCode, problem.Status)
+			assert.Len(t, problem.Errors, 2)
+		})
+	}
+}
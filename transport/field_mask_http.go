@@ -0,0 +1,121 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hadroncorp/geck/data"
+)
+
+// FieldMaskQueryParamsDefault query parameters read by NewHandlerEcho to build a data.FieldMask.
+var FieldMaskQueryParamsDefault = []string{"fields", "read_mask"}
+
+// ParseFieldMaskEcho parses a data.FieldMask from the given query parameters. Every occurrence of every parameter
+// is merged (e.g. ?fields=name&fields=status is equivalent to ?fields=name,status).
+func ParseFieldMaskEcho(c echo.Context, params ...string) (data.FieldMask, error) {
+	query := c.QueryParams()
+	raw := make([]string, 0, len(params))
+	for _, param := range params {
+		raw = append(raw, query[param]...)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return data.ParseFieldMask(strings.Join(raw, ","))
+}
+
+// ValidateFieldMaskResponse verifies every path of mask exists in the JSON representation of Res. If Res is a
+// data.Page, paths are resolved against its item type.
+func ValidateFieldMaskResponse[Res any](mask data.FieldMask) error {
+	if mask.IsEmpty() {
+		return nil
+	}
+	typ := reflect.TypeFor[Res]()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Implements(reflect.TypeFor[data.PageMetadata]()) {
+		if items, ok := typ.FieldByName("Items"); ok {
+			typ = items.Type.Elem()
+		}
+	}
+	if typ.Kind() == reflect.Interface {
+		return nil
+	}
+	return mask.Validate(reflect.New(typ).Elem().Interface())
+}
+
+// ApplyFieldMask prunes the JSON representation of v to the paths of mask. Repeated fields are pruned item by
+// item. If v is a data.Page, the mask is applied to each item while pagination metadata is kept.
+//
+// Returns v as-is if mask is empty.
+func ApplyFieldMask(v any, mask data.FieldMask) (any, error) {
+	if mask.IsEmpty() || isNilPointer(v) {
+		return v, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var tree any
+	if err = decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	root := newFieldMaskNode(mask)
+	if _, ok := v.(data.PageMetadata); ok {
+		if page, isObject := tree.(map[string]any); isObject {
+			page["items"] = pruneFieldMask(page["items"], root)
+			return page, nil
+		}
+	}
+	return pruneFieldMask(tree, root), nil
+}
+
+// fieldMaskNode a node of a data.FieldMask path tree. A node without children selects the whole value.
+type fieldMaskNode map[string]fieldMaskNode
+
+func newFieldMaskNode(mask data.FieldMask) fieldMaskNode {
+	root := make(fieldMaskNode)
+	for _, path := range data.NewFieldMask(mask...) {
+		node := root
+		for _, segment := range strings.Split(path, ".") {
+			child, ok := node[segment]
+			if !ok {
+				child = make(fieldMaskNode)
+				node[segment] = child
+			}
+			node = child
+		}
+	}
+	return root
+}
+
+func pruneFieldMask(value any, node fieldMaskNode) any {
+	if len(node) == 0 {
+		return value
+	}
+	switch typed := value.(type) {
+	case map[string]any:
+		pruned := make(map[string]any, len(node))
+		for key, child := range node {
+			if field, ok := typed[key]; ok {
+				pruned[key] = pruneFieldMask(field, child)
+			}
+		}
+		return pruned
+	case []any:
+		for i, item := range typed {
+			typed[i] = pruneFieldMask(item, node)
+		}
+		return typed
+	default:
+		return value
+	}
+}
@@ -0,0 +1,186 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+)
+
+type fieldMaskLabel struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type fieldMaskView struct {
+	TaskID string           `json:"task_id"`
+	Name   string           `json:"name"`
+	Labels []fieldMaskLabel `json:"labels"`
+	persistence.AuditableView
+}
+
+func newFieldMaskView(id string) fieldMaskView {
+	return fieldMaskView{
+		TaskID: id,
+		Name:   "task " + id,
+		Labels: []fieldMaskLabel{{Key: "env", Value: "prod"}, {Key: "team", Value: "core"}},
+		AuditableView: persistence.AuditableView{
+			CreateTime: "2024-01-01T00:00:00Z",
+			Version:    2,
+		},
+	}
+}
+
+func TestNewHandlerEcho_FieldMask(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		wantMask data.FieldMask
+		wantBody string
+		wantErr  string
+	}{
+		{
+			name:     "no mask",
+			target:   "/tasks/1",
+			wantBody: `{"data":{"task_id":"1","name":"task 1","labels":[{"key":"env","value":"prod"},{"key":"team","value":"core"}],"create_time":"2024-01-01T00:00:00Z","create_time_millis":0,"create_by":null,"last_update_time":"","last_update_time_millis":0,"last_update_by":null,"is_active":false,"version":2}}`,
+		},
+		{
+			name:     "top level and embedded",
+			target:   "/tasks/1?fields=name,version",
+			wantMask: data.FieldMask{"name", "version"},
+			wantBody: `{"data":{"name":"task 1","version":2}}`,
+		},
+		{
+			name:     "repeated nested",
+			target:   "/tasks/1?read_mask=labels.key&fields=task_id",
+			wantMask: data.FieldMask{"labels.key", "task_id"},
+			wantBody: `{"data":{"task_id":"1","labels":[{"key":"env"},{"key":"team"}]}}`,
+		},
+		{
+			name:    "unknown path",
+			target:  "/tasks/1?fields=name,owner",
+			wantErr: "NOT_ONE_OF",
+		},
+		{
+			name:    "unknown nested path",
+			target:  "/tasks/1?fields=labels.color",
+			wantErr: "NOT_ONE_OF",
+		},
+		{
+			name:    "malformed path",
+			target:  "/tasks/1?fields=labels..key",
+			wantErr: "INVALID_FORMAT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMask data.FieldMask
+			handler := transport.NewHandlerEcho(func(ctx context.Context, _ struct{}) (fieldMaskView, error) {
+				gotMask = data.GetFieldMaskFromContext(ctx)
+				return newFieldMaskView("1"), nil
+			}, transport.ConfigHandlerHTTP{FieldMask: true})
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			rec := httptest.NewRecorder()
+			err := handler(e.NewContext(req, rec))
+			if tt.wantErr != "" {
+				var sysErr systemerror.Error
+				require.True(t, errors.As(err, &sysErr), "got error %v", err)
+				assert.Equal(t, tt.wantErr, sysErr.Reason())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMask, gotMask)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestNewHandlerEcho_FieldMaskPage(t *testing.T) {
+	handler := transport.NewHandlerEcho(func(_ context.Context, _ struct{}) (data.Page[fieldMaskView], error) {
+		return data.Page[fieldMaskView]{
+			TotalItems: 2,
+			Items:      []fieldMaskView{newFieldMaskView("1"), newFieldMaskView("2")},
+		}, nil
+	}, transport.ConfigHandlerHTTP{FieldMask: true})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/tasks?fields=task_id,labels.value", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler(e.NewContext(req, rec)))
+	assert.JSONEq(t, `{"data":{"previous_page_token":"","next_page_token":"","total_items":2,"items":[`+
+		`{"task_id":"1","labels":[{"value":"prod"},{"value":"core"}]},`+
+		`{"task_id":"2","labels":[{"value":"prod"},{"value":"core"}]}]}}`, rec.Body.String())
+}
+
+func TestNewHandlerEcho_FieldMaskScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		disabled bool
+		wantMask data.FieldMask
+		wantBody string
+	}{
+		{
+			name:     "read",
+			method:   http.MethodGet,
+			wantMask: data.FieldMask{"task_id"},
+			wantBody: `{"data":{"task_id":"1"}}`,
+		},
+		{
+			name:     "write",
+			method:   http.MethodPost,
+			wantBody: `{"data":{"task_id":"1"}}`,
+		},
+		{
+			name:     "disabled",
+			method:   http.MethodGet,
+			disabled: true,
+			wantBody: `{"data":{"task_id":"1","name":"task 1","labels":[{"key":"env","value":"prod"},{"key":"team","value":"core"}],"create_time":"2024-01-01T00:00:00Z","create_time_millis":0,"create_by":null,"last_update_time":"","last_update_time_millis":0,"last_update_by":null,"is_active":false,"version":2}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMask data.FieldMask
+			handler := transport.NewHandlerEcho(func(ctx context.Context, _ struct{}) (fieldMaskView, error) {
+				gotMask = data.GetFieldMaskFromContext(ctx)
+				return newFieldMaskView("1"), nil
+			}, transport.ConfigHandlerHTTP{FieldMask: !tt.disabled})
+
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, "/tasks/1?fields=task_id", nil)
+			rec := httptest.NewRecorder()
+			require.NoError(t, handler(e.NewContext(req, rec)))
+			assert.Equal(t, tt.wantMask, gotMask)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestFieldMask_Columns(t *testing.T) {
+	fields := data.CriteriaFields{
+		"task_id":     "task_id",
+		"name":        "task_name",
+		"labels":      "labels",
+		"create_time": "create_time",
+		"version":     "row_version",
+	}
+	mask := data.NewFieldMask("labels.key", "name", " name ")
+	assert.Equal(t, data.FieldMask{"labels.key", "name"}, mask)
+	assert.Equal(t, []string{"labels", "row_version", "task_id", "task_name"},
+		mask.Columns(fields, "task_id", "row_version"))
+	assert.Nil(t, data.FieldMask(nil).Columns(fields))
+	assert.Equal(t, data.FieldMask{"labels", "labels_count"},
+		data.NewFieldMask("labels.key", "labels_count", "labels"))
+}
@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/shutdown"
+)
+
+// ControllerGRPC is a gRPC service holder. Implementations register their generated service
+// descriptors (e.g. pb.RegisterFooServer) into the given registrar.
+type ControllerGRPC interface {
+	RegisterServices(registrar grpc.ServiceRegistrar)
+}
+
+type NewServerGRPCParams struct {
+	fx.In
+
+	Lifecycle               fx.Lifecycle
+	Config                  ConfigGRPC
+	Logger                  logging.Logger
+	GroupUnaryInterceptors  [][]grpc.UnaryServerInterceptor  `group:"unary_interceptors_groups_grpc"`
+	UnaryInterceptors       []grpc.UnaryServerInterceptor    `group:"unary_interceptors_grpc"`
+	GroupStreamInterceptors [][]grpc.StreamServerInterceptor `group:"stream_interceptors_groups_grpc"`
+	StreamInterceptors      []grpc.StreamServerInterceptor   `group:"stream_interceptors_grpc"`
+	ServerOptions           []grpc.ServerOption              `group:"server_options_grpc"`
+	// Coordinator drains the server during shutdown.PhaseServers. The server is stopped once the application
+	// stops if not provided.
+	Coordinator *shutdown.Coordinator `optional:"true"`
+}
+
+// NewServerGRPC allocates a grpc.Server chaining registered interceptors. Interceptor groups are chained first
+// (keeping their internal ordering), then any other interceptor in a non-deterministic way (regarding ordering).
+func NewServerGRPC(params NewServerGRPCParams) *grpc.Server {
+	unaryInterceptors := make([]grpc.UnaryServerInterceptor, 0, len(params.UnaryInterceptors))
+	for _, group := range params.GroupUnaryInterceptors {
+		unaryInterceptors = append(unaryInterceptors, group...)
+	}
+	unaryInterceptors = append(unaryInterceptors, params.UnaryInterceptors...)
+	streamInterceptors := make([]grpc.StreamServerInterceptor, 0, len(params.StreamInterceptors))
+	for _, group := range params.GroupStreamInterceptors {
+		streamInterceptors = append(streamInterceptors, group...)
+	}
+	streamInterceptors = append(streamInterceptors, params.StreamInterceptors...)
+	params.Logger.Info().
+		WithField("total_unary_interceptors", len(unaryInterceptors)).
+		WithField("total_stream_interceptors", len(streamInterceptors)).
+		Write("registered grpc interceptors")
+
+	opts := make([]grpc.ServerOption, 0, len(params.ServerOptions)+2)
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
+	opts = append(opts, params.ServerOptions...)
+	server := grpc.NewServer(opts...)
+	shutdown.Append(params.Lifecycle, params.Coordinator, shutdown.PhaseServers, "grpc_server", fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", params.Config.Address)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if errServe := server.Serve(listener); errServe != nil && !errors.Is(errServe, grpc.ErrServerStopped) {
+					params.Logger.WithError(errServe).Write("failed to start server")
+				}
+			}()
+			params.Logger.Info().WithField("address", params.Config.Address).WriteWithCtx(ctx, "started grpc server")
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				// in-flight calls did not finish on time, closing remaining connections
+				server.Stop()
+				return ctx.Err()
+			}
+		},
+	})
+	return server
+}
+
+type RegisterControllersGRPCParams struct {
+	fx.In
+
+	Server      *grpc.Server
+	Logger      logging.Logger
+	Controllers []ControllerGRPC `group:"controllers_grpc"`
+}
+
+func RegisterControllersGRPC(params RegisterControllersGRPCParams) {
+	params.Logger.Info().
+		WithField("total_controllers", len(params.Controllers)).
+		Write("registering grpc controllers")
+	for _, controller := range params.Controllers {
+		controller.RegisterServices(params.Server)
+	}
+}
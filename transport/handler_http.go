@@ -0,0 +1,196 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/validation"
+)
+
+// HandlerFuncHTTP is a typed HTTP handler. Req is bound from path parameters (`param` tag), query parameters
+// (`query` tag), headers (`header` tag) and request body.
+type HandlerFuncHTTP[Req, Res any] func(ctx context.Context, req Req) (Res, error)
+
+// ConfigHandlerHTTP configuration of handlers allocated by NewHandlerEcho.
+type ConfigHandlerHTTP struct {
+	// StatusCode response status code. Defaults to http.StatusOK. No content is written if http.StatusNoContent.
+	StatusCode int
+	// Validator validates requests after binding. Validation is skipped if nil.
+	Validator validation.Validator
+	// PageTokenQueryParam query parameter used to build pagination links of data.Page responses.
+	// Defaults to page_token.
+	PageTokenQueryParam string
+	// FieldMask enables response field masks (see NewHandlerEcho). Disabled by default, so mask query parameters
+	// do not collide with the ones of existing handlers.
+	FieldMask bool
+	// FieldMaskQueryParams query parameters holding the data.FieldMask used to prune responses.
+	// Defaults to FieldMaskQueryParamsDefault.
+	FieldMaskQueryParams []string
+}
+
+// NewHandlerEcho allocates an echo.HandlerFunc from a typed handler. The request is bound and validated before
+// calling handler, while the response is written wrapped in Data.
+//
+// Binding failures are reported as systemerror invalid argument errors. If Res is a data.Page, pagination
+// headers (X-Total-Count, Link) are written as well.
+//
+// If ConfigHandlerHTTP.FieldMask is set and a field mask is requested (e.g. ?fields=name,audit.create_time), its
+// paths are validated against Res before calling handler and the response is pruned accordingly. For GET and HEAD
+// requests, the mask is also available to handler (and thus, repositories) through data.GetFieldMaskFromContext.
+// Other methods get the whole entity, so writes never operate on partially read entities.
+func NewHandlerEcho[Req, Res any](handler HandlerFuncHTTP[Req, Res], cfg ConfigHandlerHTTP) echo.HandlerFunc {
+	return newHandlerEcho(cfg, bindRequestEcho, true,
+		func(ctx context.Context, _ echo.Context, req Req) (Res, error) {
+			return handler(ctx, req)
+		})
+}
+
+// newHandlerEcho allocates an echo.HandlerFunc binding requests with bind and calling handler. See NewHandlerEcho.
+//
+// readMask indicates if field masks of GET and HEAD requests are propagated to handler.
+func newHandlerEcho[Req, Res any](cfg ConfigHandlerHTTP, bind func(c echo.Context, req any) error, readMask bool,
+	handler func(ctx context.Context, c echo.Context, req Req) (Res, error)) echo.HandlerFunc {
+	if cfg.StatusCode == 0 {
+		cfg.StatusCode = http.StatusOK
+	}
+	if cfg.PageTokenQueryParam == "" {
+		cfg.PageTokenQueryParam = "page_token"
+	}
+	if len(cfg.FieldMaskQueryParams) == 0 {
+		cfg.FieldMaskQueryParams = FieldMaskQueryParamsDefault
+	}
+	return func(c echo.Context) error {
+		var req Req
+		if err := bind(c, &req); err != nil {
+			return err
+		}
+		ctx := c.Request().Context()
+		if cfg.Validator != nil {
+			if err := cfg.Validator.Validate(ctx, req); err != nil {
+				return err
+			}
+		}
+		var mask data.FieldMask
+		if cfg.FieldMask {
+			var err error
+			if mask, err = ParseFieldMaskEcho(c, cfg.FieldMaskQueryParams...); err != nil {
+				return err
+			} else if err = ValidateFieldMaskResponse[Res](mask); err != nil {
+				return err
+			}
+			method := c.Request().Method
+			if readMask && !mask.IsEmpty() && (method == http.MethodGet || method == http.MethodHead) {
+				ctx = data.NewContextWithFieldMask(ctx, mask)
+			}
+		}
+
+		res, err := handler(ctx, c, req)
+		if err != nil {
+			return err
+		} else if cfg.StatusCode == http.StatusNoContent {
+			return c.NoContent(cfg.StatusCode)
+		}
+
+		if page, ok := any(res).(data.PageMetadata); ok && !isNilPointer(res) {
+			writePaginationHeadersEcho(c, cfg.PageTokenQueryParam, page)
+		}
+		body, err := ApplyFieldMask(res, mask)
+		if err != nil {
+			return err
+		}
+		return c.JSON(cfg.StatusCode, Data{
+			Data: body,
+		})
+	}
+}
+
+func isNilPointer(v any) bool {
+	val := reflect.ValueOf(v)
+	return !val.IsValid() || (val.Kind() == reflect.Pointer && val.IsNil())
+}
+
+// bindRequestEcho binds every request source into req. Unlike echo.DefaultBinder.Bind, query parameters are
+// bound regardless of the HTTP method as binding is driven by explicit struct tags.
+func bindRequestEcho(c echo.Context, req any) error {
+	if err := bindParamsEcho(c, req); err != nil {
+		return err
+	} else if err = (&echo.DefaultBinder{}).BindBody(c, req); err != nil {
+		return convertBindErrorEcho("body", err)
+	}
+	return nil
+}
+
+// bindParamsEcho binds path parameters, query parameters and headers into req.
+func bindParamsEcho(c echo.Context, req any) error {
+	binder := &echo.DefaultBinder{}
+	if err := binder.BindPathParams(c, req); err != nil {
+		return convertBindErrorEcho("path", err)
+	} else if err = binder.BindQueryParams(c, req); err != nil {
+		return convertBindErrorEcho("query", err)
+	} else if err = binder.BindHeaders(c, req); err != nil {
+		return convertBindErrorEcho("header", err)
+	}
+	return nil
+}
+
+// convertBindErrorEcho converts echo binding errors into systemerror invalid argument errors. Errors not caused
+// by malformed data (e.g. unsupported media types) are returned as-is.
+func convertBindErrorEcho(source string, err error) error {
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusBadRequest {
+		return err
+	}
+
+	var bindErr *echo.BindingError
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &bindErr):
+		return systemerror.NewMalformedArgument(bindErr.Field, fmt.Sprintf("%v", bindErr.Message))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return systemerror.NewMalformedArgument(typeErr.Field,
+			fmt.Sprintf("expected type %s, got %s", typeErr.Type.String(), typeErr.Value))
+	case errors.As(err, &syntaxErr):
+		return systemerror.NewMalformedArgument(source,
+			fmt.Sprintf("invalid syntax at offset %d", syntaxErr.Offset))
+	case httpErr.Internal != nil:
+		return systemerror.NewMalformedArgument(source, httpErr.Internal.Error())
+	default:
+		return systemerror.NewMalformedArgument(source, fmt.Sprintf("%v", httpErr.Message))
+	}
+}
+
+// writePaginationHeadersEcho writes X-Total-Count and Link (RFC 8288) headers using the current request URL.
+func writePaginationHeadersEcho(c echo.Context, tokenParam string, page data.PageMetadata) {
+	header := c.Response().Header()
+	header.Set(HeaderTotalCount, strconv.Itoa(page.GetTotalItems()))
+	links := make([]string, 0, 2)
+	if token := page.GetNextPageToken(); len(token) > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, newPageLinkEcho(c, tokenParam, token)))
+	}
+	if token := page.GetPreviousPageToken(); len(token) > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, newPageLinkEcho(c, tokenParam, token)))
+	}
+	if len(links) > 0 {
+		header.Set(HeaderLink, strings.Join(links, ", "))
+	}
+}
+
+func newPageLinkEcho(c echo.Context, tokenParam string, token data.PageToken) string {
+	linkURL := *c.Request().URL
+	query := linkURL.Query()
+	query.Set(tokenParam, token.String())
+	linkURL.RawQuery = query.Encode()
+	linkURL.Scheme, linkURL.Host = "", ""
+	return linkURL.RequestURI()
+}
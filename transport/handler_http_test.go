@@ -0,0 +1,128 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+	"github.com/hadroncorp/geck/validation"
+)
+
+type handlerRequest struct {
+	TaskID    string `param:"task_id" validate:"required"`
+	PageSize  int    `query:"page_size"`
+	RequestID string `header:"X-Request-Id"`
+	Name      string `json:"name" validate:"omitempty,lte=8"`
+}
+
+func TestNewHandlerEcho(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		handlerErr error
+		wantCode   int
+		wantErr    string
+	}{
+		{
+			name:     "ok",
+			target:   "/tasks/123?page_size=10",
+			body:     `{"name":"foo"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:    "malformed query",
+			target:  "/tasks/123?page_size=abc",
+			wantErr: "MALFORMED_ARGUMENT",
+		},
+		{
+			name:    "malformed body",
+			target:  "/tasks/123",
+			body:    `{"name":1}`,
+			wantErr: "MALFORMED_ARGUMENT",
+		},
+		{
+			name:    "invalid body",
+			target:  "/tasks/123",
+			body:    `{"name":"foo bar baz"}`,
+			wantErr: "ARGUMENT_OUT_OF_RANGE",
+		},
+		{
+			name:       "handler error",
+			target:     "/tasks/123",
+			handlerErr: systemerror.NewResourceNotFound[string]("123"),
+			wantErr:    "RESOURCE_NOT_FOUND",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got handlerRequest
+			handler := transport.NewHandlerEcho(func(_ context.Context, req handlerRequest) (handlerRequest, error) {
+				got = req
+				return req, tt.handlerErr
+			}, transport.ConfigHandlerHTTP{
+				StatusCode: http.StatusCreated,
+				Validator:  validation.NewGoPlaygroundValidator(),
+			})
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("X-Request-Id", "abc")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/tasks/:task_id")
+			c.SetParamNames("task_id")
+			c.SetParamValues("123")
+			err := handler(c)
+			if tt.wantErr != "" {
+				var sysErr systemerror.Error
+				require.True(t, errors.As(unwrapFirst(err), &sysErr), "got error %v", err)
+				assert.Equal(t, tt.wantErr, sysErr.Reason())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, handlerRequest{TaskID: "123", PageSize: 10, RequestID: "abc", Name: "foo"}, got)
+			assert.JSONEq(t, `{"data":{"TaskID":"123","PageSize":10,"RequestID":"abc","name":"foo"}}`,
+				rec.Body.String())
+		})
+	}
+}
+
+func unwrapFirst(err error) error {
+	if container, ok := err.(interface{ Unwrap() []error }); ok {
+		return container.Unwrap()[0]
+	}
+	return err
+}
+
+func TestNewHandlerEcho_Page(t *testing.T) {
+	handler := transport.NewHandlerEcho(func(_ context.Context, _ struct{}) (data.Page[string], error) {
+		return data.Page[string]{
+			PreviousPageToken: data.PageToken("abc"),
+			NextPageToken:     data.PageToken("def"),
+			TotalItems:        25,
+			Items:             []string{"foo"},
+		}, nil
+	}, transport.ConfigHandlerHTTP{})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/tasks?page_size=1&page_token=xyz", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "25", rec.Header().Get(transport.HeaderTotalCount))
+	assert.Equal(t, `</tasks?page_size=1&page_token=def>; rel="next", </tasks?page_size=1&page_token=abc>; rel="prev"`,
+		rec.Header().Get(transport.HeaderLink))
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// NewCORSEcho allocates a cross-origin resource sharing middleware using ConfigCORSHTTP.
+func NewCORSEcho(cfg ConfigCORSHTTP) echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge.Seconds()),
+	})
+}
+
+// NewSecurityHeadersEcho allocates a middleware writing security response headers (X-Content-Type-Options,
+// X-Frame-Options, Strict-Transport-Security, Content-Security-Policy and Referrer-Policy) using
+// ConfigSecurityHeadersHTTP.
+func NewSecurityHeadersEcho(cfg ConfigSecurityHeadersHTTP) echo.MiddlewareFunc {
+	return middleware.SecureWithConfig(middleware.SecureConfig{
+		ContentTypeNosniff:    cfg.ContentTypeNosniff,
+		XFrameOptions:         cfg.FrameOptions,
+		HSTSMaxAge:            int(cfg.HSTSMaxAge.Seconds()),
+		HSTSExcludeSubdomains: !cfg.HSTSIncludeSubdomains,
+		HSTSPreloadEnabled:    cfg.HSTSPreload,
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		CSPReportOnly:         cfg.CSPReportOnly,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+	})
+}
+
+// NewBodyLimitEcho allocates a middleware limiting request body sizes using ConfigHTTP.BodyLimit and
+// ConfigHTTP.BodyLimitRoutes. Requests exceeding limits are rejected with 413 status code.
+func NewBodyLimitEcho(cfg ConfigHTTP) echo.MiddlewareFunc {
+	// middlewares are allocated once per distinct limit
+	limits := make(map[string]echo.MiddlewareFunc, len(cfg.BodyLimitRoutes)+1)
+	if cfg.BodyLimit != "" {
+		limits[cfg.BodyLimit] = middleware.BodyLimit(cfg.BodyLimit)
+	}
+	for _, limit := range cfg.BodyLimitRoutes {
+		if _, ok := limits[limit]; !ok {
+			limits[limit] = middleware.BodyLimit(limit)
+		}
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			limit, ok := cfg.BodyLimitMatcher.Lookup(c.Request().Method, echo.GetPath(c.Request()))
+			if !ok {
+				limit = cfg.BodyLimit
+			}
+			limitFunc, ok := limits[limit]
+			if !ok {
+				return next(c)
+			}
+			return limitFunc(next)(c)
+		}
+	}
+}
+
+// NewHandlerTimeoutEcho allocates a middleware limiting request handling durations using ConfigHTTP.HandlerTimeout
+// and ConfigHTTP.HandlerTimeoutRoutes.
+//
+// Handlers are notified through request context cancellation, hence they MUST honour context.Context. If the
+// timeout expires and no response was written yet, a systemerror.StatusDeadlineExceeded error is returned.
+func NewHandlerTimeoutEcho(cfg ConfigHTTP) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout, ok := cfg.HandlerTimeoutMatcher.Lookup(c.Request().Method, echo.GetPath(c.Request()))
+			if !ok {
+				timeout = cfg.HandlerTimeout
+			}
+			if timeout <= 0 {
+				return next(c)
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			err := next(c)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Response().Committed {
+				return systemerror.NewTimeout(timeout)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func newHardenedEcho(t *testing.T) *echo.Echo {
+	t.Setenv("HTTP_CORS_ENABLED", "true")
+	t.Setenv("HTTP_CORS_ALLOW_ORIGINS", "https://app.example.com")
+	t.Setenv("HTTP_SECURITY_HEADERS_ENABLED", "true")
+	t.Setenv("HTTP_SECURITY_HEADERS_CONTENT_SECURITY_POLICY", "default-src 'self'")
+	t.Setenv("HTTP_SERVER_BODY_LIMIT", "8B")
+	t.Setenv("HTTP_SERVER_BODY_LIMIT_ROUTES", "POST /documents=1K")
+	t.Setenv("HTTP_SERVER_HANDLER_TIMEOUT", "10ms")
+	t.Setenv("HTTP_SERVER_HANDLER_TIMEOUT_ROUTES", "GET /events/**=0s")
+	cfg, err := transport.NewConfigHTTP()
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = transport.NewErrorHandlerEcho(cfg)
+	e.Use(transport.NewDefaultEchoMiddlewareGroup(transport.DefaultEchoMiddlewareParams{
+		Config: cfg,
+		Logger: logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	}, transport.TraceIDEchoParams{})...)
+	slowHandler := func(c echo.Context) error {
+		select {
+		case <-c.Request().Context().Done():
+			return c.Request().Context().Err()
+		case <-time.After(50 * time.Millisecond):
+			return c.NoContent(http.StatusNoContent)
+		}
+	}
+	e.GET("/slow", slowHandler)
+	e.GET("/events/stream", slowHandler)
+	bodyHandler := func(c echo.Context) error {
+		var body map[string]any
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.POST("/tasks", bodyHandler)
+	e.POST("/documents", bodyHandler)
+	return e
+}
+
+func TestNewDefaultEchoMiddlewareGroup_Hardening(t *testing.T) {
+	e := newHardenedEcho(t)
+
+	t.Run("security headers", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events/stream", nil))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "nosniff", rec.Header().Get(echo.HeaderXContentTypeOptions))
+		assert.Equal(t, "DENY", rec.Header().Get(echo.HeaderXFrameOptions))
+		assert.Equal(t, "default-src 'self'", rec.Header().Get(echo.HeaderContentSecurityPolicy))
+		assert.Equal(t, "strict-origin-when-cross-origin", rec.Header().Get(echo.HeaderReferrerPolicy))
+	})
+
+	t.Run("cors preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/tasks", nil)
+		req.Header.Set(echo.HeaderOrigin, "https://app.example.com")
+		req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	})
+
+	t.Run("body limit", func(t *testing.T) {
+		body := `{"name":"some task"}`
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+		req = httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("handler timeout", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+		assert.Contains(t, rec.Body.String(), "TIMEOUT")
+	})
+}
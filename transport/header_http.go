@@ -0,0 +1,47 @@
+package transport
+
+// HTTP headers not defined by Echo.
+const (
+	HeaderETag        = "ETag"
+	HeaderIfNoneMatch = "If-None-Match"
+	HeaderIfMatch     = "If-Match"
+	// HeaderIfUnmodifiedSince conditional header for unsafe methods (RFC 9110 section 13.1.4).
+	HeaderIfUnmodifiedSince = "If-Unmodified-Since"
+	HeaderAge               = "Age"
+	HeaderDate              = "Date"
+	// HeaderCache indicates if the response was served from cache (HIT) or not (MISS).
+	HeaderCache = "X-Cache"
+	// HeaderRateLimitLimit the request quota of the current time window (IETF RateLimit header fields draft).
+	HeaderRateLimitLimit = "RateLimit-Limit"
+	// HeaderRateLimitRemaining the remaining request quota of the current time window.
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	// HeaderRateLimitReset seconds until the quota gets fully restored.
+	HeaderRateLimitReset = "RateLimit-Reset"
+	// HeaderRateLimitPolicy the quota policy (e.g. 100;w=60).
+	HeaderRateLimitPolicy = "RateLimit-Policy"
+	// HeaderLink web links (RFC 8288), used for pagination links.
+	HeaderLink = "Link"
+	// HeaderIdempotencyKey client-generated key making unsafe requests idempotent (IETF Idempotency-Key draft).
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed indicates the response was replayed from a previous request with the same
+	// idempotency key.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	// HeaderTotalCount total number of items of a paginated dataset.
+	HeaderTotalCount = "X-Total-Count"
+	// HeaderLastEventID identifier of the last server-sent event received by a reconnecting client.
+	HeaderLastEventID = "Last-Event-ID"
+	// HeaderSpanID span identifier of the caller, propagated to downstream services.
+	HeaderSpanID = "X-Span-ID"
+	// HeaderTraceID trace identifier of the request, echoed in responses so clients can correlate logs.
+	HeaderTraceID = "X-Trace-ID"
+	// HeaderAPIVersion API version serving the response.
+	HeaderAPIVersion = "API-Version"
+	// HeaderDeprecation indicates the resource is (or will be) deprecated (RFC 9745).
+	HeaderDeprecation = "Deprecation"
+	// HeaderSunset indicates when the resource will become unavailable (RFC 8594).
+	HeaderSunset = "Sunset"
+	// HeaderContentDigest digest of the (part) content, using the nomenclature: ALGORITHM=:BASE64: (RFC 9530).
+	HeaderContentDigest = "Content-Digest"
+	// HeaderAcceptPatch patch document media types accepted by the resource (RFC 5789).
+	HeaderAcceptPatch = "Accept-Patch"
+)
@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/hadroncorp/geck/actuator"
+	"github.com/hadroncorp/geck/observability/logging"
+)
+
+// HealthControllerGRPC implements the gRPC health-checking protocol
+// (https://github.com/grpc/grpc/blob/master/doc/health-checking.md) using actuator.Manager.
+//
+// Only the overall server health is supported (empty service name), as actuator.Manager aggregates every component.
+type HealthControllerGRPC struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	Manager *actuator.Manager
+	Logger  logging.Logger
+	Config  ConfigGRPC
+}
+
+var (
+	_ ControllerGRPC              = (*HealthControllerGRPC)(nil)
+	_ grpc_health_v1.HealthServer = (*HealthControllerGRPC)(nil)
+)
+
+type NewHealthControllerGRPCParams struct {
+	fx.In
+	Manager *actuator.Manager
+	Logger  logging.Logger
+	Config  ConfigGRPC
+}
+
+func NewHealthControllerGRPC(params NewHealthControllerGRPCParams) *HealthControllerGRPC {
+	return &HealthControllerGRPC{
+		Manager: params.Manager,
+		Logger:  params.Logger,
+		Config:  params.Config,
+	}
+}
+
+func (h *HealthControllerGRPC) RegisterServices(registrar grpc.ServiceRegistrar) {
+	grpc_health_v1.RegisterHealthServer(registrar, h)
+}
+
+func (h *HealthControllerGRPC) getStatus(ctx context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	state, err := h.Manager.Health(ctx)
+	if err != nil {
+		h.Logger.WithError(err).WriteWithCtx(ctx, "health check failed")
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	switch state.Status {
+	case actuator.StatusUp:
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	case actuator.StatusDown:
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	default:
+		return grpc_health_v1.HealthCheckResponse_UNKNOWN
+	}
+}
+
+func (h *HealthControllerGRPC) Check(ctx context.Context,
+	req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if req.GetService() != "" {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &grpc_health_v1.HealthCheckResponse{
+		Status: h.getStatus(ctx),
+	}, nil
+}
+
+func (h *HealthControllerGRPC) Watch(req *grpc_health_v1.HealthCheckRequest,
+	stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	if req.GetService() != "" {
+		// as specified by the protocol, unknown services are reported instead of failing the stream, keeping it
+		// open as the service might get registered later on
+		err := stream.Send(&grpc_health_v1.HealthCheckResponse{
+			Status: grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN,
+		})
+		if err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	ticker := time.NewTicker(h.Config.HealthWatchInterval)
+	defer ticker.Stop()
+	lastStatus := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		currentStatus := h.getStatus(ctx)
+		if currentStatus != lastStatus {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: currentStatus}); err != nil {
+				return err
+			}
+			lastStatus = currentStatus
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
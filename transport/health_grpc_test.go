@@ -0,0 +1,105 @@
+package transport_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/hadroncorp/geck/actuator"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/transport"
+)
+
+type stubActuator struct {
+	status atomic.Int32
+}
+
+func (s *stubActuator) State(_ context.Context) (actuator.State, error) {
+	return actuator.State{Status: actuator.Status(s.status.Load())}, nil
+}
+
+type stubHealthWatchServer struct {
+	stubServerStreamGRPC
+	responses chan grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+func (s stubHealthWatchServer) Send(res *grpc_health_v1.HealthCheckResponse) error {
+	s.responses <- res.GetStatus()
+	return nil
+}
+
+func newTestHealthControllerGRPC() (*transport.HealthControllerGRPC, *stubActuator) {
+	component := &stubActuator{}
+	component.status.Store(int32(actuator.StatusUp))
+	controller := transport.NewHealthControllerGRPC(transport.NewHealthControllerGRPCParams{
+		Manager: actuator.NewManager(actuator.NewManagerParams{
+			Actuators: []actuator.Actuator{component},
+		}),
+		Logger: logging.NewZerologLoggerAdapter(zerolog.Nop()),
+		Config: transport.ConfigGRPC{HealthWatchInterval: time.Millisecond},
+	})
+	return controller, component
+}
+
+func TestHealthControllerGRPC_Check(t *testing.T) {
+	controller, component := newTestHealthControllerGRPC()
+	res, err := controller.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, res.GetStatus())
+
+	component.status.Store(int32(actuator.StatusDown))
+	res, err = controller.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, res.GetStatus())
+
+	_, err = controller.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "tasks"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestHealthControllerGRPC_Watch(t *testing.T) {
+	watch := func(controller *transport.HealthControllerGRPC, service string) (
+		chan grpc_health_v1.HealthCheckResponse_ServingStatus, context.CancelFunc, chan error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream := stubHealthWatchServer{
+			stubServerStreamGRPC: stubServerStreamGRPC{ctx: ctx},
+			responses:            make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 8),
+		}
+		errs := make(chan error, 1)
+		go func() {
+			errs <- controller.Watch(&grpc_health_v1.HealthCheckRequest{Service: service}, stream)
+		}()
+		return stream.responses, cancel, errs
+	}
+
+	t.Run("status changes", func(t *testing.T) {
+		controller, component := newTestHealthControllerGRPC()
+		responses, cancel, errs := watch(controller, "")
+		assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, <-responses)
+		component.status.Store(int32(actuator.StatusDown))
+		assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, <-responses)
+		cancel()
+		assert.Equal(t, codes.Canceled, status.Code(<-errs))
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		controller, _ := newTestHealthControllerGRPC()
+		responses, cancel, errs := watch(controller, "tasks")
+		assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, <-responses)
+		select {
+		case err := <-errs:
+			t.Fatalf("stream closed before context was done: %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		cancel()
+		assert.Equal(t, codes.Canceled, status.Code(<-errs))
+		assert.Empty(t, responses)
+	})
+}
@@ -0,0 +1,212 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/emirpasic/gods/v2/sets/hashset"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+const (
+	idempotencyKeyPrefix = "geck.transport.http.idempotency#"
+	// idempotencyAnonymousScope scope of keys sent without principal. Principal scopes are prefixed, so they never
+	// collide with it.
+	idempotencyAnonymousScope = "anonymous"
+)
+
+// non-replayable headers. Most of them are either per-request or computed by the HTTP server itself.
+var idempotencyExcludedHeaders = map[string]struct{}{
+	echo.HeaderContentLength:   {},
+	echo.HeaderContentEncoding: {},
+	echo.HeaderVary:            {},
+	HeaderDate:                 {},
+}
+
+type NewIdempotencyEchoParams struct {
+	fx.In
+
+	Store        IdempotencyStore
+	Config       ConfigIdempotencyHTTP
+	ServerConfig ConfigHTTP
+	Logger       logging.Logger
+}
+
+// NewIdempotencyEcho allocates a middleware honouring the Idempotency-Key header (see IdempotencyEcho).
+func NewIdempotencyEcho(params NewIdempotencyEchoParams) echo.MiddlewareFunc {
+	return IdempotencyEcho(params.Store, params.Config, params.ServerConfig, params.Logger)
+}
+
+// IdempotencyEcho allocates a middleware honouring the Idempotency-Key header for ConfigIdempotencyHTTP.Methods.
+//
+// The first request holding a key gets processed and its response stored, then requests with the same key and
+// payload get the stored response replayed. Concurrent requests with the same key get a StatusAborted error, while
+// keys reused with a different payload get a StatusUnprocessableContent error. Failed requests
+// (errors or 5xx responses) release their key, so clients are able to retry.
+//
+// Request bodies are buffered to fingerprint requests, so bodies larger than ConfigIdempotencyHTTP.MaxBodySize are
+// rejected with 413 status code.
+//
+// Keys are scoped by principal (security.Principal ID), so the middleware MUST run after authenticators (see
+// AsAuthenticatedMiddlewareHTTP) and keys survive token refreshes. Anonymous requests share a single scope.
+func IdempotencyEcho(store IdempotencyStore, cfg ConfigIdempotencyHTTP, serverCfg ConfigHTTP,
+	logger logging.Logger) echo.MiddlewareFunc {
+	methods := hashset.New(cfg.Methods...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !methods.Contains(req.Method) {
+				return next(c)
+			}
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if key == "" && cfg.KeyRequired {
+				return systemerror.NewMissingArgument(HeaderIdempotencyKey)
+			} else if key == "" {
+				return next(c)
+			} else if len(key) > cfg.MaxKeyLength {
+				return systemerror.NewArgumentOutOfRangeSingle(HeaderIdempotencyKey, "max", cfg.MaxKeyLength)
+			}
+
+			if cfg.MaxBodySizeBytes > 0 {
+				req.Body = http.MaxBytesReader(c.Response(), req.Body, cfg.MaxBodySizeBytes)
+			}
+			body, err := io.ReadAll(req.Body)
+			var errMaxBytes *http.MaxBytesError
+			if errors.As(err, &errMaxBytes) {
+				return echo.ErrStatusRequestEntityTooLarge
+			} else if err != nil {
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := req.Context()
+			storeKey := newIdempotencyKey(c, key)
+			record := IdempotencyRecord{
+				Fingerprint: newIdempotencyFingerprint(c, body),
+				ExpireTime:  time.Now().UTC().Add(cfg.InFlightTTL),
+			}
+			current, reserved, err := store.Reserve(ctx, storeKey, record)
+			if errors.Is(err, ErrIdempotencyKeyContended) {
+				return newIdempotentRequestInProgressError(key)
+			} else if err != nil {
+				// fail open, an unavailable store must not take the service down
+				logger.WithError(err).WriteWithCtx(ctx, "failed to reserve idempotency key")
+				return next(c)
+			} else if current.Fingerprint != record.Fingerprint {
+				return systemerror.NewUnprocessableContent("IDEMPOTENCY_KEY_REUSED",
+					"idempotency key was already used with a different request", map[string]string{
+						"idempotency_key": key,
+					})
+			} else if !reserved && !current.Completed {
+				return newIdempotentRequestInProgressError(key)
+			} else if !reserved {
+				return replayIdempotentResponseEcho(c, current)
+			}
+			return captureIdempotentResponseEcho(c, next, store, storeKey, record, cfg, serverCfg, logger)
+		}
+	}
+}
+
+func newIdempotentRequestInProgressError(key string) error {
+	return systemerror.NewAborted("IDEMPOTENT_REQUEST_IN_PROGRESS",
+		"a request with the same idempotency key is being processed", map[string]string{
+			"idempotency_key": key,
+		})
+}
+
+func newIdempotencyKey(c echo.Context, key string) string {
+	req := c.Request()
+	hasher := sha256.New()
+	writeField := func(val string) {
+		_, _ = hasher.Write([]byte(val))
+		_, _ = hasher.Write([]byte{0})
+	}
+	if principal, err := security.GetPrincipalFromContext(req.Context()); err == nil {
+		writeField("principal:" + principal.ID())
+	} else {
+		writeField(idempotencyAnonymousScope)
+	}
+	writeField(key)
+	return idempotencyKeyPrefix + hex.EncodeToString(hasher.Sum(nil))
+}
+
+func newIdempotencyFingerprint(c echo.Context, body []byte) string {
+	req := c.Request()
+	hasher := sha256.New()
+	_, _ = hasher.Write([]byte(req.Method))
+	_, _ = hasher.Write([]byte{0})
+	_, _ = hasher.Write([]byte(req.URL.RequestURI()))
+	_, _ = hasher.Write([]byte{0})
+	_, _ = hasher.Write(body)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func replayIdempotentResponseEcho(c echo.Context, record IdempotencyRecord) error {
+	header := c.Response().Header()
+	for key, values := range record.Header {
+		header[key] = values
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+	c.Response().WriteHeader(record.Status)
+	_, err := c.Response().Write(record.Body)
+	return err
+}
+
+func captureIdempotentResponseEcho(c echo.Context, next echo.HandlerFunc, store IdempotencyStore, key string,
+	record IdempotencyRecord, cfg ConfigIdempotencyHTTP, serverCfg ConfigHTTP, logger logging.Logger) error {
+	// store operations MUST happen even if the client is gone, otherwise, keys would remain in-flight
+	ctx := context.WithoutCancel(c.Request().Context())
+	res := c.Response()
+	originalHeader := res.Header().Clone()
+	writer := &bufferedResponseWriter{ResponseWriter: res.Writer}
+	res.Writer = writer
+	err := next(c)
+	res.Writer = writer.ResponseWriter
+
+	status := res.Status
+	if err != nil || !res.Committed || status >= http.StatusInternalServerError {
+		if errRelease := store.Release(ctx, key); errRelease != nil {
+			logger.WithError(errRelease).WriteWithCtx(ctx, "failed to release idempotency key")
+		}
+	} else {
+		record.Completed = true
+		record.Status = status
+		record.Header = newIdempotentHeader(originalHeader, res.Header(), serverCfg.RequestIDTargetHeader)
+		record.Body = writer.Bytes()
+		record.ExpireTime = time.Now().UTC().Add(cfg.TTL)
+		if errComplete := store.Complete(ctx, key, record); errComplete != nil {
+			logger.WithError(errComplete).WriteWithCtx(ctx, "failed to store idempotent response")
+		}
+	}
+	if !res.Committed {
+		return err
+	}
+	return errors.Join(err, writer.replay(res, status))
+}
+
+// newIdempotentHeader returns headers set by the handler itself, headers set by outer middlewares are per-request.
+func newIdempotentHeader(before, after http.Header, requestIDHeader string) http.Header {
+	out := make(http.Header, len(after))
+	for key, values := range after {
+		if _, excluded := idempotencyExcludedHeaders[key]; excluded || key == requestIDHeader {
+			continue
+		}
+		if prevValues, ok := before[key]; ok && slices.Equal(prevValues, values) {
+			continue
+		}
+		out[key] = values
+	}
+	return out
+}
@@ -0,0 +1,258 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/allegro/bigcache/v3"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/caching"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestIdempotencyEcho(t *testing.T) {
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := transport.NewIdempotencyStoreCache(caching.NewCacheEmbedded(db, caching.BigCacheConfig{}, nil))
+	cfg := transport.ConfigIdempotencyHTTP{
+		TTL:          time.Minute,
+		InFlightTTL:  time.Minute,
+		Methods:      []string{http.MethodPost},
+		MaxKeyLength: 255,
+	}
+	calls := 0
+	block := make(chan struct{})
+	started := make(chan struct{})
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	e.Use(transport.IdempotencyEcho(store, cfg, transport.ConfigHTTP{}, logging.NewZerologLoggerAdapter(zerolog.Nop())))
+	e.POST("/tasks", func(c echo.Context) error {
+		calls++
+		if c.QueryParam("block") != "" {
+			close(started)
+			<-block
+		}
+		c.Response().Header().Set("Location", "/tasks/1")
+		return c.JSON(http.StatusCreated, transport.Data{Data: calls})
+	})
+
+	doRequest := func(key, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(transport.HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := doRequest("key-1", "/tasks", `{"name":"foo"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"data":1}`, strings.TrimSpace(rec.Body.String()))
+
+	// replay
+	rec = doRequest("key-1", "/tasks", `{"name":"foo"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"data":1}`, strings.TrimSpace(rec.Body.String()))
+	assert.Equal(t, "true", rec.Header().Get(transport.HeaderIdempotentReplayed))
+	assert.Equal(t, "/tasks/1", rec.Header().Get("Location"))
+	assert.Equal(t, 1, calls)
+
+	// different payload
+	rec = doRequest("key-1", "/tasks", `{"name":"bar"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+
+	// no key
+	rec = doRequest("", "/tasks", `{"name":"foo"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 2, calls)
+
+	// in-flight
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		doRequest("key-2", "/tasks?block=true", `{}`)
+	}()
+	<-started
+	rec = doRequest("key-2", "/tasks?block=true", `{}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "IDEMPOTENT_REQUEST_IN_PROGRESS")
+	close(block)
+	wg.Wait()
+}
+
+func TestIdempotencyEcho_Principal(t *testing.T) {
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := transport.NewIdempotencyStoreCache(caching.NewCacheEmbedded(db, caching.BigCacheConfig{}, nil))
+	cfg := transport.ConfigIdempotencyHTTP{
+		TTL:          time.Minute,
+		InFlightTTL:  time.Minute,
+		Methods:      []string{http.MethodPost},
+		MaxKeyLength: 255,
+	}
+	logger := logging.NewZerologLoggerAdapter(zerolog.Nop())
+	principals := map[string]string{"Bearer token-1": "user-1", "Bearer token-2": "user-1", "Bearer token-3": "user-2"}
+	authenticator := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principalID, ok := principals[c.Request().Header.Get(echo.HeaderAuthorization)]
+			if !ok {
+				return systemerror.NewUnauthenticated()
+			}
+			ctx := context.WithValue(c.Request().Context(), security.PrincipalContextKey,
+				security.PrincipalTemplate{Identifier: principalID})
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+
+	calls := 0
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	// authenticated middlewares are registered last regardless of the order they are provided in
+	transport.RegisterMiddlewaresEcho(transport.RegisterMiddlewaresEchoParams{
+		Echo:   e,
+		Logger: logger,
+		AuthenticatedMiddlewares: []echo.MiddlewareFunc{
+			transport.IdempotencyEcho(store, cfg, transport.ConfigHTTP{}, logger),
+		},
+		Middlewares: []echo.MiddlewareFunc{authenticator},
+	})
+	e.POST("/tasks", func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, transport.Data{Data: calls})
+	})
+
+	doRequest := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"name":"foo"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, token)
+		req.Header.Set(transport.HeaderIdempotencyKey, "key-1")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := doRequest("Bearer token-1")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// retried after refreshing the token
+	rec = doRequest("Bearer token-2")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(transport.HeaderIdempotentReplayed))
+	assert.Equal(t, 1, calls)
+
+	// stored responses are never replayed to unauthenticated requests
+	rec = doRequest("Bearer expired")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// keys are scoped by principal
+	rec = doRequest("Bearer token-3")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(transport.HeaderIdempotentReplayed))
+	assert.Equal(t, 2, calls)
+}
+
+type contendedIdempotencyStore struct {
+	transport.IdempotencyStore
+}
+
+func (s contendedIdempotencyStore) Reserve(_ context.Context, _ string,
+	_ transport.IdempotencyRecord) (transport.IdempotencyRecord, bool, error) {
+	return transport.IdempotencyRecord{}, false, transport.ErrIdempotencyKeyContended
+}
+
+func TestIdempotencyEcho_Rejections(t *testing.T) {
+	cfg := transport.ConfigIdempotencyHTTP{
+		TTL:              time.Minute,
+		InFlightTTL:      time.Minute,
+		Methods:          []string{http.MethodPost},
+		MaxKeyLength:     255,
+		MaxBodySizeBytes: 8,
+	}
+	calls := 0
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	e.Use(transport.IdempotencyEcho(contendedIdempotencyStore{}, cfg, transport.ConfigHTTP{},
+		logging.NewZerologLoggerAdapter(zerolog.Nop())))
+	e.POST("/tasks", func(c echo.Context) error {
+		calls++
+		return c.NoContent(http.StatusCreated)
+	})
+	doRequest := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+		req.Header.Set(transport.HeaderIdempotencyKey, "key-1")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// contended keys must not fail open
+	rec := doRequest(`{}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "IDEMPOTENT_REQUEST_IN_PROGRESS")
+
+	rec = doRequest(`{"name":"foo"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Zero(t, calls)
+}
+
+type contendedAtomicCache struct {
+	caching.AtomicCache
+}
+
+func (c contendedAtomicCache) SetIfAbsent(_ context.Context, _ string, _ []byte) (bool, error) {
+	return false, nil
+}
+
+func (c contendedAtomicCache) Get(_ context.Context, _ string) ([]byte, error) {
+	return nil, caching.ErrEntryNotFound
+}
+
+func TestIdempotencyStoreCache_Reserve(t *testing.T) {
+	store := transport.NewIdempotencyStoreCache(contendedAtomicCache{})
+	_, reserved, err := store.Reserve(context.Background(), "key-1", transport.IdempotencyRecord{})
+	assert.False(t, reserved)
+	assert.ErrorIs(t, err, transport.ErrIdempotencyKeyContended)
+}
+
+func TestIdempotencyStorePostgres_Reserve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	cfg := transport.ConfigIdempotencyHTTP{TableName: "idempotency_keys"}
+	store := transport.NewIdempotencyStorePostgres(cfg, db)
+
+	// conflicting row released between the upsert and the follow-up select
+	mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT record FROM idempotency_keys").
+		WithArgs("key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"record"}))
+	mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, reserved, err := store.Reserve(context.Background(), "key-1", transport.IdempotencyRecord{})
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hadroncorp/geck/data/caching"
+	gecksql "github.com/hadroncorp/geck/data/sql"
+)
+
+const idempotencyStoreMaxAttempts = 3
+
+// ErrIdempotencyKeyContended the idempotency key could not be reserved as concurrent requests kept changing its
+// record.
+var ErrIdempotencyKeyContended = errors.New("transport: idempotency key contended")
+
+// IdempotencyRecord is the state of an idempotency key. Records are in-flight until Completed, then they hold
+// the response to replay.
+type IdempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	ExpireTime  time.Time   `json:"expire_time"`
+}
+
+// IsExpired indicates if the record is no longer valid at the given time.
+func (r IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpireTime)
+}
+
+// IdempotencyStore persists IdempotencyRecord(s).
+type IdempotencyStore interface {
+	// Reserve stores record for key only if key has no record (or an expired one). Returns the current
+	// record and false if key is already taken. Produces ErrIdempotencyKeyContended if concurrent requests
+	// prevented the reservation.
+	Reserve(ctx context.Context, key string, record IdempotencyRecord) (IdempotencyRecord, bool, error)
+	// Complete replaces the record of key.
+	Complete(ctx context.Context, key string, record IdempotencyRecord) error
+	// Release removes the record of key, allowing clients to retry.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyStoreCache is the IdempotencyStore implementation using caching.AtomicCache.
+//
+// Entries might be evicted by the cache before their expiration (e.g. BigCacheConfig.ItemTTL), so cache
+// TTLs should be greater than ConfigIdempotencyHTTP.TTL.
+type IdempotencyStoreCache struct {
+	Cache caching.AtomicCache
+}
+
+var _ IdempotencyStore = IdempotencyStoreCache{}
+
+func NewIdempotencyStoreCache(cache caching.AtomicCache) IdempotencyStoreCache {
+	return IdempotencyStoreCache{
+		Cache: cache,
+	}
+}
+
+func (s IdempotencyStoreCache) Reserve(ctx context.Context, key string,
+	record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	for attempt := 0; attempt < idempotencyStoreMaxAttempts; attempt++ {
+		stored, errSet := s.Cache.SetIfAbsent(ctx, key, raw)
+		if errSet != nil {
+			return IdempotencyRecord{}, false, errSet
+		} else if stored {
+			return record, true, nil
+		}
+
+		currentRaw, errGet := s.Cache.Get(ctx, key)
+		if errors.Is(errGet, caching.ErrEntryNotFound) {
+			// released in between, try again
+			continue
+		} else if errGet != nil {
+			return IdempotencyRecord{}, false, errGet
+		}
+		current := IdempotencyRecord{}
+		if err = json.Unmarshal(currentRaw, &current); err != nil {
+			return IdempotencyRecord{}, false, err
+		}
+		if !current.IsExpired(time.Now().UTC()) {
+			return current, false, nil
+		}
+		swapped, errSwap := s.Cache.CompareAndSwap(ctx, key, currentRaw, raw)
+		if errSwap != nil {
+			return IdempotencyRecord{}, false, errSwap
+		} else if swapped {
+			return record, true, nil
+		}
+	}
+	return IdempotencyRecord{}, false, ErrIdempotencyKeyContended
+}
+
+func (s IdempotencyStoreCache) Complete(ctx context.Context, key string, record IdempotencyRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.Cache.Set(ctx, key, raw)
+}
+
+func (s IdempotencyStoreCache) Release(ctx context.Context, key string) error {
+	err := s.Cache.Delete(ctx, key)
+	if errors.Is(err, caching.ErrEntryNotFound) {
+		return nil
+	}
+	return err
+}
+
+// IdempotencyStorePostgres is the IdempotencyStore implementation using a PostgreSQL table with the
+// following structure:
+//
+//	CREATE TABLE idempotency_keys (
+//		idempotency_key VARCHAR(512) PRIMARY KEY,
+//		record          BYTEA NOT NULL,
+//		expire_time     TIMESTAMPTZ NOT NULL
+//	);
+//
+// Table name is set by ConfigIdempotencyHTTP.TableName.
+type IdempotencyStorePostgres struct {
+	Config ConfigIdempotencyHTTP
+	Client gecksql.Client
+}
+
+var _ IdempotencyStore = IdempotencyStorePostgres{}
+
+func NewIdempotencyStorePostgres(cfg ConfigIdempotencyHTTP, client gecksql.Client) IdempotencyStorePostgres {
+	return IdempotencyStorePostgres{
+		Config: cfg,
+		Client: client,
+	}
+}
+
+func (s IdempotencyStorePostgres) Reserve(ctx context.Context, key string,
+	record IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	// expired records are overridden, behaving as if they did not exist
+	upsertQuery := fmt.Sprintf(`INSERT INTO %s (idempotency_key, record, expire_time) VALUES ($1, $2, $3)
+ON CONFLICT (idempotency_key) DO UPDATE SET record = EXCLUDED.record, expire_time = EXCLUDED.expire_time
+WHERE %s.expire_time <= $4`, s.Config.TableName, s.Config.TableName)
+	selectQuery := fmt.Sprintf("SELECT record FROM %s WHERE idempotency_key = $1", s.Config.TableName)
+	for attempt := 0; attempt < idempotencyStoreMaxAttempts; attempt++ {
+		res, errExec := s.Client.ExecContext(ctx, upsertQuery, key, raw, record.ExpireTime, time.Now().UTC())
+		if errExec != nil {
+			return IdempotencyRecord{}, false, errExec
+		}
+		if affected, errRows := res.RowsAffected(); errRows != nil {
+			return IdempotencyRecord{}, false, errRows
+		} else if affected > 0 {
+			return record, true, nil
+		}
+
+		var currentRaw []byte
+		errScan := s.Client.QueryRowContext(ctx, selectQuery, key).Scan(&currentRaw)
+		if errors.Is(errScan, sql.ErrNoRows) {
+			// released in between, try again
+			continue
+		} else if errScan != nil {
+			return IdempotencyRecord{}, false, errScan
+		}
+		current := IdempotencyRecord{}
+		if err = json.Unmarshal(currentRaw, &current); err != nil {
+			return IdempotencyRecord{}, false, err
+		}
+		return current, false, nil
+	}
+	return IdempotencyRecord{}, false, ErrIdempotencyKeyContended
+}
+
+func (s IdempotencyStorePostgres) Complete(ctx context.Context, key string, record IdempotencyRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("UPDATE %s SET record = $2, expire_time = $3 WHERE idempotency_key = $1",
+		s.Config.TableName)
+	_, err = s.Client.ExecContext(ctx, query, key, raw, record.ExpireTime)
+	return err
+}
+
+func (s IdempotencyStorePostgres) Release(ctx context.Context, key string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE idempotency_key = $1", s.Config.TableName)
+	_, err := s.Client.ExecContext(ctx, query, key)
+	return err
+}
@@ -0,0 +1,429 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/observability/tracing"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// wrappedServerStreamGRPC overrides grpc.ServerStream context, allowing stream interceptors to pass
+// values (e.g. trace identifiers, principals) down the chain.
+type wrappedServerStreamGRPC struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w wrappedServerStreamGRPC) Context() context.Context {
+	return w.ctx
+}
+
+func newWrappedServerStreamGRPC(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
+	return wrappedServerStreamGRPC{
+		ServerStream: stream,
+		ctx:          ctx,
+	}
+}
+
+// General-purposed interceptors
+
+type DefaultInterceptorGRPCParams struct {
+	fx.In
+
+	Config ConfigGRPC
+	Logger logging.Logger
+}
+
+// NewDefaultUnaryInterceptorGroupGRPC allocates a gRPC unary interceptor group. An array is returned to guarantee
+// ordering within this group.
+func NewDefaultUnaryInterceptorGroupGRPC(params DefaultInterceptorGRPCParams,
+	paramsTrace TraceIDEchoParams) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		NewTracerUnaryGRPC(paramsTrace),
+		NewLogRequestUnaryGRPC(params.Config, params.Logger),
+		NewErrorUnaryGRPC(),
+		NewRecoverRequestUnaryGRPC(params.Logger),
+	}
+}
+
+// NewDefaultStreamInterceptorGroupGRPC allocates a gRPC stream interceptor group. An array is returned to
+// guarantee ordering within this group.
+func NewDefaultStreamInterceptorGroupGRPC(params DefaultInterceptorGRPCParams,
+	paramsTrace TraceIDEchoParams) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		NewTracerStreamGRPC(paramsTrace),
+		NewLogRequestStreamGRPC(params.Config, params.Logger),
+		NewErrorStreamGRPC(),
+		NewRecoverRequestStreamGRPC(params.Logger),
+	}
+}
+
+func newTracedContextGRPC(ctx context.Context, params TraceIDEchoParams) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return params.newTracedContext(ctx, metadataCarrierGRPC(md))
+}
+
+// newTraceHeaderGRPC allocates the response header echoing the trace identifier of ctx.
+func newTraceHeaderGRPC(ctx context.Context) metadata.MD {
+	span, err := tracing.GetSpanContextFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+	return metadata.Pairs(strings.ToLower(HeaderTraceID), span.TraceID)
+}
+
+// metadataCarrierGRPC adapts metadata.MD to tracing.Carrier.
+type metadataCarrierGRPC metadata.MD
+
+var _ tracing.Carrier = metadataCarrierGRPC{}
+
+func (m metadataCarrierGRPC) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (m metadataCarrierGRPC) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+// NewTracerUnaryGRPC appends a span to each call using tracing.NewTracedContext. Incoming trace context
+// (e.g. W3C traceparent metadata) is continued, while the trace identifier is echoed through x-trace-id header.
+func NewTracerUnaryGRPC(params TraceIDEchoParams) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = newTracedContextGRPC(ctx, params)
+		if header := newTraceHeaderGRPC(ctx); header != nil {
+			_ = grpc.SetHeader(ctx, header)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewTracerStreamGRPC appends a span to each stream using tracing.NewTracedContext. Incoming trace context
+// (e.g. W3C traceparent metadata) is continued, while the trace identifier is echoed through x-trace-id header.
+func NewTracerStreamGRPC(params TraceIDEchoParams) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := newTracedContextGRPC(stream.Context(), params)
+		if header := newTraceHeaderGRPC(ctx); header != nil {
+			_ = stream.SetHeader(header)
+		}
+		return handler(srv, newWrappedServerStreamGRPC(ctx, stream))
+	}
+}
+
+// NewErrorUnaryGRPC converts errors returned by handlers into gRPC status errors (see statusCodeGRPCMap).
+func NewErrorUnaryGRPC() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		res, err := handler(ctx, req)
+		return res, convertErrorGRPC(err)
+	}
+}
+
+// NewErrorStreamGRPC converts errors returned by stream handlers into gRPC status errors
+// (see statusCodeGRPCMap).
+func NewErrorStreamGRPC() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return convertErrorGRPC(handler(srv, stream))
+	}
+}
+
+func getRequestIDGRPC(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func logRequestGRPC(ctx context.Context, cfg ConfigGRPC, logger logging.Logger, method string, isStream bool,
+	startTime time.Time, err error) {
+	var logEvent logging.Event
+	if err != nil {
+		logEvent = logger.Error()
+	} else {
+		logEvent = logger.Info()
+	}
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	latency := time.Since(startTime)
+	logEvent.
+		WithField("request_id", getRequestIDGRPC(ctx, cfg.RequestIDMetadataKey)).
+		WithField("start_time", startTime).
+		WithField("remote_ip", remoteAddr).
+		WithField("method", method).
+		WithField("is_stream", isStream).
+		WithField("status", status.Code(err).String()).
+		WithField("error", err).
+		WithField("latency", latency).
+		WithField("latency_human", latency.String()).
+		WriteWithCtx(ctx, "got request")
+}
+
+func NewLogRequestUnaryGRPC(cfg ConfigGRPC, logger logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		startTime := time.Now()
+		if requestID := getRequestIDGRPC(ctx, cfg.RequestIDMetadataKey); requestID != "" {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(cfg.RequestIDMetadataKey, requestID))
+			ctx = NewContextWithRequestID(ctx, requestID)
+		}
+		res, err := handler(ctx, req)
+		logRequestGRPC(ctx, cfg, logger, info.FullMethod, false, startTime, err)
+		return res, err
+	}
+}
+
+func NewLogRequestStreamGRPC(cfg ConfigGRPC, logger logging.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		startTime := time.Now()
+		if requestID := getRequestIDGRPC(stream.Context(), cfg.RequestIDMetadataKey); requestID != "" {
+			_ = stream.SetHeader(metadata.Pairs(cfg.RequestIDMetadataKey, requestID))
+			stream = newWrappedServerStreamGRPC(NewContextWithRequestID(stream.Context(), requestID), stream)
+		}
+		err := handler(srv, stream)
+		logRequestGRPC(stream.Context(), cfg, logger, info.FullMethod, true, startTime, err)
+		return err
+	}
+}
+
+func recoverGRPC(ctx context.Context, logger logging.Logger, r any) error {
+	var err error
+	switch v := r.(type) {
+	case error:
+		err = v
+	default:
+		err = fmt.Errorf("%v", v)
+	}
+	logger.WithError(err).WithField("stack", debug.Stack()).WriteWithCtx(ctx, "recovered from panic")
+	return status.Error(codes.Internal, codes.Internal.String())
+}
+
+func NewRecoverRequestUnaryGRPC(logger logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverGRPC(ctx, logger, r)
+			}
+		}()
+		res, err = handler(ctx, req)
+		return
+	}
+}
+
+func NewRecoverRequestStreamGRPC(logger logging.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverGRPC(stream.Context(), logger, r)
+			}
+		}()
+		err = handler(srv, stream)
+		return
+	}
+}
+
+// Authentication interceptors
+
+type NewJWTAuthenticatorGRPCParams struct {
+	fx.In
+
+	Config           security.ConfigJWT
+	ServerConfig     ConfigGRPC
+	Logger           logging.Logger
+	PrincipalFactory security.PrincipalFactory[*jwt.Token]
+	KeyFunc          keyfunc.Keyfunc `optional:"true"`
+}
+
+// JWTAuthenticatorGRPC authenticates gRPC calls using bearer tokens from the `authorization` metadata key.
+// Injects a security.Principal into call contexts using security.PrincipalFactory.
+type JWTAuthenticatorGRPC struct {
+	config           security.ConfigJWT
+	serverConfig     ConfigGRPC
+	logger           logging.Logger
+	principalFactory security.PrincipalFactory[*jwt.Token]
+	keyFunc          jwt.Keyfunc
+}
+
+func NewJWTAuthenticatorGRPC(params NewJWTAuthenticatorGRPCParams) JWTAuthenticatorGRPC {
+	a := JWTAuthenticatorGRPC{
+		config:           params.Config,
+		serverConfig:     params.ServerConfig,
+		logger:           params.Logger,
+		principalFactory: params.PrincipalFactory,
+	}
+	if params.KeyFunc != nil {
+		a.keyFunc = params.KeyFunc.Keyfunc
+	} else {
+		a.keyFunc = a.defaultKeyFunc
+	}
+	return a
+}
+
+// defaultKeyFunc resolves signing keys from security.ConfigJWT, same as echojwt does for HTTP servers.
+func (a JWTAuthenticatorGRPC) defaultKeyFunc(token *jwt.Token) (any, error) {
+	if token.Method.Alg() != a.config.SigningMethod {
+		return nil, fmt.Errorf("unexpected jwt signing method=%v", token.Header["alg"])
+	}
+	if len(a.config.SigningKeys) == 0 {
+		return []byte(a.config.SigningKey), nil
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, errors.New("unexpected jwt key id")
+	}
+	key, ok := a.config.SigningKeys[kid]
+	if !ok {
+		return nil, errors.New("unexpected jwt key id")
+	}
+	return []byte(key), nil
+}
+
+func (a JWTAuthenticatorGRPC) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if a.serverConfig.AuthenticationWhitelistMatcher.Match("", method) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		a.logger.WithError(errors.New("transport: missing authorization metadata")).
+			WriteWithCtx(ctx, "got jwt error")
+		return nil, systemerror.NewUnauthenticated()
+	}
+	rawToken, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		a.logger.WithError(errors.New("transport: malformed authorization metadata")).
+			WriteWithCtx(ctx, "got jwt error")
+		return nil, systemerror.NewUnauthenticated()
+	}
+
+	token, err := jwt.Parse(rawToken, a.keyFunc)
+	if err != nil {
+		a.logger.WithError(err).WriteWithCtx(ctx, "got jwt error")
+		return nil, systemerror.NewUnauthenticated()
+	}
+	ctxPrincipal, err := a.principalFactory.NewContextWithPrincipal(ctx, token)
+	if err != nil {
+		a.logger.WithError(err).WriteWithCtx(ctx, "could not create principal context")
+		return nil, systemerror.NewUnauthenticated()
+	}
+	return security.NewContextWithToken(ctxPrincipal, rawToken), nil
+}
+
+func (a JWTAuthenticatorGRPC) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctxAuth, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctxAuth, req)
+	}
+}
+
+func (a JWTAuthenticatorGRPC) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctxAuth, err := a.authenticate(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, newWrappedServerStreamGRPC(ctxAuth, stream))
+	}
+}
+
+func NewJWTAuthenticatorUnaryGRPC(authenticator JWTAuthenticatorGRPC) grpc.UnaryServerInterceptor {
+	return authenticator.Unary()
+}
+
+func NewJWTAuthenticatorStreamGRPC(authenticator JWTAuthenticatorGRPC) grpc.StreamServerInterceptor {
+	return authenticator.Stream()
+}
+
+// Persistence interceptors
+
+func closeTransactionGRPC(ctx context.Context, r any) error {
+	switch v := r.(type) {
+	case error:
+		return persistence.CloseTransaction(ctx, v)
+	case string:
+		return persistence.CloseTransaction(ctx, errors.New(v))
+	default:
+		return persistence.CloseTransaction(ctx, fmt.Errorf("%v", v))
+	}
+}
+
+// WithPersistentTransactionUnaryGRPC scopes unary calls within a persistence transaction. If methods
+// are specified (full method names), only those calls will be scoped.
+func WithPersistentTransactionUnaryGRPC(txFactory persistence.TransactionContextFactory,
+	methods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+		if len(methods) > 0 && !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		var ctxTx context.Context
+		ctxTx, err = txFactory.NewContext(ctx)
+		if err != nil {
+			return
+		}
+		defer func() {
+			// same as WithPersistentTransaction, recovering here is required for transaction rollbacks.
+			if r := recover(); r != nil {
+				err = closeTransactionGRPC(ctxTx, r)
+				panic(r) // re-throw, not swallowing to propagate error to other interceptors.
+			}
+			err = persistence.CloseTransaction(ctxTx, err)
+		}()
+		res, err = handler(ctxTx, req)
+		return
+	}
+}
+
+// WithPersistentTransactionStreamGRPC scopes streams within a persistence transaction. If methods
+// are specified (full method names), only those streams will be scoped.
+func WithPersistentTransactionStreamGRPC(txFactory persistence.TransactionContextFactory,
+	methods ...string) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		if len(methods) > 0 && !slices.Contains(methods, info.FullMethod) {
+			return handler(srv, stream)
+		}
+		var ctxTx context.Context
+		ctxTx, err = txFactory.NewContext(stream.Context())
+		if err != nil {
+			return
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				err = closeTransactionGRPC(ctxTx, r)
+				panic(r)
+			}
+			err = persistence.CloseTransaction(ctxTx, err)
+		}()
+		err = handler(srv, newWrappedServerStreamGRPC(ctxTx, stream))
+		return
+	}
+}
@@ -0,0 +1,215 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/observability/tracing"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestNewErrorUnaryGRPC(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantReasons []string
+	}{
+		{
+			name:     "nil",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("some error"),
+			wantCode: codes.Internal,
+		},
+		{
+			name:     "status error",
+			err:      status.Error(codes.Unavailable, "unavailable"),
+			wantCode: codes.Unavailable,
+		},
+		{
+			name:        "system error",
+			err:         systemerror.NewResourceNotFound[string]("123"),
+			wantCode:    codes.NotFound,
+			wantReasons: []string{"RESOURCE_NOT_FOUND"},
+		},
+		{
+			name: "container",
+			err: errors.Join(
+				systemerror.NewMissingArgument("name"),
+				systemerror.NewUnauthenticated(),
+			),
+			wantCode:    codes.Unauthenticated,
+			wantReasons: []string{"MISSING_ARGUMENT", "PRINCIPAL_UNAUTHENTICATED"},
+		},
+	}
+
+	interceptor := transport.NewErrorUnaryGRPC()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{},
+				func(_ context.Context, _ any) (any, error) {
+					return nil, tt.err
+				})
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantCode, st.Code())
+
+			reasons := make([]string, 0, len(st.Details()))
+			for _, detail := range st.Details() {
+				info, isInfo := detail.(*errdetails.ErrorInfo)
+				require.True(t, isInfo)
+				reasons = append(reasons, info.GetReason())
+			}
+			if len(tt.wantReasons) == 0 {
+				assert.Empty(t, reasons)
+				return
+			}
+			assert.Equal(t, tt.wantReasons, reasons)
+		})
+	}
+}
+
+type stubServerStreamGRPC struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s stubServerStreamGRPC) Context() context.Context {
+	return s.ctx
+}
+
+func (s stubServerStreamGRPC) SetHeader(_ metadata.MD) error {
+	return nil
+}
+
+func TestNewTracerUnaryGRPC(t *testing.T) {
+	interceptor := transport.NewTracerUnaryGRPC(transport.TraceIDEchoParams{})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tracing.HeaderTraceParent,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	var got tracing.SpanContext
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+		var err error
+		got, err = tracing.GetSpanContextFromContext(ctx)
+		return nil, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", got.ParentSpanID)
+	assert.True(t, got.Sampled)
+
+	stream := transport.NewTracerStreamGRPC(transport.TraceIDEchoParams{})
+	err = stream(nil, stubServerStreamGRPC{ctx: context.Background()}, &grpc.StreamServerInfo{},
+		func(_ any, stream grpc.ServerStream) error {
+			var err error
+			got, err = tracing.GetSpanContextFromContext(stream.Context())
+			return err
+		})
+	require.NoError(t, err)
+	assert.True(t, got.IsValid())
+	assert.Empty(t, got.ParentSpanID)
+}
+
+type stubPrincipalFactory struct{}
+
+func (s stubPrincipalFactory) NewContextWithPrincipal(parent context.Context, token *jwt.Token) (context.Context, error) {
+	sub, err := token.Claims.GetSubject()
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(parent, security.PrincipalContextKey, security.PrincipalTemplate{Identifier: sub}), nil
+}
+
+func TestJWTAuthenticatorGRPC(t *testing.T) {
+	cfg, err := transport.NewConfigGRPC()
+	require.NoError(t, err)
+	authenticator := transport.NewJWTAuthenticatorGRPC(transport.NewJWTAuthenticatorGRPCParams{
+		Config:           security.ConfigJWT{SigningMethod: "HS256", SigningKey: "secret"},
+		ServerConfig:     cfg,
+		Logger:           logging.NewZerologLoggerAdapter(zerolog.Nop()),
+		PrincipalFactory: stubPrincipalFactory{},
+	})
+	newToken := func(key string) string {
+		token, errSign := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1"}).
+			SignedString([]byte(key))
+		require.NoError(t, errSign)
+		return token
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantPrincipal string
+		wantErr       bool
+	}{
+		{name: "whitelisted", method: "/grpc.health.v1.Health/Check"},
+		{name: "missing", method: "/tasks.v1.TaskService/GetTask", wantErr: true},
+		{name: "not bearer", method: "/tasks.v1.TaskService/GetTask", authorization: newToken("secret"),
+			wantErr: true},
+		{name: "invalid signature", method: "/tasks.v1.TaskService/GetTask",
+			authorization: "Bearer " + newToken("other"), wantErr: true},
+		{name: "valid", method: "/tasks.v1.TaskService/GetTask", authorization: "Bearer " + newToken("secret"),
+			wantPrincipal: "user-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.authorization))
+			}
+			var gotPrincipal string
+			captureUnary := func(ctx context.Context, _ any) (any, error) {
+				if principal, errPrincipal := security.GetPrincipalFromContext(ctx); errPrincipal == nil {
+					gotPrincipal = principal.ID()
+				}
+				return nil, nil
+			}
+			_, err := authenticator.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, captureUnary)
+			errStream := authenticator.Stream()(nil, stubServerStreamGRPC{ctx: ctx},
+				&grpc.StreamServerInfo{FullMethod: tt.method}, func(_ any, stream grpc.ServerStream) error {
+					_, errCapture := captureUnary(stream.Context(), nil)
+					return errCapture
+				})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, systemerror.ErrUnauthenticated)
+				assert.ErrorIs(t, errStream, systemerror.ErrUnauthenticated)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, errStream)
+			assert.Equal(t, tt.wantPrincipal, gotPrincipal)
+		})
+	}
+}
+
+func TestNewRecoverRequestGRPC(t *testing.T) {
+	logger := logging.NewZerologLoggerAdapter(zerolog.Nop())
+	_, err := transport.NewRecoverRequestUnaryGRPC(logger)(context.Background(), nil, &grpc.UnaryServerInfo{},
+		func(_ context.Context, _ any) (any, error) {
+			panic("boom")
+		})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	err = transport.NewRecoverRequestStreamGRPC(logger)(nil, stubServerStreamGRPC{ctx: context.Background()},
+		&grpc.StreamServerInfo{}, func(_ any, _ grpc.ServerStream) error {
+			panic(errors.New("boom"))
+		})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
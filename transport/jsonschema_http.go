@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/application"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/validation"
+)
+
+const productionEnvironment = "production"
+
+// JSONSchemaRouteHTTP JSON Schema documents of a route. Nil schemas are skipped.
+type JSONSchemaRouteHTTP struct {
+	// Request validates request bodies.
+	Request *validation.JSONSchema
+	// Response validates response bodies (including the Data envelope) of successful JSON responses.
+	Response *validation.JSONSchema
+}
+
+// JSONSchemaHTTP holds JSON Schema documents registered per route. Use NewJSONSchemaEcho to validate requests (and
+// responses) of registered routes.
+type JSONSchemaHTTP struct {
+	validateResponses bool
+	logger            logging.Logger
+
+	mu     *sync.RWMutex
+	routes map[string]JSONSchemaRouteHTTP
+}
+
+type NewJSONSchemaHTTPParams struct {
+	fx.In
+
+	Config    ConfigJSONSchemaHTTP
+	AppConfig application.Config
+	Logger    logging.Logger
+}
+
+// NewJSONSchemaHTTP allocates a new JSONSchemaHTTP instance.
+func NewJSONSchemaHTTP(params NewJSONSchemaHTTPParams) JSONSchemaHTTP {
+	return JSONSchemaHTTP{
+		validateResponses: params.Config.ValidateResponses &&
+			!strings.EqualFold(params.AppConfig.Environment, productionEnvironment),
+		logger: params.Logger,
+		mu:     &sync.RWMutex{},
+		routes: make(map[string]JSONSchemaRouteHTTP),
+	}
+}
+
+// Register registers schemas of the route with the given method and path, as declared in echo (e.g. /tasks/:id).
+func (j JSONSchemaHTTP) Register(method, path string, schemas JSONSchemaRouteHTTP) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.routes[method+" "+path] = schemas
+}
+
+func (j JSONSchemaHTTP) lookup(method, path string) (JSONSchemaRouteHTTP, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	schemas, ok := j.routes[method+" "+path]
+	return schemas, ok
+}
+
+// NewJSONSchemaEcho allocates a middleware validating bodies of routes registered in schemas.
+//
+// Invalid requests are rejected with the same systemerror invalid argument and out of range errors struct
+// validation produces, naming fields after their JSON pointer (e.g. /metadata/name). Invalid responses are
+// logged and replaced with an internal error, so contract drifts are caught before reaching production.
+func NewJSONSchemaEcho(schemas JSONSchemaHTTP) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route, ok := schemas.lookup(c.Request().Method, c.Path())
+			if !ok {
+				return next(c)
+			}
+			if route.Request != nil {
+				req := c.Request()
+				body, err := io.ReadAll(req.Body)
+				if err != nil {
+					return err
+				}
+				req.Body = io.NopCloser(bytes.NewReader(body))
+				if err = route.Request.ValidateJSON(body); err != nil {
+					return err
+				}
+			}
+			if route.Response == nil || !schemas.validateResponses {
+				return next(c)
+			}
+			return schemas.validateResponse(c, next, route.Response)
+		}
+	}
+}
+
+func (j JSONSchemaHTTP) validateResponse(c echo.Context, next echo.HandlerFunc,
+	schema *validation.JSONSchema) error {
+	res := c.Response()
+	writer := &bufferedResponseWriter{ResponseWriter: res.Writer}
+	res.Writer = writer
+	err := next(c)
+	res.Writer = writer.ResponseWriter
+	if !res.Committed {
+		return err
+	}
+
+	status := res.Status
+	if err == nil && status >= http.StatusOK && status < http.StatusMultipleChoices &&
+		strings.HasPrefix(res.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		if errValidate := schema.ValidateJSON(writer.Bytes()); errValidate != nil {
+			j.logger.WithError(errValidate).
+				WithField("method", c.Request().Method).
+				WithField("path", c.Path()).
+				WriteWithCtx(c.Request().Context(), "response does not match json schema")
+			uncommitResponseEcho(res)
+			// not wrapped, validation errors would be reported as client errors otherwise
+			return fmt.Errorf("transport: response of %s %s does not match json schema", c.Request().Method,
+				c.Path())
+		}
+	}
+	return errors.Join(err, writer.replay(res, status))
+}
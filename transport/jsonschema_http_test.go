@@ -0,0 +1,76 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hadroncorp/geck/application"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/transport"
+	"github.com/hadroncorp/geck/validation"
+)
+
+func TestNewJSONSchemaEcho(t *testing.T) {
+	newEcho := func(environment string) *echo.Echo {
+		schemas := transport.NewJSONSchemaHTTP(transport.NewJSONSchemaHTTPParams{
+			Config:    transport.ConfigJSONSchemaHTTP{ValidateResponses: true},
+			AppConfig: application.Config{Environment: environment},
+			Logger:    logging.NewZerologLoggerAdapter(zerolog.Nop()),
+		})
+		schemas.Register(http.MethodPost, "/tasks/:id/metadata", transport.JSONSchemaRouteHTTP{
+			Request: validation.MustJSONSchema([]byte(`{"type":"object","required":["owner"]}`)),
+			Response: validation.MustJSONSchema([]byte(`{
+				"properties": {"data": {"type": "object", "required": ["id"]}}
+			}`)),
+		})
+		e := echo.New()
+		e.HTTPErrorHandler = transport.HandleEchoError
+		e.Use(transport.NewJSONSchemaEcho(schemas))
+		e.POST("/tasks/:id/metadata", func(c echo.Context) error {
+			body := map[string]any{}
+			if err := c.Bind(&body); err != nil {
+				return err
+			}
+			if c.Param("id") == "drift" {
+				return c.JSON(http.StatusOK, transport.Data{Data: map[string]any{}})
+			}
+			return c.JSON(http.StatusOK, transport.Data{Data: map[string]any{"id": c.Param("id"), "owner": body["owner"]}})
+		})
+		return e
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		path        string
+		body        string
+		expStatus   int
+		expBody     string
+	}{
+		{name: "valid", path: "/tasks/1/metadata", body: `{"owner":"jane"}`, expStatus: http.StatusOK,
+			expBody: `"owner":"jane"`},
+		{name: "invalid request", path: "/tasks/1/metadata", body: `{"name":"jane"}`,
+			expStatus: http.StatusBadRequest, expBody: "/owner"},
+		{name: "malformed request", path: "/tasks/1/metadata", body: `{`, expStatus: http.StatusBadRequest},
+		{name: "invalid response", environment: "local", path: "/tasks/drift/metadata", body: `{"owner":"jane"}`,
+			expStatus: http.StatusInternalServerError},
+		{name: "invalid response in production", environment: "production", path: "/tasks/drift/metadata",
+			body: `{"owner":"jane"}`, expStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			newEcho(tt.environment).ServeHTTP(rec, req)
+			assert.Equal(t, tt.expStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.expBody)
+		})
+	}
+}
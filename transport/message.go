@@ -22,3 +22,17 @@ type Errors struct {
 	Code   int     `json:"code"`
 	Errors []Error `json:"errors"`
 }
+
+// Problem is a RFC 9457 problem details structure. Reason, Metadata and Errors are extension members.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Errors holds every error when more than one was produced. Top-level members then describe the most
+	// severe status.
+	Errors []Problem `json:"errors,omitempty"`
+}
@@ -37,19 +37,37 @@ func HandleEchoError(srcErr error, c echo.Context) {
 type TraceIDEchoParams struct {
 	fx.In
 	TraceFactory tracing.TraceFactory `optional:"true"`
+	// Propagator trace context format of incoming requests. Defaults to tracing.PropagatorW3C.
+	Propagator tracing.Propagator `optional:"true"`
 }
 
-// NewTracerEcho appends a trace identifier to each request using tracing.NewTracedContext.
+// newTracedContext appends a new span to ctx. The span is a child of the caller span extracted from carrier, if
+// any.
+func (p TraceIDEchoParams) newTracedContext(ctx context.Context, carrier tracing.Carrier) context.Context {
+	propagator := p.Propagator
+	if propagator == nil {
+		propagator = tracing.PropagatorW3C{}
+	}
+	if remote, ok := propagator.Extract(carrier); ok {
+		ctx = tracing.NewContextWithSpan(ctx, remote)
+	}
+	if p.TraceFactory != nil {
+		return p.TraceFactory.NewTracedContext(ctx)
+	}
+	return tracing.NewTracedContext(ctx)
+}
+
+// NewTracerEcho appends a span to each request using tracing.NewTracedContext. Incoming trace context
+// (e.g. W3C traceparent header) is continued, while the trace identifier is echoed through X-Trace-ID response
+// header.
 func NewTracerEcho(params TraceIDEchoParams) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// injects principal in context.Context. Using echo's context won't suffice
 			// as GECK tracing package relies on Go's context.
-			var ctx context.Context
-			if params.TraceFactory != nil {
-				ctx = params.TraceFactory.NewTracedContext(c.Request().Context())
-			} else {
-				ctx = tracing.NewTracedContext(c.Request().Context())
+			ctx := params.newTracedContext(c.Request().Context(), c.Request().Header)
+			if span, err := tracing.GetSpanContextFromContext(ctx); err == nil {
+				c.Response().Header().Set(HeaderTraceID, span.TraceID)
 			}
 			req := c.Request().WithContext(ctx) // uses shallow copy, reducing extra malloc
 			c.SetRequest(req)
@@ -68,21 +86,49 @@ type DefaultEchoMiddlewareParams struct {
 // NewDefaultEchoMiddlewareGroup allocates an Echo middleware group. An array is returned to guarantee
 // ordering within this group. Any other middlewares outside this group will be injected into application in
 // a non-deterministic way (regarding ordering).
+//
+// Hardening middlewares (CORS, security headers, body limits and handler timeouts) are appended only if enabled
+// in ConfigHTTP.
 func NewDefaultEchoMiddlewareGroup(params DefaultEchoMiddlewareParams, paramsTrace TraceIDEchoParams) []echo.MiddlewareFunc {
-	return []echo.MiddlewareFunc{
+	middlewares := []echo.MiddlewareFunc{
 		NewTracerEcho(paramsTrace),
 		middleware.RequestIDWithConfig(middleware.RequestIDConfig{
 			TargetHeader: params.Config.RequestIDTargetHeader,
+			RequestIDHandler: func(c echo.Context, requestID string) {
+				c.SetRequest(c.Request().WithContext(NewContextWithRequestID(c.Request().Context(), requestID)))
+			},
 		}),
 		NewLogRequestEcho(params.Logger),
 		NewRecoverRequestEcho(params.Logger),
-		middleware.Gzip(),
 	}
+	if params.Config.CORS.Enabled {
+		middlewares = append(middlewares, NewCORSEcho(params.Config.CORS))
+	}
+	if params.Config.SecurityHeaders.Enabled {
+		middlewares = append(middlewares, NewSecurityHeadersEcho(params.Config.SecurityHeaders))
+	}
+	if params.Config.BodyLimit != "" || len(params.Config.BodyLimitRoutes) > 0 {
+		middlewares = append(middlewares, NewBodyLimitEcho(params.Config))
+	}
+	if params.Config.HandlerTimeout > 0 || len(params.Config.HandlerTimeoutRoutes) > 0 {
+		middlewares = append(middlewares, NewHandlerTimeoutEcho(params.Config))
+	}
+	return append(middlewares, middleware.GzipWithConfig(middleware.GzipConfig{
+		// compressed event streams get buffered by intermediaries, delaying events. Upgraded connections
+		// are not compressed by HTTP.
+		Skipper: func(c echo.Context) bool {
+			return acceptsEventStream(c.Request()) || c.IsWebSocket()
+		},
+	}))
 }
 
 func NewLogRequestEcho(logger logging.Logger) echo.MiddlewareFunc {
 	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			if IsStreamEcho(c) {
+				// streams are logged by their streamers when opened and closed
+				return nil
+			}
 			var logEvent logging.Event
 			if v.Error != nil {
 				logEvent = logger.Error()
@@ -154,8 +200,9 @@ type NewEchoJWTAuthenticatorConfigParams struct {
 func NewEchoJWTAuthenticatorConfig(params NewEchoJWTAuthenticatorConfigParams) echojwt.Config {
 	return echojwt.Config{
 		Skipper: func(c echo.Context) bool {
-			// TODO: Use prefix tree to search patterns efficiently, enable wildcard annotations
-			return params.ServerConfig.AuthenticationWhitelistSet.Contains(c.Path())
+			// matches the path echo routes on (raw path if escaped), otherwise, encoded slashes (e.g. /admin/x%2Fhealth)
+			// would match whitelisted patterns (e.g. /**/health)
+			return params.ServerConfig.AuthenticationWhitelistMatcher.Match(c.Request().Method, echo.GetPath(c.Request()))
 		},
 		SuccessHandler: func(c echo.Context) {
 			// injects principal in context.Context. Using echo's context won't suffice
@@ -173,6 +220,7 @@ func NewEchoJWTAuthenticatorConfig(params NewEchoJWTAuthenticatorConfigParams) e
 				params.Logger.WithError(err).WriteWithCtx(c.Request().Context(), "could not create principal context")
 				return
 			}
+			ctx = security.NewContextWithToken(ctx, token.Raw)
 			req := c.Request().WithContext(ctx) // uses shallow copy, reducing extra malloc
 			c.SetRequest(req)
 		},
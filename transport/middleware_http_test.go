@@ -0,0 +1,133 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/observability/tracing"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestNewTracerEcho(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(tracing.HeaderTraceParent)))
+	}))
+	defer downstream.Close()
+	client := newTestClientHTTP(0)
+
+	var got tracing.SpanContext
+	var gotOutbound string
+	e := echo.New()
+	e.Use(transport.NewTracerEcho(transport.TraceIDEchoParams{}))
+	e.GET("/tasks", func(c echo.Context) error {
+		var err error
+		got, err = tracing.GetSpanContextFromContext(c.Request().Context())
+		require.NoError(t, err)
+
+		req, err := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		buf := make([]byte, 128)
+		n, _ := res.Body.Read(buf)
+		gotOutbound = string(buf[:n])
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	t.Run("continues incoming trace", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set(tracing.HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		req.Header.Set(tracing.HeaderTraceState, "congo=t61rcWkgMzE")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got.TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", got.ParentSpanID)
+		assert.NotEqual(t, "00f067aa0ba902b7", got.SpanID)
+		assert.False(t, got.Sampled)
+		assert.Equal(t, "congo=t61rcWkgMzE", got.TraceState)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", rec.Header().Get(transport.HeaderTraceID))
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+got.SpanID+"-00", gotOutbound)
+	})
+
+	t.Run("starts new trace", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set(tracing.HeaderTraceParent, "invalid")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.True(t, got.IsValid())
+		assert.True(t, got.Sampled)
+		assert.Empty(t, got.ParentSpanID)
+		assert.Equal(t, got.TraceID, rec.Header().Get(transport.HeaderTraceID))
+		assert.Equal(t, "00-"+got.TraceID+"-"+got.SpanID+"-01", gotOutbound)
+	})
+}
+
+// keySetJWK aliases keyfunc.Keyfunc, so it can be embedded while overriding its Keyfunc method.
+type keySetJWK = keyfunc.Keyfunc
+
+type stubKeyfunc struct {
+	keySetJWK
+}
+
+func (s stubKeyfunc) Keyfunc(_ *jwt.Token) (any, error) {
+	return []byte("secret"), nil
+}
+
+func TestNewEchoJWTAuthenticatorConfig(t *testing.T) {
+	t.Setenv("HTTP_SERVER_AUTHN_WHITELIST", "/**/health")
+	cfg, err := transport.NewConfigHTTP()
+	require.NoError(t, err)
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	e.Use(transport.NewEchoJWTAuthenticator(transport.NewEchoJWTAuthenticatorConfig(
+		transport.NewEchoJWTAuthenticatorConfigParams{
+			Config:           security.ConfigJWT{SigningMethod: "HS256", SigningKey: "secret"},
+			ServerConfig:     cfg,
+			Logger:           logging.NewZerologLoggerAdapter(zerolog.Nop()),
+			PrincipalFactory: stubPrincipalFactory{},
+			KeyFunc:          stubKeyfunc{},
+		})))
+	e.GET("/admin/:resource", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1"}).
+		SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "whitelisted", target: "/admin/health", wantStatus: http.StatusNoContent},
+		{name: "encoded slash", target: "/admin/x%2Fhealth", wantStatus: http.StatusUnauthorized},
+		{name: "unauthenticated", target: "/admin/users", wantStatus: http.StatusUnauthorized},
+		{name: "authenticated", target: "/admin/x%2Fhealth", authorization: "Bearer " + token,
+			wantStatus: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package transport
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mediaRange is a single entry of an Accept header (RFC 9110 section 12.5.1).
+type mediaRange struct {
+	Type    string
+	Subtype string
+	Params  map[string]string
+	Quality float64
+}
+
+// matches indicates if mediaType (e.g. application/json) is accepted by the range.
+func (m mediaRange) matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (m.Type == "*" || strings.EqualFold(m.Type, typ)) &&
+		(m.Subtype == "*" || strings.EqualFold(m.Subtype, subtype))
+}
+
+// specificity ranks ranges so the most specific one sets the quality of a media type.
+func (m mediaRange) specificity() int {
+	switch {
+	case m.Type == "*":
+		return 0
+	case m.Subtype == "*":
+		return 1
+	default:
+		return 2 + len(m.Params)
+	}
+}
+
+// parseAcceptHeader parses an Accept header value, skipping malformed entries.
+func parseAcceptHeader(header string) []mediaRange {
+	if header == "" {
+		return nil
+	}
+	rawRanges := strings.Split(header, ",")
+	ranges := make([]mediaRange, 0, len(rawRanges))
+	for _, rawRange := range rawRanges {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(rawRange))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if rawQuality, hasQuality := params["q"]; hasQuality {
+			delete(params, "q")
+			if quality, err = strconv.ParseFloat(rawQuality, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{
+			Type:    typ,
+			Subtype: subtype,
+			Params:  params,
+			Quality: quality,
+		})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// negotiateMediaType picks the offer with the highest quality from the given Accept header. Ties are resolved
+// using offers order. Returns the first offer if header is empty and an empty string if no offer is acceptable.
+func negotiateMediaType(header string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	ranges := parseAcceptHeader(header)
+	if len(ranges) == 0 {
+		return offers[0]
+	}
+
+	bestOffer := ""
+	bestQuality := 0.0
+	for _, offer := range offers {
+		for _, r := range ranges {
+			if !r.matches(offer) {
+				continue
+			}
+			// ranges are sorted by specificity, first match sets the quality
+			if r.Quality > bestQuality {
+				bestOffer, bestQuality = offer, r.Quality
+			}
+			break
+		}
+	}
+	return bestOffer
+}
@@ -0,0 +1,352 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/application"
+	"github.com/hadroncorp/geck/observability/logging"
+)
+
+// OperationHTTP describes an HTTP operation (route) for OpenAPI document generation.
+type OperationHTTP struct {
+	// Method the HTTP method (e.g. POST).
+	Method string
+	// Path the route path using Echo nomenclature (e.g. /tasks/:task_id).
+	Path string
+	// Versioned indicates Path is relative to the versioned group (see VersionedControllerHTTP), or to the
+	// version prefix of DescribedMultiVersionControllerHTTP operations.
+	Versioned   bool
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Request a request value (e.g. task.CreateCommand{}). Fields with `param`, `query` and `header` tags are
+	// documented as parameters, the rest as the request body.
+	Request any
+	// Response a response value (e.g. Data{Data: task.View{}}). Interface fields are documented using their
+	// dynamic values. No content is documented if nil.
+	Response any
+	// StatusCode response status code. Defaults to http.StatusOK, or http.StatusNoContent if Response is nil.
+	StatusCode int
+	// Errors HTTP status codes of known error responses (e.g. http.StatusNotFound).
+	Errors []int
+}
+
+// DescribedControllerHTTP is a ControllerHTTP describing its operations for OpenAPI document generation.
+type DescribedControllerHTTP interface {
+	DescribeOperations() []OperationHTTP
+}
+
+// DescribedMultiVersionControllerHTTP is a MultiVersionControllerHTTP describing the operations of each API version
+// for OpenAPI document generation. Root operations are described through DescribedControllerHTTP.
+type DescribedMultiVersionControllerHTTP interface {
+	DescribeVersionOperations(version string) []OperationHTTP
+}
+
+// OpenAPISecuritySchemeHTTP a named security scheme applied to every operation not present in
+// ConfigHTTP.AuthenticationWhitelist.
+type OpenAPISecuritySchemeHTTP struct {
+	Name   string
+	Scheme OpenAPISecurityScheme
+}
+
+// NewOpenAPISecuritySchemeJWT allocates the security scheme used by JWT authenticator middlewares.
+func NewOpenAPISecuritySchemeJWT() OpenAPISecuritySchemeHTTP {
+	return OpenAPISecuritySchemeHTTP{
+		Name: "bearer_auth",
+		Scheme: OpenAPISecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+		},
+	}
+}
+
+// matches Echo path parameters (e.g. :task_id).
+var echoPathParamRegex = regexp.MustCompile(`:([^/]+)`)
+
+type NewOpenAPIDocumentParams struct {
+	fx.In
+
+	Config                  ConfigOpenAPI
+	ServerConfig            ConfigHTTP
+	AppConfig               application.Config
+	RootControllers         []ControllerHTTP             `group:"root_controllers_http"`
+	VersionedControllers    []VersionedControllerHTTP    `group:"versioned_controllers_http"`
+	MultiVersionControllers []MultiVersionControllerHTTP `group:"multiversion_controllers_http"`
+	SecuritySchemes         []OpenAPISecuritySchemeHTTP  `group:"openapi_security_schemes_http"`
+}
+
+// NewOpenAPIDocument generates an OpenAPI 3.1 document from registered controllers implementing
+// DescribedControllerHTTP.
+func NewOpenAPIDocument(params NewOpenAPIDocumentParams) (*OpenAPIDocument, error) {
+	title := params.Config.Title
+	if title == "" {
+		title = params.AppConfig.ApplicationName
+	}
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.1.0",
+		Info: OpenAPIInfo{
+			Title:       title,
+			Description: params.Config.Description,
+			Version:     params.AppConfig.Version,
+		},
+		Paths: make(map[string]OpenAPIPathItem),
+	}
+	for _, serverURL := range params.Config.ServerURLs {
+		doc.Servers = append(doc.Servers, OpenAPIServer{URL: serverURL})
+	}
+
+	securityReqs := make([]OpenAPISecurityRequirement, 0, len(params.SecuritySchemes))
+	if len(params.SecuritySchemes) > 0 {
+		doc.Components.SecuritySchemes = make(map[string]OpenAPISecurityScheme, len(params.SecuritySchemes))
+	}
+	for _, scheme := range params.SecuritySchemes {
+		doc.Components.SecuritySchemes[scheme.Name] = scheme.Scheme
+		securityReqs = append(securityReqs, OpenAPISecurityRequirement{scheme.Name: {}})
+	}
+
+	generator := newOpenAPISchemaGenerator()
+	errorsRef := generator.SchemaOf(Errors{})
+	problemRef := generator.SchemaOf(Problem{})
+	addOperations := func(basePath string, ops []OperationHTTP) error {
+		for _, op := range ops {
+			path := op.Path
+			if op.Versioned {
+				path = basePath + path
+			}
+			method := strings.ToLower(op.Method)
+			openAPIPath := echoPathParamRegex.ReplaceAllString(path, "{$1}")
+			pathItem, exists := doc.Paths[openAPIPath]
+			if !exists {
+				pathItem = make(OpenAPIPathItem)
+				doc.Paths[openAPIPath] = pathItem
+			}
+			if _, dup := pathItem[method]; dup {
+				return fmt.Errorf("transport: duplicated openapi operation %s %s", op.Method, path)
+			}
+
+			operation := newOpenAPIOperation(generator, op, path)
+			if !params.ServerConfig.AuthenticationWhitelistMatcher.Match(op.Method, path) && len(securityReqs) > 0 {
+				operation.Security = securityReqs
+				if !slices.Contains(op.Errors, http.StatusUnauthorized) {
+					op.Errors = append(slices.Clone(op.Errors), http.StatusUnauthorized)
+				}
+			}
+			operation.Responses = newOpenAPIResponses(generator, op, params.ServerConfig.ErrorFormat,
+				errorsRef, problemRef)
+			pathItem[method] = operation
+			for _, tag := range op.Tags {
+				if !slices.ContainsFunc(doc.Tags, func(t OpenAPITag) bool { return t.Name == tag }) {
+					doc.Tags = append(doc.Tags, OpenAPITag{Name: tag})
+				}
+			}
+		}
+		return nil
+	}
+
+	controllers := make([]any, 0, len(params.RootControllers)+len(params.VersionedControllers)+
+		len(params.MultiVersionControllers))
+	for _, controller := range params.RootControllers {
+		controllers = append(controllers, controller)
+	}
+	for _, controller := range params.VersionedControllers {
+		controllers = append(controllers, controller)
+	}
+	for _, controller := range params.MultiVersionControllers {
+		controllers = append(controllers, controller)
+	}
+	basePath := fmt.Sprintf("/%s", params.AppConfig.Semver.Major)
+	for _, controller := range controllers {
+		if described, ok := controller.(DescribedControllerHTTP); ok {
+			if err := addOperations(basePath, described.DescribeOperations()); err != nil {
+				return nil, err
+			}
+		}
+		multiVersion, isMultiVersion := controller.(MultiVersionControllerHTTP)
+		described, ok := controller.(DescribedMultiVersionControllerHTTP)
+		if !isMultiVersion || !ok {
+			continue
+		}
+		for _, version := range multiVersion.APIVersions() {
+			if err := addOperations("/"+version, described.DescribeVersionOperations(version)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+	doc.Components.Schemas = generator.schemas
+	return doc, nil
+}
+
+func newOpenAPIOperation(generator *openAPISchemaGenerator, op OperationHTTP, path string) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+	}
+	// path parameters are always documented, even if not declared in Request
+	declaredParams := make(map[string]struct{})
+	if op.Request != nil {
+		operation.Parameters, operation.RequestBody = newOpenAPIRequest(generator, op)
+		for _, param := range operation.Parameters {
+			if param.In == "path" {
+				declaredParams[param.Name] = struct{}{}
+			}
+		}
+	}
+	for _, match := range echoPathParamRegex.FindAllStringSubmatch(path, -1) {
+		if _, ok := declaredParams[match[1]]; ok {
+			continue
+		}
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		})
+	}
+	return operation
+}
+
+func newOpenAPIRequest(generator *openAPISchemaGenerator, op OperationHTTP) ([]OpenAPIParameter,
+	*OpenAPIRequestBody) {
+	val := reflect.ValueOf(op.Request)
+	typ := val.Type()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+		val = reflect.Value{}
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, newOpenAPIRequestBody(generator.SchemaOf(op.Request))
+	}
+
+	params := make([]OpenAPIParameter, 0)
+	hasBindingTags := false
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		for _, tag := range bindingTags {
+			name, ok := field.Tag.Lookup(tag)
+			if !ok {
+				continue
+			}
+			hasBindingTags = true
+			schema := generator.schemaOfValue(reflect.Value{}, field.Type)
+			required := applyValidationConstraints(schema, field.Type, field.Tag.Get("validate"))
+			in := tag
+			if tag == "param" {
+				in, required = "path", true
+			}
+			params = append(params, OpenAPIParameter{
+				Name:     name,
+				In:       in,
+				Required: required,
+				Schema:   schema,
+			})
+		}
+	}
+	// Echo binds bodies only for methods with semantic bodies
+	switch op.Method {
+	case http.MethodGet, http.MethodDelete, http.MethodHead:
+		return params, nil
+	}
+	if !hasBindingTags {
+		return params, newOpenAPIRequestBody(generator.SchemaOf(op.Request))
+	}
+	bodySchema := generator.newStructSchema(val, typ, bindingTags)
+	if len(bodySchema.Properties) == 0 {
+		return params, nil
+	}
+	return params, newOpenAPIRequestBody(bodySchema)
+}
+
+func newOpenAPIRequestBody(schema *OpenAPISchema) *OpenAPIRequestBody {
+	return &OpenAPIRequestBody{
+		Required: true,
+		Content: map[string]OpenAPIMediaType{
+			echo.MIMEApplicationJSON: {Schema: schema},
+		},
+	}
+}
+
+func newOpenAPIResponses(generator *openAPISchemaGenerator, op OperationHTTP, errFormat ErrorFormatHTTP,
+	errorsRef, problemRef *OpenAPISchema) map[string]OpenAPIResponse {
+	statusCode := op.StatusCode
+	if statusCode == 0 && op.Response == nil {
+		statusCode = http.StatusNoContent
+	} else if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	responses := make(map[string]OpenAPIResponse, len(op.Errors)+2)
+	response := OpenAPIResponse{
+		Description: http.StatusText(statusCode),
+	}
+	if op.Response != nil {
+		response.Content = map[string]OpenAPIMediaType{
+			echo.MIMEApplicationJSON: {Schema: generator.SchemaOf(op.Response)},
+		}
+	}
+	responses[strconv.Itoa(statusCode)] = response
+
+	errContent := make(map[string]OpenAPIMediaType, 2)
+	switch errFormat {
+	case ErrorFormatProblem:
+		errContent[MIMEApplicationProblemJSON] = OpenAPIMediaType{Schema: problemRef}
+	case ErrorFormatNegotiate:
+		errContent[echo.MIMEApplicationJSON] = OpenAPIMediaType{Schema: errorsRef}
+		errContent[MIMEApplicationProblemJSON] = OpenAPIMediaType{Schema: problemRef}
+	default:
+		errContent[echo.MIMEApplicationJSON] = OpenAPIMediaType{Schema: errorsRef}
+	}
+	for _, code := range op.Errors {
+		responses[strconv.Itoa(code)] = OpenAPIResponse{
+			Description: http.StatusText(code),
+			Content:     errContent,
+		}
+	}
+	responses["default"] = OpenAPIResponse{
+		Description: "Unexpected error",
+		Content:     errContent,
+	}
+	return responses
+}
+
+type RegisterOpenAPIControllerHTTPParams struct {
+	fx.In
+
+	Echo     *echo.Echo
+	Config   ConfigOpenAPI
+	Logger   logging.Logger
+	Document *OpenAPIDocument
+}
+
+// RegisterOpenAPIControllerHTTP serves the OpenAPI document at ConfigOpenAPI.Route.
+//
+// Not registered as ControllerHTTP as the document depends on every registered controller.
+func RegisterOpenAPIControllerHTTP(params RegisterOpenAPIControllerHTTPParams) {
+	params.Logger.Info().
+		WithField("route", params.Config.Route).
+		WithField("total_paths", len(params.Document.Paths)).
+		Write("registering openapi document route")
+	params.Echo.GET(params.Config.Route, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, params.Document)
+	})
+}
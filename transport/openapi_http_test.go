@@ -0,0 +1,191 @@
+package transport_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/application"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/transport"
+	"github.com/hadroncorp/geck/versioning"
+)
+
+type openAPICreateCommand struct {
+	Name   string   `json:"name" validate:"required,lte=96"`
+	Status string   `json:"status" validate:"omitempty,oneof=PENDING RUNNING"`
+	Labels []string `json:"labels" validate:"max=5,dive,min=1"`
+}
+
+type openAPIGetQuery struct {
+	TaskID string `param:"task_id"`
+	View   string `query:"view" validate:"omitempty,oneof=BASIC FULL"`
+}
+
+type openAPIView struct {
+	TaskID string `json:"task_id"`
+	Name   string `json:"name"`
+	persistence.AuditableView
+}
+
+type openAPIController struct{}
+
+var (
+	_ transport.VersionedControllerHTTP = openAPIController{}
+	_ transport.DescribedControllerHTTP = openAPIController{}
+)
+
+func (o openAPIController) SetRoutes(_ *echo.Echo) {}
+
+func (o openAPIController) SetVersionedRoutes(_ *echo.Group) {}
+
+func (o openAPIController) DescribeOperations() []transport.OperationHTTP {
+	return []transport.OperationHTTP{
+		{
+			Method:      http.MethodPost,
+			Path:        "/tasks",
+			Versioned:   true,
+			OperationID: "createTask",
+			Tags:        []string{"tasks"},
+			Request:     openAPICreateCommand{},
+			Response:    transport.Data{Data: openAPIView{}},
+			StatusCode:  http.StatusCreated,
+			Errors:      []int{http.StatusBadRequest},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/tasks/:task_id",
+			Versioned:   true,
+			OperationID: "getTask",
+			Tags:        []string{"tasks"},
+			Request:     openAPIGetQuery{},
+			Response:    transport.Data{Data: openAPIView{}},
+			Errors:      []int{http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/public/ping",
+		},
+	}
+}
+
+func TestNewOpenAPIDocument(t *testing.T) {
+	whitelist, err := transport.NewRouteMatcherSet("GET /public/**")
+	require.NoError(t, err)
+	doc, err := transport.NewOpenAPIDocument(transport.NewOpenAPIDocumentParams{
+		Config: transport.ConfigOpenAPI{},
+		ServerConfig: transport.ConfigHTTP{
+			AuthenticationWhitelistMatcher: whitelist,
+		},
+		AppConfig: application.Config{
+			ApplicationName: "tasks",
+			Version:         "v1.2.0",
+			Semver:          versioning.SemanticVersion{Major: "v1"},
+		},
+		VersionedControllers: []transport.VersionedControllerHTTP{openAPIController{}},
+		SecuritySchemes:      []transport.OpenAPISecuritySchemeHTTP{transport.NewOpenAPISecuritySchemeJWT()},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "tasks", doc.Info.Title)
+	require.Contains(t, doc.Paths, "/v1/tasks")
+	require.Contains(t, doc.Paths, "/v1/tasks/{task_id}")
+	require.Contains(t, doc.Paths, "/public/ping")
+
+	// create
+	create := doc.Paths["/v1/tasks"]["post"]
+	require.NotNil(t, create)
+	assert.Equal(t, []transport.OpenAPISecurityRequirement{{"bearer_auth": {}}}, create.Security)
+	assert.Contains(t, create.Responses, "201")
+	assert.Contains(t, create.Responses, "400")
+	assert.Contains(t, create.Responses, "401")
+	assert.Contains(t, create.Responses, "default")
+	require.NotNil(t, create.RequestBody)
+	cmdRef := create.RequestBody.Content[echo.MIMEApplicationJSON].Schema.Ref
+	assert.Equal(t, "#/components/schemas/transport_test.openAPICreateCommand", cmdRef)
+	cmdSchema := doc.Components.Schemas["transport_test.openAPICreateCommand"]
+	require.NotNil(t, cmdSchema)
+	assert.Equal(t, []string{"name"}, cmdSchema.Required)
+	assert.Equal(t, 96, *cmdSchema.Properties["name"].MaxLength)
+	assert.Equal(t, []any{"PENDING", "RUNNING"}, cmdSchema.Properties["status"].Enum)
+	assert.Equal(t, 5, *cmdSchema.Properties["labels"].MaxItems)
+	assert.Equal(t, 1, *cmdSchema.Properties["labels"].Items.MinLength)
+
+	// dynamic Data values are inlined, while views are referenced
+	resSchema := create.Responses["201"].Content[echo.MIMEApplicationJSON].Schema
+	assert.Equal(t, "object", resSchema.Type)
+	assert.Equal(t, "#/components/schemas/transport_test.openAPIView", resSchema.Properties["data"].Ref)
+	viewSchema := doc.Components.Schemas["transport_test.openAPIView"]
+	require.NotNil(t, viewSchema)
+	assert.Contains(t, viewSchema.Properties, "task_id")
+	assert.Contains(t, viewSchema.Properties, "create_time") // embedded struct
+
+	// get
+	get := doc.Paths["/v1/tasks/{task_id}"]["get"]
+	require.NotNil(t, get)
+	assert.Nil(t, get.RequestBody)
+	require.Len(t, get.Parameters, 2)
+	assert.Equal(t, transport.OpenAPIParameter{
+		Name: "task_id", In: "path", Required: true, Schema: &transport.OpenAPISchema{Type: "string"},
+	}, get.Parameters[0])
+	assert.Equal(t, "query", get.Parameters[1].In)
+	assert.Equal(t, []any{"BASIC", "FULL"}, get.Parameters[1].Schema.Enum)
+
+	// public
+	ping := doc.Paths["/public/ping"]["get"]
+	require.NotNil(t, ping)
+	assert.Empty(t, ping.Security)
+	assert.Contains(t, ping.Responses, "204")
+	assert.Contains(t, doc.Components.Schemas, "transport.Errors")
+}
+
+type openAPIControllerMultiVersion struct{}
+
+var (
+	_ transport.MultiVersionControllerHTTP          = openAPIControllerMultiVersion{}
+	_ transport.DescribedMultiVersionControllerHTTP = openAPIControllerMultiVersion{}
+)
+
+func (o openAPIControllerMultiVersion) SetRoutes(_ *echo.Echo) {}
+
+func (o openAPIControllerMultiVersion) APIVersions() []string {
+	return []string{"v1", "v2"}
+}
+
+func (o openAPIControllerMultiVersion) SetVersionRoutes(_ string, _ *echo.Group) {}
+
+func (o openAPIControllerMultiVersion) DescribeVersionOperations(version string) []transport.OperationHTTP {
+	return []transport.OperationHTTP{
+		{
+			Method:      http.MethodGet,
+			Path:        "/tasks/:task_id",
+			Versioned:   true,
+			OperationID: "getTask" + version,
+			Deprecated:  version == "v1",
+			Response:    transport.Data{Data: openAPIView{}},
+		},
+	}
+}
+
+func TestNewOpenAPIDocument_MultiVersion(t *testing.T) {
+	doc, err := transport.NewOpenAPIDocument(transport.NewOpenAPIDocumentParams{
+		ServerConfig: transport.ConfigHTTP{
+			AuthenticationWhitelistMatcher: transport.NewRouteMatcher[struct{}](),
+		},
+		AppConfig: application.Config{
+			ApplicationName: "tasks",
+			Semver:          versioning.SemanticVersion{Major: "v2"},
+		},
+		MultiVersionControllers: []transport.MultiVersionControllerHTTP{openAPIControllerMultiVersion{}},
+	})
+	require.NoError(t, err)
+	require.Contains(t, doc.Paths, "/v1/tasks/{task_id}")
+	require.Contains(t, doc.Paths, "/v2/tasks/{task_id}")
+	assert.Equal(t, "getTaskv1", doc.Paths["/v1/tasks/{task_id}"]["get"].OperationID)
+	assert.True(t, doc.Paths["/v1/tasks/{task_id}"]["get"].Deprecated)
+	assert.Equal(t, "getTaskv2", doc.Paths["/v2/tasks/{task_id}"]["get"].OperationID)
+	assert.False(t, doc.Paths["/v2/tasks/{task_id}"]["get"].Deprecated)
+}
@@ -0,0 +1,377 @@
+package transport
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	typeTime          = reflect.TypeOf(time.Time{})
+	typeDuration      = reflect.TypeOf(time.Duration(0))
+	typeRawMessage    = reflect.TypeOf(json.RawMessage{})
+	typeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	typeJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// matches import paths within generic type names (e.g. data.Page[github.com/foo/task.View]).
+	openAPITypePathRegex = regexp.MustCompile(`[^\[\],]*/`)
+)
+
+// bindingTags echo binding struct tags. Fields using them are not part of request bodies.
+var bindingTags = []string{"param", "query", "header"}
+
+// openAPISchemaGenerator converts Go types into OpenAPISchema(s) following encoding/json semantics.
+// Named structs are registered as components and referenced, unless they hold non-nil interface values (e.g. Data),
+// which are inlined as each value might produce a different schema.
+//
+// Constraints are derived from go-playground validation tags (validate struct tag).
+type openAPISchemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+func newOpenAPISchemaGenerator() *openAPISchemaGenerator {
+	return &openAPISchemaGenerator{
+		schemas: make(map[string]*OpenAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// newOpenAPISchemaName returns a components-compatible name for typ (e.g. data.Page[task.View] -> data.Page_task.View).
+func newOpenAPISchemaName(typ reflect.Type) string {
+	name := openAPITypePathRegex.ReplaceAllString(typ.String(), "")
+	return strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "", " ", "").Replace(name)
+}
+
+// SchemaOf generates a schema from v. Dynamic values of interfaces are used if not nil.
+func (g *openAPISchemaGenerator) SchemaOf(v any) *OpenAPISchema {
+	if v == nil {
+		return &OpenAPISchema{}
+	}
+	return g.schemaOfValue(reflect.ValueOf(v), reflect.TypeOf(v))
+}
+
+func (g *openAPISchemaGenerator) schemaOfValue(val reflect.Value, typ reflect.Type) *OpenAPISchema {
+	if typ.Kind() == reflect.Interface {
+		if val.IsValid() && !val.IsNil() {
+			return g.schemaOfValue(val.Elem(), val.Elem().Type())
+		}
+		return &OpenAPISchema{}
+	}
+	if typ.Kind() == reflect.Pointer {
+		var elemVal reflect.Value
+		if val.IsValid() && !val.IsNil() {
+			elemVal = val.Elem()
+		}
+		return g.schemaOfValue(elemVal, typ.Elem())
+	}
+
+	switch {
+	case typ == typeTime:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case typ == typeDuration:
+		return &OpenAPISchema{Type: "integer", Format: "int64", Description: "duration in nanoseconds"}
+	case typ == typeRawMessage:
+		return &OpenAPISchema{}
+	case typ.Implements(typeJSONMarshaler) || reflect.PointerTo(typ).Implements(typeJSONMarshaler):
+		// custom encoding, type is unknown
+		return &OpenAPISchema{}
+	case typ.Implements(typeTextMarshaler) || reflect.PointerTo(typ).Implements(typeTextMarshaler):
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		var elemVal reflect.Value
+		if val.IsValid() && val.Len() > 0 {
+			elemVal = val.Index(0)
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schemaOfValue(elemVal, typ.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schemaOfValue(reflect.Value{}, typ.Elem())}
+	case reflect.Struct:
+		return g.schemaOfStruct(val, typ)
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+func (g *openAPISchemaGenerator) schemaOfStruct(val reflect.Value, typ reflect.Type) *OpenAPISchema {
+	isDynamic := hasDynamicValues(val, typ)
+	if typ.Name() == "" || isDynamic {
+		return g.newStructSchema(val, typ, nil)
+	}
+
+	if name, ok := g.names[typ]; ok {
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	name := newOpenAPISchemaName(typ)
+	g.names[typ] = name
+	// register before walking fields to support recursive types
+	g.schemas[name] = &OpenAPISchema{}
+	*g.schemas[name] = *g.newStructSchema(val, typ, nil)
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+// newStructSchema generates an inlined object schema. Fields using any of the excludedTags are skipped.
+func (g *openAPISchemaGenerator) newStructSchema(val reflect.Value, typ reflect.Type,
+	excludedTags []string) *OpenAPISchema {
+	schema := &OpenAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*OpenAPISchema),
+	}
+	g.appendStructFields(schema, val, typ, excludedTags)
+	return schema
+}
+
+func (g *openAPISchemaGenerator) appendStructFields(schema *OpenAPISchema, val reflect.Value, typ reflect.Type,
+	excludedTags []string) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if hasAnyTag(field, excludedTags) {
+			continue
+		}
+		name, opts, hasName := parseJSONTag(field)
+		if name == "-" && opts == "" {
+			continue
+		}
+		var fieldVal reflect.Value
+		if val.IsValid() {
+			fieldVal = val.Field(i)
+		}
+
+		fieldType := field.Type
+		if field.Anonymous && !hasName {
+			// embedded structs are flattened, same as encoding/json
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+				if fieldVal.IsValid() && !fieldVal.IsNil() {
+					fieldVal = fieldVal.Elem()
+				} else {
+					fieldVal = reflect.Value{}
+				}
+			}
+			if fieldType.Kind() == reflect.Struct {
+				g.appendStructFields(schema, fieldVal, fieldType, excludedTags)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if !hasName {
+			name = field.Name
+		}
+
+		fieldSchema := g.schemaOfValue(fieldVal, fieldType)
+		required := applyValidationConstraints(fieldSchema, fieldType, field.Tag.Get("validate"))
+		if _, hasValidation := field.Tag.Lookup("validate"); !hasValidation {
+			required = !strings.Contains(opts, "omitempty") && fieldType.Kind() != reflect.Pointer
+		}
+		schema.Properties[name] = fieldSchema
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// hasDynamicValues indicates if a struct holds non-nil interface values, including its embedded structs.
+func hasDynamicValues(val reflect.Value, typ reflect.Type) bool {
+	if !val.IsValid() {
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+		switch field.Type.Kind() {
+		case reflect.Interface:
+			if !fieldVal.IsNil() {
+				return true
+			}
+		case reflect.Struct:
+			if field.Anonymous && hasDynamicValues(fieldVal, field.Type) {
+				return true
+			}
+		default:
+		}
+	}
+	return false
+}
+
+func parseJSONTag(field reflect.StructField) (name, opts string, ok bool) {
+	tag, hasTag := field.Tag.Lookup("json")
+	if !hasTag {
+		return "", "", false
+	}
+	name, opts, _ = strings.Cut(tag, ",")
+	return name, opts, name != ""
+}
+
+func hasAnyTag(field reflect.StructField, tags []string) bool {
+	for _, tag := range tags {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applyValidationConstraints translates go-playground validation tags into schema constraints. Returns true
+// if the field is required.
+//
+// Tags after the `dive` keyword are applied to items of arrays and maps.
+func applyValidationConstraints(schema *OpenAPISchema, typ reflect.Type, tag string) bool {
+	if tag == "" || tag == "-" {
+		return false
+	}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	rawTag, diveTag, hasDive := strings.Cut(tag, ",dive")
+	if hasDive && schema.Items != nil {
+		applyValidationConstraints(schema.Items, typ.Elem(), strings.TrimPrefix(diveTag, ","))
+	} else if hasDive && schema.AdditionalProperties != nil {
+		applyValidationConstraints(schema.AdditionalProperties, typ.Elem(), strings.TrimPrefix(diveTag, ","))
+	}
+
+	required := false
+	for _, rule := range strings.Split(rawTag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			values := strings.Fields(param)
+			schema.Enum = make([]any, 0, len(values))
+			for _, value := range values {
+				schema.Enum = append(schema.Enum, parseValidationParam(typ, value))
+			}
+		case "eq":
+			schema.Const = parseValidationParam(typ, param)
+		case "len":
+			setLengthConstraint(schema, typ, param, true, true)
+		case "min", "gte":
+			setLengthConstraint(schema, typ, param, true, false)
+		case "max", "lte":
+			setLengthConstraint(schema, typ, param, false, true)
+		case "gt":
+			if n, err := strconv.ParseFloat(param, 64); err == nil && isNumberKind(typ.Kind()) {
+				schema.ExclusiveMinimum = &n
+			}
+		case "lt":
+			if n, err := strconv.ParseFloat(param, 64); err == nil && isNumberKind(typ.Kind()) {
+				schema.ExclusiveMaximum = &n
+			}
+		case "email":
+			schema.Format = "email"
+		case "uuid", "uuid4", "uuid5", "uuid3":
+			schema.Format = "uuid"
+		case "url", "uri", "http_url":
+			schema.Format = "uri"
+		case "hostname", "hostname_rfc1123", "fqdn":
+			schema.Format = "hostname"
+		case "ip4_addr", "ipv4":
+			schema.Format = "ipv4"
+		case "ip6_addr", "ipv6":
+			schema.Format = "ipv6"
+		case "datetime":
+			schema.Format = "date-time"
+		case "date":
+			schema.Format = "date"
+		case "startswith":
+			schema.Pattern = "^" + regexp.QuoteMeta(param)
+		case "endswith":
+			schema.Pattern = regexp.QuoteMeta(param) + "$"
+		case "alpha":
+			schema.Pattern = "^[a-zA-Z]*$"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]*$"
+		case "numeric":
+			schema.Pattern = `^[-+]?[0-9]+(?:\.[0-9]+)?$`
+		default:
+		}
+	}
+	return required
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+func setLengthConstraint(schema *OpenAPISchema, typ reflect.Type, param string, isMin, isMax bool) {
+	if isNumberKind(typ.Kind()) {
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if isMin {
+			schema.Minimum = &n
+		}
+		if isMax {
+			schema.Maximum = &n
+		}
+		return
+	}
+
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		if isMin {
+			schema.MinLength = &n
+		}
+		if isMax {
+			schema.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array:
+		if isMin {
+			schema.MinItems = &n
+		}
+		if isMax {
+			schema.MaxItems = &n
+		}
+	case reflect.Map:
+		if isMin {
+			schema.MinProperties = &n
+		}
+		if isMax {
+			schema.MaxProperties = &n
+		}
+	default:
+	}
+}
+
+func parseValidationParam(typ reflect.Type, param string) any {
+	switch {
+	case typ.Kind() == reflect.Bool:
+		if v, err := strconv.ParseBool(param); err == nil {
+			return v
+		}
+	case isNumberKind(typ.Kind()):
+		if v, err := strconv.ParseFloat(param, 64); err == nil {
+			return v
+		}
+	}
+	return param
+}
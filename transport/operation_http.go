@@ -0,0 +1,238 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/operation"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// routeNameGetOperationHTTP name of the route retrieving operations, used to build Location headers.
+const routeNameGetOperationHTTP = "geck.operations.get"
+
+// LongRunningOperationHTTP is the HTTP view of operation.Operation.
+type LongRunningOperationHTTP struct {
+	OperationID string             `json:"operation_id"`
+	Name        string             `json:"name"`
+	Status      operation.Status   `json:"status"`
+	Done        bool               `json:"done"`
+	Progress    operation.Progress `json:"progress"`
+	Metadata    map[string]string  `json:"metadata,omitempty"`
+	Result      json.RawMessage    `json:"result,omitempty"`
+	Error       *Error             `json:"error,omitempty"`
+	ExpireTime  string             `json:"expire_time,omitempty"`
+	persistence.AuditableView
+}
+
+// ConvertLongRunningOperationHTTP converts an operation.Operation into its HTTP view.
+func ConvertLongRunningOperationHTTP(src operation.Operation) LongRunningOperationHTTP {
+	view := LongRunningOperationHTTP{
+		OperationID:   src.ID,
+		Name:          src.Name,
+		Status:        src.Status,
+		Done:          src.Done(),
+		Progress:      src.Progress,
+		Metadata:      src.Metadata,
+		Result:        src.Result,
+		AuditableView: persistence.ConvertAuditableView(src.Auditable),
+	}
+	if src.Error != nil {
+		errView := convertSystemErrorEcho(src.Error.SystemError())
+		view.Error = &errView
+	}
+	if !src.ExpireTime.IsZero() {
+		view.ExpireTime = src.ExpireTime.Format(time.RFC3339)
+	}
+	return view
+}
+
+// AcceptedOperationEcho writes op with http.StatusAccepted, so clients poll the operation instead of waiting for
+// its work. The Location header points to the operation if OperationControllerHTTP is registered.
+func AcceptedOperationEcho(c echo.Context, op operation.Operation) error {
+	if location := c.Echo().Reverse(routeNameGetOperationHTTP, op.ID); location != "" {
+		c.Response().Header().Set(echo.HeaderLocation, location)
+	}
+	return c.JSON(http.StatusAccepted, Data{
+		Data: ConvertLongRunningOperationHTTP(op),
+	})
+}
+
+// GetOperationRequestHTTP request of operation retrieval and cancellation routes.
+type GetOperationRequestHTTP struct {
+	OperationID string `param:"operation_id"`
+}
+
+// ListOperationsRequestHTTP request of the operation listing route.
+type ListOperationsRequestHTTP struct {
+	PageSize  int64  `query:"page_size" validate:"omitempty,min=1,max=250"`
+	PageToken string `query:"page_token"`
+	// Name filters operations by name (e.g. reports.generate).
+	Name string `query:"name"`
+	// Status filters operations by status (e.g. RUNNING).
+	Status string `query:"status"`
+}
+
+// WaitOperationRequestHTTP request of the operation wait route.
+type WaitOperationRequestHTTP struct {
+	OperationID string `param:"operation_id"`
+	// Timeout maximum time to wait for (e.g. 10s), capped by operation.Config.MaxWaitTimeout.
+	Timeout string `query:"timeout"`
+}
+
+// OperationControllerHTTP serves operation.Operation(s) following Google AIP-151 (long-running operations):
+//
+//   - GET /operations
+//   - GET /operations/:operation_id
+//   - POST /operations/:operation_id/cancel
+//   - POST /operations/:operation_id/wait
+//
+// Principals only access the operations operation.Manager authorizes them to (see operation.Authorizer).
+type OperationControllerHTTP struct {
+	Manager *operation.Manager
+}
+
+var (
+	_ VersionedControllerHTTP = OperationControllerHTTP{}
+	_ DescribedControllerHTTP = OperationControllerHTTP{}
+)
+
+type NewOperationControllerHTTPParams struct {
+	fx.In
+
+	Manager *operation.Manager
+}
+
+func NewOperationControllerHTTP(params NewOperationControllerHTTPParams) OperationControllerHTTP {
+	return OperationControllerHTTP{
+		Manager: params.Manager,
+	}
+}
+
+func (o OperationControllerHTTP) SetRoutes(_ *echo.Echo) {}
+
+func (o OperationControllerHTTP) SetVersionedRoutes(g *echo.Group) {
+	g.GET("/operations", NewHandlerEcho(o.list, ConfigHandlerHTTP{}))
+	g.GET("/operations/:operation_id", NewHandlerEcho(o.get, ConfigHandlerHTTP{})).Name = routeNameGetOperationHTTP
+	g.POST("/operations/:operation_id/cancel", NewHandlerEcho(o.cancel, ConfigHandlerHTTP{}))
+	g.POST("/operations/:operation_id/wait", NewHandlerEcho(o.wait, ConfigHandlerHTTP{}))
+}
+
+func (o OperationControllerHTTP) DescribeOperations() []OperationHTTP {
+	opResponse := Data{Data: LongRunningOperationHTTP{}}
+	return []OperationHTTP{
+		{
+			Method:      http.MethodGet,
+			Path:        "/operations",
+			Versioned:   true,
+			OperationID: "listOperations",
+			Summary:     "List long-running operations",
+			Tags:        []string{"operations"},
+			Request:     ListOperationsRequestHTTP{},
+			Response:    Data{Data: data.Page[LongRunningOperationHTTP]{}},
+			Errors:      []int{http.StatusBadRequest},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/operations/:operation_id",
+			Versioned:   true,
+			OperationID: "getOperation",
+			Summary:     "Get a long-running operation",
+			Tags:        []string{"operations"},
+			Request:     GetOperationRequestHTTP{},
+			Response:    opResponse,
+			Errors:      []int{http.StatusNotFound},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/operations/:operation_id/cancel",
+			Versioned:   true,
+			OperationID: "cancelOperation",
+			Summary:     "Cancel a long-running operation",
+			Tags:        []string{"operations"},
+			Request:     GetOperationRequestHTTP{},
+			Response:    opResponse,
+			Errors:      []int{http.StatusNotFound},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/operations/:operation_id/wait",
+			Versioned:   true,
+			OperationID: "waitOperation",
+			Summary:     "Wait for a long-running operation to be done",
+			Description: "Returns the operation once done or timeout elapses, whichever happens first.",
+			Tags:        []string{"operations"},
+			Request:     WaitOperationRequestHTTP{},
+			Response:    opResponse,
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		},
+	}
+}
+
+func (o OperationControllerHTTP) list(ctx context.Context,
+	req ListOperationsRequestHTTP) (data.Page[LongRunningOperationHTTP], error) {
+	criteria := data.Criteria{
+		PageSize:  req.PageSize,
+		PageToken: data.PageToken(req.PageToken),
+	}
+	if req.Name != "" {
+		criteria.Filters = append(criteria.Filters, data.CriteriaFilter{
+			Field:    operation.FieldName,
+			Operator: data.OperatorEquals,
+			Value:    []any{req.Name},
+		})
+	}
+	if req.Status != "" {
+		criteria.Filters = append(criteria.Filters, data.CriteriaFilter{
+			Field:    operation.FieldStatus,
+			Operator: data.OperatorEquals,
+			Value:    []any{req.Status},
+		})
+	}
+	page, err := o.Manager.List(ctx, criteria)
+	if err != nil {
+		return data.Page[LongRunningOperationHTTP]{}, err
+	}
+	return data.ConvertPage(page, ConvertLongRunningOperationHTTP), nil
+}
+
+func (o OperationControllerHTTP) get(ctx context.Context, req GetOperationRequestHTTP) (LongRunningOperationHTTP,
+	error) {
+	op, err := o.Manager.Get(ctx, req.OperationID)
+	if err != nil {
+		return LongRunningOperationHTTP{}, err
+	}
+	return ConvertLongRunningOperationHTTP(op), nil
+}
+
+func (o OperationControllerHTTP) cancel(ctx context.Context,
+	req GetOperationRequestHTTP) (LongRunningOperationHTTP, error) {
+	op, err := o.Manager.Cancel(ctx, req.OperationID)
+	if err != nil {
+		return LongRunningOperationHTTP{}, err
+	}
+	return ConvertLongRunningOperationHTTP(op), nil
+}
+
+func (o OperationControllerHTTP) wait(ctx context.Context, req WaitOperationRequestHTTP) (LongRunningOperationHTTP,
+	error) {
+	var timeout time.Duration
+	if req.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(req.Timeout); err != nil || timeout < 0 {
+			return LongRunningOperationHTTP{}, systemerror.NewInvalidFormatArgument("timeout", "duration (e.g. 10s)")
+		}
+	}
+	op, err := o.Manager.Wait(ctx, req.OperationID, timeout)
+	if err != nil {
+		return LongRunningOperationHTTP{}, err
+	}
+	return ConvertLongRunningOperationHTTP(op), nil
+}
@@ -0,0 +1,103 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/operation"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestOperationControllerHTTP(t *testing.T) {
+	lifecycle := fxtest.NewLifecycle(t)
+	manager := operation.NewManager(operation.NewManagerParams{
+		Lifecycle: lifecycle,
+		Config: operation.Config{
+			WaitPollInterval: time.Millisecond,
+			MaxWaitTimeout:   time.Second,
+		},
+		Repository: operation.NewRepositoryMemory(encryption.NewEncryptorAES(encryption.ConfigEncryptor{
+			SecretKey: data.PageTokenDefaultEncryptionKey,
+		})),
+		FactoryID: identifier.NewFactoryUUID(),
+		Logger:    logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+	lifecycle.RequireStart()
+	defer lifecycle.RequireStop()
+
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	g := e.Group("/v1")
+	controller := transport.NewOperationControllerHTTP(transport.NewOperationControllerHTTPParams{
+		Manager: manager,
+	})
+	controller.SetVersionedRoutes(g)
+	release := make(chan struct{})
+	g.POST("/reports", func(c echo.Context) error {
+		op, err := manager.Start(c.Request().Context(), "reports.generate", nil,
+			func(ctx context.Context, _ *operation.Worker) (any, error) {
+				select {
+				case <-release:
+					return map[string]string{"report_id": "123"}, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			})
+		if err != nil {
+			return err
+		}
+		return transport.AcceptedOperationEcho(c, op)
+	})
+
+	serve := func(method, target string) (*httptest.ResponseRecorder, transport.LongRunningOperationHTTP) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		var out struct {
+			Data transport.LongRunningOperationHTTP `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return rec, out.Data
+	}
+
+	rec, started := serve(http.MethodPost, "/v1/reports")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "/v1/operations/"+started.OperationID, rec.Header().Get(echo.HeaderLocation))
+	assert.False(t, started.Done)
+
+	rec, _ = serve(http.MethodPost, "/v1/operations/"+started.OperationID+"/wait?timeout=1ms")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = serve(http.MethodPost, "/v1/operations/"+started.OperationID+"/wait?timeout=soon")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	close(release)
+	rec, done := serve(http.MethodPost, "/v1/operations/"+started.OperationID+"/wait")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, done.Done)
+	assert.Equal(t, operation.StatusSucceeded, done.Status)
+	assert.JSONEq(t, `{"report_id":"123"}`, string(done.Result))
+
+	rec, cancelled := serve(http.MethodPost, "/v1/operations/"+started.OperationID+"/cancel")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, operation.StatusSucceeded, cancelled.Status)
+
+	rec, _ = serve(http.MethodGet, "/v1/operations?status=SUCCEEDED")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(transport.HeaderTotalCount))
+
+	rec, _ = serve(http.MethodGet, "/v1/operations/unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hadroncorp/geck/data"
+)
+
+const (
+	// MIMEApplicationMergePatchJSON JSON Merge Patch media type (RFC 7396).
+	MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+	// MIMEApplicationJSONPatchJSON JSON Patch media type (RFC 6902).
+	MIMEApplicationJSONPatchJSON = "application/json-patch+json"
+)
+
+// UpdateMaskQueryParamsDefault query parameters read by ParseChangeSetEcho to build an update mask.
+var UpdateMaskQueryParamsDefault = []string{"update_mask"}
+
+// PatchHandlerFuncHTTP is a typed HTTP handler of partial updates. Req is bound from path parameters, query
+// parameters and headers, while the request body is parsed into a data.ChangeSet of Doc.
+type PatchHandlerFuncHTTP[Req, Doc, Res any] func(ctx context.Context, req Req, changes data.ChangeSet[Doc]) (Res, error)
+
+// NewPatchHandlerEcho allocates an echo.HandlerFunc from a typed handler of partial updates. Requests are handled
+// as in NewHandlerEcho, except the body is parsed with ParseChangeSetEcho.
+//
+// Field masks (if enabled) only prune responses, they are never available to handler through
+// data.GetFieldMaskFromContext: entities MUST be read entirely to be patched and stored.
+func NewPatchHandlerEcho[Req, Doc, Res any](handler PatchHandlerFuncHTTP[Req, Doc, Res],
+	cfg ConfigHandlerHTTP) echo.HandlerFunc {
+	return newHandlerEcho(cfg, bindParamsEcho, false,
+		func(ctx context.Context, c echo.Context, req Req) (Res, error) {
+			changes, err := ParseChangeSetEcho[Doc](c)
+			if err != nil {
+				var zero Res
+				return zero, err
+			}
+			return handler(ctx, req, changes)
+		})
+}
+
+// ParseChangeSetEcho parses the request body into a data.ChangeSet based on its media type:
+//
+//   - application/merge-patch+json: JSON Merge Patch (RFC 7396).
+//   - application/json-patch+json: JSON Patch (RFC 6902).
+//   - application/json: partial document. Only the fields of the update mask (e.g. ?update_mask=name,status)
+//     are updated. Behaves as a JSON Merge Patch if no update mask was given.
+//
+// Update masks are read from updateMaskParams, defaulting to UpdateMaskQueryParamsDefault. Other media types are
+// rejected with http.StatusUnsupportedMediaType, advertising the accepted ones through the Accept-Patch header.
+func ParseChangeSetEcho[T any](c echo.Context, updateMaskParams ...string) (data.ChangeSet[T], error) {
+	if len(updateMaskParams) == 0 {
+		updateMaskParams = UpdateMaskQueryParamsDefault
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case MIMEApplicationMergePatchJSON, MIMEApplicationJSONPatchJSON, echo.MIMEApplicationJSON:
+	default:
+		c.Response().Header().Set(HeaderAcceptPatch, strings.Join([]string{
+			MIMEApplicationMergePatchJSON, MIMEApplicationJSONPatchJSON, echo.MIMEApplicationJSON}, ", "))
+		return data.ChangeSet[T]{}, echo.ErrUnsupportedMediaType
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return data.ChangeSet[T]{}, err
+	}
+	switch mediaType {
+	case MIMEApplicationJSONPatchJSON:
+		return data.NewChangeSetJSONPatch[T](body)
+	case echo.MIMEApplicationJSON:
+		mask, errMask := ParseFieldMaskEcho(c, updateMaskParams...)
+		if errMask != nil {
+			return data.ChangeSet[T]{}, errMask
+		} else if !mask.IsEmpty() {
+			return data.NewChangeSetUpdateMask[T](mask, body)
+		}
+	}
+	return data.NewChangeSetMergePatch[T](body)
+}
@@ -0,0 +1,241 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/transport"
+	"github.com/hadroncorp/geck/validation"
+)
+
+type patchTask struct {
+	persistence.Auditable
+	ID     string
+	Name   string
+	Status string
+	Labels []string
+}
+
+type patchTaskDocument struct {
+	TaskID string   `json:"task_id" patch:"immutable"`
+	Name   string   `json:"name" validate:"required,lte=16"`
+	Status string   `json:"status" validate:"omitempty,oneof=PENDING DONE"`
+	Labels []string `json:"labels"`
+}
+
+type patchTaskRequest struct {
+	TaskID string `param:"task_id"`
+}
+
+func TestNewPatchHandlerEcho(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		target      string
+		body        string
+		wantPaths   data.FieldMask
+		wantDoc     patchTaskDocument
+		wantVersion int64
+		wantErr     string
+		wantCode    int
+	}{
+		{
+			name:        "merge patch",
+			contentType: transport.MIMEApplicationMergePatchJSON,
+			body:        `{"status":null,"labels":["a","b"]}`,
+			wantPaths:   data.FieldMask{"labels", "status"},
+			wantDoc:     patchTaskDocument{TaskID: "1", Name: "foo", Labels: []string{"a", "b"}},
+			wantVersion: 2,
+		},
+		{
+			name:        "merge patch without changes",
+			contentType: transport.MIMEApplicationMergePatchJSON,
+			body:        `{"task_id":"1","name":"foo"}`,
+			wantPaths:   data.FieldMask{"name", "task_id"},
+			wantDoc:     patchTaskDocument{TaskID: "1", Name: "foo", Status: "PENDING", Labels: []string{"x"}},
+			wantVersion: 1,
+		},
+		{
+			name:        "json patch",
+			contentType: transport.MIMEApplicationJSONPatchJSON,
+			body: `[{"op":"test","path":"/status","value":"PENDING"},{"op":"replace","path":"/status","value":"DONE"},` +
+				`{"op":"add","path":"/labels/0","value":"y"},{"op":"copy","from":"/labels/1","path":"/labels/-"},` +
+				`{"op":"move","from":"/labels/0","path":"/name"}]`,
+			wantPaths:   data.FieldMask{"labels", "name", "status"},
+			wantDoc:     patchTaskDocument{TaskID: "1", Name: "y", Status: "DONE", Labels: []string{"x", "x"}},
+			wantVersion: 2,
+		},
+		{
+			name:        "json patch remove",
+			contentType: transport.MIMEApplicationJSONPatchJSON,
+			body:        `[{"op":"remove","path":"/labels/0"},{"op":"remove","path":"/status"}]`,
+			wantPaths:   data.FieldMask{"labels", "status"},
+			wantDoc:     patchTaskDocument{TaskID: "1", Name: "foo", Labels: []string{}},
+			wantVersion: 2,
+		},
+		{
+			name:        "update mask",
+			contentType: echo.MIMEApplicationJSON,
+			target:      "?update_mask=name,labels",
+			body:        `{"name":"bar","status":"DONE"}`,
+			wantPaths:   data.FieldMask{"labels", "name"},
+			wantDoc:     patchTaskDocument{TaskID: "1", Name: "bar", Status: "PENDING"},
+			wantVersion: 2,
+		},
+		{
+			name:        "json without update mask",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"name":"bar"}`,
+			wantPaths:   data.FieldMask{"name"},
+			wantDoc:     patchTaskDocument{TaskID: "1", Name: "bar", Status: "PENDING", Labels: []string{"x"}},
+			wantVersion: 2,
+		},
+		{
+			name:        "immutable field",
+			contentType: transport.MIMEApplicationMergePatchJSON,
+			body:        `{"task_id":"2"}`,
+			wantErr:     "IMMUTABLE_ARGUMENT",
+		},
+		{
+			name:        "immutable field through json patch",
+			contentType: transport.MIMEApplicationJSONPatchJSON,
+			body:        `[{"op":"remove","path":"/task_id"}]`,
+			wantErr:     "IMMUTABLE_ARGUMENT",
+		},
+		{
+			name:        "invalid merged result",
+			contentType: transport.MIMEApplicationMergePatchJSON,
+			body:        `{"name":null}`,
+			wantErr:     "MISSING_ARGUMENT",
+		},
+		{
+			name:        "unknown field",
+			contentType: transport.MIMEApplicationMergePatchJSON,
+			body:        `{"owner":"bar"}`,
+			wantErr:     "MALFORMED_ARGUMENT",
+		},
+		{
+			name:        "unknown update mask path",
+			contentType: echo.MIMEApplicationJSON,
+			target:      "?update_mask=owner",
+			body:        `{}`,
+			wantErr:     "NOT_ONE_OF",
+		},
+		{
+			name:        "json patch test failed",
+			contentType: transport.MIMEApplicationJSONPatchJSON,
+			body:        `[{"op":"test","path":"/status","value":"DONE"},{"op":"replace","path":"/name","value":"bar"}]`,
+			wantErr:     "PATCH_TEST_FAILED",
+		},
+		{
+			name:        "json patch unresolved path",
+			contentType: transport.MIMEApplicationJSONPatchJSON,
+			body:        `[{"op":"replace","path":"/labels/3","value":"bar"}]`,
+			wantErr:     "INVALID_PATCH_PATH",
+		},
+		{
+			name:        "json patch malformed operation",
+			contentType: transport.MIMEApplicationJSONPatchJSON,
+			body:        `[{"op":"add","path":"/name"}]`,
+			wantErr:     "MALFORMED_ARGUMENT",
+		},
+		{
+			name:        "unsupported media type",
+			contentType: echo.MIMETextPlain,
+			body:        `name=bar`,
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity := patchTask{
+				Auditable: persistence.Auditable{Version: 1},
+				ID:        "1",
+				Name:      "foo",
+				Status:    "PENDING",
+				Labels:    []string{"x"},
+			}
+			var gotPaths data.FieldMask
+			handler := transport.NewPatchHandlerEcho(func(ctx context.Context, req patchTaskRequest,
+				changes data.ChangeSet[patchTaskDocument]) (patchTaskDocument, error) {
+				gotPaths = changes.Paths
+				_, err := persistence.Patch(ctx, validation.NewGoPlaygroundValidator(), &entity, changes,
+					convertPatchTaskDocument, applyPatchTaskDocument)
+				return convertPatchTaskDocument(entity), err
+			}, transport.ConfigHandlerHTTP{})
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/tasks/1"+tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("task_id")
+			c.SetParamValues("1")
+			err := handler(c)
+			if tt.wantCode != 0 {
+				var httpErr *echo.HTTPError
+				require.True(t, errors.As(err, &httpErr), "got error %v", err)
+				assert.Equal(t, tt.wantCode, httpErr.Code)
+				assert.Contains(t, rec.Header().Get(transport.HeaderAcceptPatch), transport.MIMEApplicationJSONPatchJSON)
+				return
+			} else if tt.wantErr != "" {
+				var sysErr systemerror.Error
+				require.True(t, errors.As(unwrapFirst(err), &sysErr), "got error %v", err)
+				assert.Equal(t, tt.wantErr, sysErr.Reason())
+				assert.Equal(t, int64(1), entity.Version)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPaths, gotPaths)
+			assert.Equal(t, tt.wantDoc, convertPatchTaskDocument(entity))
+			assert.Equal(t, tt.wantVersion, entity.Version)
+		})
+	}
+}
+
+func TestNewPatchHandlerEcho_FieldMask(t *testing.T) {
+	gotMask := data.FieldMask{}
+	handler := transport.NewPatchHandlerEcho(func(ctx context.Context, req patchTaskRequest,
+		changes data.ChangeSet[patchTaskDocument]) (patchTaskDocument, error) {
+		gotMask = data.GetFieldMaskFromContext(ctx)
+		return changes.Apply(ctx, validation.NewGoPlaygroundValidator(), patchTaskDocument{TaskID: req.TaskID, Name: "foo"})
+	}, transport.ConfigHandlerHTTP{FieldMask: true})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/1?fields=name", strings.NewReader(`{"name":"bar"}`))
+	req.Header.Set(echo.HeaderContentType, transport.MIMEApplicationMergePatchJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("task_id")
+	c.SetParamValues("1")
+	require.NoError(t, handler(c))
+	// entities are read entirely, responses are pruned though
+	assert.Nil(t, gotMask)
+	assert.JSONEq(t, `{"data":{"name":"bar"}}`, rec.Body.String())
+}
+
+func convertPatchTaskDocument(src patchTask) patchTaskDocument {
+	return patchTaskDocument{
+		TaskID: src.ID,
+		Name:   src.Name,
+		Status: src.Status,
+		Labels: src.Labels,
+	}
+}
+
+func applyPatchTaskDocument(entity *patchTask, doc patchTaskDocument) {
+	entity.Name = doc.Name
+	entity.Status = doc.Status
+	entity.Labels = doc.Labels
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/ratelimit"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// RateLimitKeyFuncEcho identifies the client of a request (e.g. principal, API key, IP address) for
+// rate limiting purposes.
+type RateLimitKeyFuncEcho func(c echo.Context) string
+
+// RateLimitKeyByIPEcho identifies clients by their IP address. X-Forwarded-For headers are only honoured if sent
+// by ConfigHTTP.TrustedProxies (see NewIPExtractorEcho).
+func RateLimitKeyByIPEcho(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+// RateLimitKeyByPrincipalEcho identifies clients by their security.Principal. Falls back to
+// RateLimitKeyByIPEcho if request is not authenticated (e.g. whitelisted routes).
+//
+// Middlewares using this strategy must run after the authenticator (see transportfx.AsAuthenticatedMiddlewareHTTP);
+// otherwise, every client gets identified by its IP address.
+func RateLimitKeyByPrincipalEcho(c echo.Context) string {
+	principal, err := security.GetPrincipalFromContext(c.Request().Context())
+	if err != nil {
+		return RateLimitKeyByIPEcho(c)
+	}
+	return "principal:" + principal.ID()
+}
+
+// RateLimitKeyByHeaderEcho identifies clients by the value of the given header (e.g. X-API-Key). Falls back to
+// RateLimitKeyByIPEcho if header is not present.
+func RateLimitKeyByHeaderEcho(header string) RateLimitKeyFuncEcho {
+	return func(c echo.Context) string {
+		val := c.Request().Header.Get(header)
+		if val == "" {
+			return RateLimitKeyByIPEcho(c)
+		}
+		return "header:" + header + ":" + val
+	}
+}
+
+// NewRateLimitKeyFuncEcho allocates a RateLimitKeyFuncEcho from its name (ip, principal, header:HEADER_NAME).
+func NewRateLimitKeyFuncEcho(strategy string) (RateLimitKeyFuncEcho, error) {
+	switch {
+	case strategy == "" || strategy == "ip":
+		return RateLimitKeyByIPEcho, nil
+	case strategy == "principal":
+		return RateLimitKeyByPrincipalEcho, nil
+	case strings.HasPrefix(strategy, "header:"):
+		return RateLimitKeyByHeaderEcho(strings.TrimPrefix(strategy, "header:")), nil
+	default:
+		return nil, fmt.Errorf("transport: unknown rate limit key strategy '%s'", strategy)
+	}
+}
+
+type rateLimitFuncEcho func(c echo.Context) (ratelimit.Limit, bool)
+
+// RateLimitEcho allocates a middleware limiting requests using the given ratelimit.Limit. Meant to be used per-route.
+//
+// Rejected requests get a systemerror.Error with StatusResourceExhausted and a Retry-After header. RateLimit-*
+// headers are written on every response.
+func RateLimitEcho(limiter ratelimit.Limiter, logger logging.Logger, limit ratelimit.Limit,
+	keyFunc RateLimitKeyFuncEcho) echo.MiddlewareFunc {
+	return newRateLimitEcho(limiter, logger, keyFunc, func(_ echo.Context) (ratelimit.Limit, bool) {
+		return limit, true
+	})
+}
+
+type NewRateLimitEchoParams struct {
+	fx.In
+
+	Limiter ratelimit.Limiter
+	Config  ConfigRateLimitHTTP
+	Logger  logging.Logger
+}
+
+// NewRateLimitEcho allocates a middleware limiting requests using ConfigRateLimitHTTP. Route-specific limits take
+// precedence over the default limit. Routes without limits are not limited.
+func NewRateLimitEcho(params NewRateLimitEchoParams) (echo.MiddlewareFunc, error) {
+	keyFunc, err := NewRateLimitKeyFuncEcho(params.Config.KeyStrategy)
+	if err != nil {
+		return nil, err
+	}
+	return newRateLimitEcho(params.Limiter, params.Logger, keyFunc, func(c echo.Context) (ratelimit.Limit, bool) {
+		if limit, ok := params.Config.RouteLimits.Lookup(c.Request().Method, echo.GetPath(c.Request())); ok {
+			return limit, true
+		} else if params.Config.DefaultLimitValue != nil {
+			return *params.Config.DefaultLimitValue, true
+		}
+		return ratelimit.Limit{}, false
+	}), nil
+}
+
+func newRateLimitEcho(limiter ratelimit.Limiter, logger logging.Logger, keyFunc RateLimitKeyFuncEcho,
+	limitFunc rateLimitFuncEcho) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			limit, ok := limitFunc(c)
+			if !ok {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			// scopes quota by route so limits of different routes do not interfere
+			key := c.Request().Method + " " + c.Path() + "#" + keyFunc(c)
+			result, err := limiter.Allow(ctx, key, limit)
+			if err != nil {
+				// fail open, an unavailable limiter state store must not take the service down
+				logger.WithError(err).WriteWithCtx(ctx, "failed to evaluate rate limit")
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.FormatInt(limit.Requests, 10))
+			header.Set(HeaderRateLimitRemaining, strconv.FormatInt(result.Remaining, 10))
+			header.Set(HeaderRateLimitReset, formatSecondsCeil(result.ResetAfter))
+			header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%s", limit.Requests, formatSecondsCeil(limit.Period)))
+			if !result.Allowed {
+				header.Set(echo.HeaderRetryAfter, formatSecondsCeil(result.RetryAfter))
+				return systemerror.NewRateLimitExceeded(limit.Requests, limit.Period, result.RetryAfter)
+			}
+			return next(c)
+		}
+	}
+}
+
+func formatSecondsCeil(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
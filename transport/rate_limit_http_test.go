@@ -0,0 +1,192 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/ratelimit"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/transport"
+)
+
+type failingLimiter struct{}
+
+func (f failingLimiter) Allow(_ context.Context, _ string, _ ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func newRateLimitedEcho(limiter ratelimit.Limiter, keyFunc transport.RateLimitKeyFuncEcho) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	e.Use(transport.RateLimitEcho(limiter, logging.NewZerologLoggerAdapter(zerolog.Nop()),
+		ratelimit.Limit{Requests: 2, Period: time.Minute}, keyFunc))
+	e.GET("/tasks", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	return e
+}
+
+func TestRateLimitEcho(t *testing.T) {
+	limiter := ratelimit.NewTokenBucketLimiter(ratelimit.Config{}, ratelimit.NewStoreMemory())
+
+	t.Run("headers and rejection", func(t *testing.T) {
+		e := newRateLimitedEcho(limiter, transport.RateLimitKeyByIPEcho)
+		for i, remaining := range []string{"1", "0"} {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+			require.Equal(t, http.StatusNoContent, rec.Code, "request %d", i)
+			assert.Equal(t, "2", rec.Header().Get(transport.HeaderRateLimitLimit))
+			assert.Equal(t, remaining, rec.Header().Get(transport.HeaderRateLimitRemaining))
+			assert.Equal(t, "2;w=60", rec.Header().Get(transport.HeaderRateLimitPolicy))
+			assert.NotEmpty(t, rec.Header().Get(transport.HeaderRateLimitReset))
+		}
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get(transport.HeaderRateLimitRemaining))
+		assert.Equal(t, "30", rec.Header().Get(echo.HeaderRetryAfter))
+	})
+
+	t.Run("fail open", func(t *testing.T) {
+		e := newRateLimitedEcho(failingLimiter{}, transport.RateLimitKeyByIPEcho)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Header().Get(transport.HeaderRateLimitLimit))
+	})
+}
+
+func TestNewRateLimitKeyFuncEcho(t *testing.T) {
+	e := echo.New()
+	newContext := func(principalID, apiKey string) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		if principalID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), security.PrincipalContextKey,
+				security.PrincipalTemplate{Identifier: principalID}))
+		}
+		return e.NewContext(req, httptest.NewRecorder())
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		c        echo.Context
+		want     string
+		wantErr  bool
+	}{
+		{name: "default", c: newContext("", ""), want: "ip:10.0.0.1"},
+		{name: "ip", strategy: "ip", c: newContext("user-1", ""), want: "ip:10.0.0.1"},
+		{name: "principal", strategy: "principal", c: newContext("user-1", ""), want: "principal:user-1"},
+		{name: "principal fallback", strategy: "principal", c: newContext("", ""), want: "ip:10.0.0.1"},
+		{name: "header", strategy: "header:X-API-Key", c: newContext("", "key-1"), want: "header:X-API-Key:key-1"},
+		{name: "header fallback", strategy: "header:X-API-Key", c: newContext("", ""), want: "ip:10.0.0.1"},
+		{name: "unknown", strategy: "cookie", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyFunc, err := transport.NewRateLimitKeyFuncEcho(tt.strategy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, keyFunc(tt.c))
+		})
+	}
+}
+
+func TestRegisterMiddlewaresEcho_AuthenticatedMiddlewares(t *testing.T) {
+	t.Setenv("HTTP_RATE_LIMIT_DEFAULT", "1/1m")
+	t.Setenv("HTTP_RATE_LIMIT_KEY", "principal")
+	cfg, err := transport.NewConfigRateLimitHTTP()
+	require.NoError(t, err)
+	logger := logging.NewZerologLoggerAdapter(zerolog.Nop())
+	rateLimit, err := transport.NewRateLimitEcho(transport.NewRateLimitEchoParams{
+		Limiter: ratelimit.NewTokenBucketLimiter(ratelimit.Config{}, ratelimit.NewStoreMemory()),
+		Config:  cfg,
+		Logger:  logger,
+	})
+	require.NoError(t, err)
+	authenticator := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal := security.PrincipalTemplate{Identifier: c.Request().Header.Get(echo.HeaderAuthorization)}
+			ctx := context.WithValue(c.Request().Context(), security.PrincipalContextKey, principal)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	// authenticated middlewares are registered last regardless of the order they are provided in
+	transport.RegisterMiddlewaresEcho(transport.RegisterMiddlewaresEchoParams{
+		Echo:                     e,
+		Logger:                   logger,
+		AuthenticatedMiddlewares: []echo.MiddlewareFunc{rateLimit},
+		Middlewares:              []echo.MiddlewareFunc{authenticator},
+	})
+	e.GET("/tasks", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	serve := func(principalID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set(echo.HeaderAuthorization, principalID)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusNoContent, serve("user-1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("user-1"))
+	// same IP address, different principal
+	assert.Equal(t, http.StatusNoContent, serve("user-2"))
+}
+
+func TestRateLimitKeyByIPEcho_ForwardedFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		want           string
+	}{
+		{name: "spoofed without trusted proxies", remoteAddr: "203.0.113.7:1234", want: "ip:203.0.113.7"},
+		{name: "spoofed from private network", remoteAddr: "10.0.0.1:1234", want: "ip:10.0.0.1"},
+		{name: "spoofed from untrusted proxy", trustedProxies: "10.0.0.0/8", remoteAddr: "203.0.113.7:1234",
+			want: "ip:203.0.113.7"},
+		{name: "trusted proxy", trustedProxies: "10.0.0.0/8,192.168.1.1", remoteAddr: "10.0.0.1:1234",
+			want: "ip:198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HTTP_SERVER_TRUSTED_PROXIES", tt.trustedProxies)
+			cfg, err := transport.NewConfigHTTP()
+			require.NoError(t, err)
+
+			e := echo.New()
+			e.IPExtractor = transport.NewIPExtractorEcho(cfg)
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.1")
+			assert.Equal(t, tt.want, transport.RateLimitKeyByIPEcho(e.NewContext(req, httptest.NewRecorder())))
+		})
+	}
+
+	t.Setenv("HTTP_SERVER_TRUSTED_PROXIES", "10.0.0.0/33")
+	_, err := transport.NewConfigHTTP()
+	assert.Error(t, err)
+}
@@ -0,0 +1,411 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/data/caching"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/security"
+)
+
+const (
+	responseCacheKeyPrefix    = "geck.transport.http.response#"
+	responseCacheTagKeyPrefix = "geck.transport.http.response.tag#"
+	responseCacheTagsEchoKey  = "geck.transport.http.response.tags"
+)
+
+// non-cacheable headers. Most of them are either per-request or computed by the HTTP server itself.
+var responseCacheExcludedHeaders = map[string]struct{}{
+	echo.HeaderSetCookie:       {},
+	echo.HeaderContentLength:   {},
+	echo.HeaderContentEncoding: {},
+	echo.HeaderVary:            {},
+	HeaderDate:                 {},
+	HeaderAge:                  {},
+	HeaderCache:                {},
+}
+
+// responseCacheEntry a cached HTTP response.
+type responseCacheEntry struct {
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	ETag         string      `json:"etag"`
+	LastModified time.Time   `json:"last_modified"`
+	CreateTime   time.Time   `json:"create_time"`
+	ExpireTime   time.Time   `json:"expire_time"`
+}
+
+// ResponseCacheHTTP is an HTTP response cache backed by caching.Cache.
+//
+// Cached responses are grouped by tags (see TagResponseCacheEcho), so they can be invalidated
+// when the underlying resources mutate (see ResponseCacheHTTP.Invalidate).
+type ResponseCacheHTTP struct {
+	Cache        caching.Cache
+	Config       ConfigResponseCacheHTTP
+	ServerConfig ConfigHTTP
+	Logger       logging.Logger
+}
+
+// NewResponseCacheHTTPParams ResponseCacheHTTP dependencies.
+type NewResponseCacheHTTPParams struct {
+	fx.In
+
+	Cache        caching.Cache
+	Config       ConfigResponseCacheHTTP
+	ServerConfig ConfigHTTP
+	Logger       logging.Logger
+}
+
+// NewResponseCacheHTTP allocates a new ResponseCacheHTTP instance.
+func NewResponseCacheHTTP(params NewResponseCacheHTTPParams) ResponseCacheHTTP {
+	return ResponseCacheHTTP{
+		Cache:        params.Cache,
+		Config:       params.Config,
+		ServerConfig: params.ServerConfig,
+		Logger:       params.Logger,
+	}
+}
+
+// Invalidate removes every cached response attached to any of the given tags.
+func (r ResponseCacheHTTP) Invalidate(ctx context.Context, tags ...string) error {
+	errs := make([]error, 0, len(tags))
+	for _, tag := range tags {
+		tagKey := responseCacheTagKeyPrefix + tag
+		keys, err := r.Cache.List(ctx, tagKey)
+		if err != nil && errors.Is(err, caching.ErrEntryNotFound) {
+			continue
+		} else if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		deleteKeys := make([]string, 0, len(keys)+1)
+		for _, key := range keys {
+			deleteKeys = append(deleteKeys, string(key))
+		}
+		deleteKeys = append(deleteKeys, tagKey)
+		if err = r.Cache.DeleteMany(ctx, deleteKeys); err != nil && !errors.Is(err, caching.ErrEntryNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TagResponseCacheEcho attaches tags to the response of the current request. If the response gets cached, it will
+// be invalidated once any of these tags is invalidated through ResponseCacheHTTP.Invalidate.
+//
+// For example, a list endpoint returning a data.Page might tag its response with the resource collection name
+// and each item identifier.
+func TagResponseCacheEcho(c echo.Context, tags ...string) {
+	current, _ := c.Get(responseCacheTagsEchoKey).([]string)
+	c.Set(responseCacheTagsEchoKey, append(current, tags...))
+}
+
+// NewResponseCacheEcho allocates a middleware caching GET responses using ResponseCacheHTTP. Meant to be used
+// per-route.
+//
+// Responses are keyed by route, path, query, Accept headers and, if ConfigResponseCacheHTTP.VaryByPrincipal is
+// set, the current principal. Honours Cache-Control request (no-store, no-cache, max-age) and response
+// (no-store, private, max-age, s-maxage) directives. Emits ETag and Last-Modified headers, responding
+// with 304 (Not Modified) to conditional requests.
+//
+// Static tags are attached to every cached response of the route. Handlers might attach more tags
+// through TagResponseCacheEcho.
+func NewResponseCacheEcho(cache ResponseCacheHTTP, tags ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodGet {
+				return next(c)
+			}
+			reqDirectives := parseCacheControl(req.Header.Get(echo.HeaderCacheControl))
+			if _, ok := reqDirectives["no-store"]; ok {
+				return next(c)
+			}
+
+			ctx := req.Context()
+			key := cache.newKey(c)
+			if _, noCache := reqDirectives["no-cache"]; !noCache {
+				entry, ok := cache.get(ctx, key)
+				if ok && isResponseCacheEntryFresh(entry, reqDirectives) {
+					return cache.writeEntry(c, entry, true)
+				}
+			}
+
+			TagResponseCacheEcho(c, tags...)
+			return cache.captureAndStore(c, next, key)
+		}
+	}
+}
+
+func (r ResponseCacheHTTP) newKey(c echo.Context) string {
+	req := c.Request()
+	hasher := sha256.New()
+	writeField := func(val string) {
+		_, _ = hasher.Write([]byte(val))
+		_, _ = hasher.Write([]byte{0})
+	}
+	writeField(c.Path())
+	writeField(req.URL.Path)
+	// url.Values.Encode sorts by key, producing the same key regardless of query ordering
+	writeField(req.URL.Query().Encode())
+	writeField(req.Header.Get(echo.HeaderAccept))
+	writeField(req.Header.Get("Accept-Language"))
+	if r.Config.VaryByPrincipal {
+		if principal, err := security.GetPrincipalFromContext(req.Context()); err == nil {
+			writeField(principal.ID())
+		}
+	}
+	return responseCacheKeyPrefix + hex.EncodeToString(hasher.Sum(nil))
+}
+
+func (r ResponseCacheHTTP) get(ctx context.Context, key string) (responseCacheEntry, bool) {
+	raw, err := r.Cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, caching.ErrEntryNotFound) {
+			r.Logger.WithError(err).WriteWithCtx(ctx, "failed to read cached response")
+		}
+		return responseCacheEntry{}, false
+	}
+	entry := responseCacheEntry{}
+	if err = json.Unmarshal(raw, &entry); err != nil {
+		r.Logger.WithError(err).WriteWithCtx(ctx, "failed to decode cached response")
+		return responseCacheEntry{}, false
+	}
+	if time.Now().UTC().After(entry.ExpireTime) {
+		return responseCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (r ResponseCacheHTTP) captureAndStore(c echo.Context, next echo.HandlerFunc, key string) error {
+	res := c.Response()
+	originalHeader := res.Header().Clone()
+	writer := &bufferedResponseWriter{ResponseWriter: res.Writer}
+	res.Writer = writer
+	err := next(c)
+	res.Writer = writer.ResponseWriter
+	if !res.Committed {
+		return err
+	}
+
+	status := res.Status
+	if err != nil || status != http.StatusOK || writer.Len() > r.Config.MaxBodySize {
+		return errors.Join(err, writer.replay(res, status))
+	}
+
+	now := time.Now().UTC()
+	entry := responseCacheEntry{
+		Status:     status,
+		Header:     r.newCacheableHeader(originalHeader, res.Header()),
+		Body:       writer.Bytes(),
+		CreateTime: now,
+		ExpireTime: now.Add(r.Config.TTL),
+	}
+	entry.ETag = res.Header().Get(HeaderETag)
+	if entry.ETag == "" {
+		entry.ETag = newETag(entry.Body)
+	}
+	entry.LastModified = now.Truncate(time.Second)
+	if lastModified, errParse := http.ParseTime(res.Header().Get(echo.HeaderLastModified)); errParse == nil {
+		entry.LastModified = lastModified
+	}
+
+	if r.isStorable(c, res.Header().Get(echo.HeaderCacheControl), &entry) {
+		r.store(c, key, entry)
+	}
+	uncommitResponseEcho(res)
+	return r.writeEntry(c, entry, false)
+}
+
+func (r ResponseCacheHTTP) isStorable(c echo.Context, cacheControl string, entry *responseCacheEntry) bool {
+	directives := parseCacheControl(cacheControl)
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+
+	maxAge, ok := directives["s-maxage"]
+	if !ok {
+		maxAge, ok = directives["max-age"]
+	}
+	if ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds <= 0 {
+			return false
+		}
+		entry.ExpireTime = entry.CreateTime.Add(time.Duration(seconds) * time.Second)
+	}
+	if _, isPrivate := directives["private"]; !isPrivate {
+		return true
+	} else if !r.Config.VaryByPrincipal {
+		return false
+	}
+	// private responses MUST be keyed by principal
+	_, err := security.GetPrincipalFromContext(c.Request().Context())
+	return err == nil
+}
+
+func (r ResponseCacheHTTP) store(c echo.Context, key string, entry responseCacheEntry) {
+	ctx := c.Request().Context()
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		r.Logger.WithError(err).WriteWithCtx(ctx, "failed to encode response")
+		return
+	}
+	if err = r.Cache.Set(ctx, key, raw); err != nil {
+		r.Logger.WithError(err).WriteWithCtx(ctx, "failed to cache response")
+		return
+	}
+
+	tags, _ := c.Get(responseCacheTagsEchoKey).([]string)
+	for _, tag := range tags {
+		tagKey := responseCacheTagKeyPrefix + tag
+		// avoids duplicated references if the response gets cached again
+		if err = r.Cache.RemoveFromList(ctx, tagKey, []byte(key)); err != nil && !errors.Is(err, caching.ErrEntryNotFound) {
+			r.Logger.WithError(err).WithField("tag", tag).WriteWithCtx(ctx, "failed to tag cached response")
+			continue
+		}
+		if err = r.Cache.Add(ctx, tagKey, []byte(key)); err != nil {
+			r.Logger.WithError(err).WithField("tag", tag).WriteWithCtx(ctx, "failed to tag cached response")
+		}
+	}
+}
+
+func (r ResponseCacheHTTP) newCacheableHeader(before, after http.Header) http.Header {
+	out := make(http.Header, len(after))
+	for key, values := range after {
+		if _, excluded := responseCacheExcludedHeaders[key]; excluded || key == r.ServerConfig.RequestIDTargetHeader {
+			continue
+		}
+		// only headers set by the handler itself, headers set by outer middlewares are per-request
+		if prevValues, ok := before[key]; ok && strings.Join(prevValues, ",") == strings.Join(values, ",") {
+			continue
+		}
+		out[key] = values
+	}
+	return out
+}
+
+func (r ResponseCacheHTTP) writeEntry(c echo.Context, entry responseCacheEntry, isHit bool) error {
+	header := c.Response().Header()
+	for key, values := range entry.Header {
+		header[key] = values
+	}
+	header.Set(HeaderETag, entry.ETag)
+	header.Set(echo.HeaderLastModified, entry.LastModified.Format(http.TimeFormat))
+	if isHit {
+		header.Set(HeaderCache, "HIT")
+		header.Set(HeaderAge, strconv.Itoa(int(time.Since(entry.CreateTime).Seconds())))
+	} else {
+		header.Set(HeaderCache, "MISS")
+	}
+
+	if isNotModified(c.Request(), entry.ETag, entry.LastModified) {
+		header.Del(echo.HeaderContentType)
+		return c.NoContent(http.StatusNotModified)
+	}
+	c.Response().WriteHeader(entry.Status)
+	_, err := c.Response().Write(entry.Body)
+	return err
+}
+
+func isResponseCacheEntryFresh(entry responseCacheEntry, reqDirectives map[string]string) bool {
+	maxAgeRaw, ok := reqDirectives["max-age"]
+	if !ok {
+		return true
+	}
+	maxAge, err := strconv.Atoi(maxAgeRaw)
+	if err != nil {
+		return true
+	}
+	return time.Since(entry.CreateTime) <= time.Duration(maxAge)*time.Second
+}
+
+// isNotModified evaluates conditional request headers (If-None-Match, If-Modified-Since) against the given
+// validators. If-Modified-Since is ignored if If-None-Match is present, as stated by RFC 9110.
+func isNotModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := req.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" {
+		return matchesETag(ifNoneMatch, etag)
+	}
+	ifModifiedSince, err := http.ParseTime(req.Header.Get(echo.HeaderIfModifiedSince))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// matchesETag performs a weak comparison of etag against a list of entity tags (e.g. If-None-Match header value).
+func matchesETag(list, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func newETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// parseCacheControl parses a Cache-Control header value into a directive/argument map.
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, directive := range strings.Split(value, ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(directive, "=")
+		directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+	}
+	return directives
+}
+
+// bufferedResponseWriter holds the response body in memory instead of writing it to the underlying
+// http.ResponseWriter. Headers are still shared with the underlying writer.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	bytes.Buffer
+}
+
+var _ http.ResponseWriter = (*bufferedResponseWriter)(nil)
+
+func (b *bufferedResponseWriter) WriteHeader(_ int) {
+	// status code is tracked by echo.Response
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.Buffer.Write(p)
+}
+
+// replay writes the buffered response through res using status.
+func (b *bufferedResponseWriter) replay(res *echo.Response, status int) error {
+	uncommitResponseEcho(res)
+	res.WriteHeader(status)
+	_, err := res.Write(b.Bytes())
+	return err
+}
+
+// uncommitResponseEcho resets the state of res, so a response committed into a bufferedResponseWriter gets written
+// through res again.
+func uncommitResponseEcho(res *echo.Response) {
+	res.Committed = false
+	res.Size = 0
+}
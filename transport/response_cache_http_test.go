@@ -0,0 +1,79 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/caching"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestNewResponseCacheEcho(t *testing.T) {
+	db, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Minute))
+	require.NoError(t, err)
+	defer db.Close()
+
+	cache := transport.ResponseCacheHTTP{
+		Cache: caching.NewCacheEmbedded(db, caching.BigCacheConfig{}, nil),
+		Config: transport.ConfigResponseCacheHTTP{
+			TTL:         time.Minute,
+			MaxBodySize: 1024,
+		},
+		Logger: logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	}
+	calls := 0
+	e := echo.New()
+	e.GET("/tasks", func(c echo.Context) error {
+		calls++
+		transport.TagResponseCacheEcho(c, "task:1")
+		return c.JSON(http.StatusOK, transport.Data{Data: []string{"1"}})
+	}, transport.NewResponseCacheEcho(cache, "tasks"))
+
+	doRequest := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tasks?page_size=10", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := doRequest(nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get(transport.HeaderCache))
+	etag := rec.Header().Get(transport.HeaderETag)
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderLastModified))
+	body := rec.Body.String()
+
+	rec = doRequest(nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get(transport.HeaderCache))
+	assert.Equal(t, body, rec.Body.String())
+	assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, 1, calls)
+
+	rec = doRequest(http.Header{transport.HeaderIfNoneMatch: []string{etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = doRequest(http.Header{echo.HeaderCacheControl: []string{"no-cache"}})
+	assert.Equal(t, "MISS", rec.Header().Get(transport.HeaderCache))
+	assert.Equal(t, 2, calls)
+
+	require.NoError(t, cache.Invalidate(context.Background(), "task:1"))
+	rec = doRequest(nil)
+	assert.Equal(t, "MISS", rec.Header().Get(transport.HeaderCache))
+	assert.Equal(t, 3, calls)
+}
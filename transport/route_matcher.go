@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidRoutePattern the route pattern does not follow RouteMatcher nomenclature.
+var ErrInvalidRoutePattern = errors.New("transport: invalid route pattern")
+
+// routeMatcherAnyMethod key of values matching every method.
+const routeMatcherAnyMethod = ""
+
+// routeNode is a RouteMatcher prefix tree node. Each node is a path segment.
+type routeNode[T any] struct {
+	literals map[string]*routeNode[T]
+	// param matches exactly one segment (:name, {name} or *).
+	param *routeNode[T]
+	// catchAll matches zero or more segments (**).
+	catchAll *routeNode[T]
+	// values route values by method.
+	values map[string]T
+}
+
+func newRouteNode[T any]() *routeNode[T] {
+	return &routeNode[T]{}
+}
+
+// RouteMatcher matches HTTP requests against route patterns using a prefix tree. Patterns use the nomenclature
+// [METHOD ]PATH, where PATH segments might be:
+//
+//   - Literals (e.g. /v1/tasks).
+//   - Path parameters, matching exactly one segment (e.g. /v1/tasks/:task_id, /v1/tasks/{task_id}).
+//   - Wildcards, matching exactly one segment (e.g. /v1/*/health).
+//   - Double wildcards, matching zero or more segments (e.g. /public/**, GET /v1/catalog/**).
+//
+// If more than one pattern matches a request, the most specific one wins: literals over parameters, parameters
+// over double wildcards and, for the same path, method-qualified patterns over patterns without method.
+//
+// Patterns are evaluated against request paths (not route templates). As route templates use path parameters,
+// they are valid patterns as well.
+type RouteMatcher[T any] struct {
+	root     *routeNode[T]
+	patterns []string
+}
+
+// NewRouteMatcher allocates an empty RouteMatcher.
+func NewRouteMatcher[T any]() *RouteMatcher[T] {
+	return &RouteMatcher[T]{
+		root: newRouteNode[T](),
+	}
+}
+
+// NewRouteMatcherSet allocates a RouteMatcher holding the given patterns. Use it when patterns hold no values
+// (e.g. allowlists).
+func NewRouteMatcherSet(patterns ...string) (*RouteMatcher[struct{}], error) {
+	matcher := NewRouteMatcher[struct{}]()
+	for _, pattern := range patterns {
+		if err := matcher.Add(pattern, struct{}{}); err != nil {
+			return nil, err
+		}
+	}
+	return matcher, nil
+}
+
+// NewRouteMatcherMap allocates a RouteMatcher holding the given pattern/value map.
+func NewRouteMatcherMap[T any](values map[string]T) (*RouteMatcher[T], error) {
+	matcher := NewRouteMatcher[T]()
+	for pattern, value := range values {
+		if err := matcher.Add(pattern, value); err != nil {
+			return nil, err
+		}
+	}
+	return matcher, nil
+}
+
+func parseRoutePattern(pattern string) (string, []string, error) {
+	pattern = strings.TrimSpace(pattern)
+	method := routeMatcherAnyMethod
+	if rawMethod, path, ok := strings.Cut(pattern, " "); ok {
+		method, pattern = strings.ToUpper(rawMethod), strings.TrimSpace(path)
+	}
+	if method == "*" {
+		method = routeMatcherAnyMethod
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		return "", nil, fmt.Errorf("%w: %q must start with /", ErrInvalidRoutePattern, pattern)
+	}
+	return method, splitRoutePath(pattern), nil
+}
+
+func splitRoutePath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isRouteParamSegment(segment string) bool {
+	return segment == "*" || strings.HasPrefix(segment, ":") ||
+		(strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"))
+}
+
+// Add registers pattern with the given value. Registering the same pattern twice overrides its value.
+func (m *RouteMatcher[T]) Add(pattern string, value T) error {
+	method, segments, err := parseRoutePattern(pattern)
+	if err != nil {
+		return err
+	}
+	node := m.root
+	for _, segment := range segments {
+		switch {
+		case segment == "**":
+			if node.catchAll == nil {
+				node.catchAll = newRouteNode[T]()
+			}
+			node = node.catchAll
+		case isRouteParamSegment(segment):
+			if node.param == nil {
+				node.param = newRouteNode[T]()
+			}
+			node = node.param
+		default:
+			if node.literals == nil {
+				node.literals = make(map[string]*routeNode[T])
+			}
+			child, ok := node.literals[segment]
+			if !ok {
+				child = newRouteNode[T]()
+				node.literals[segment] = child
+			}
+			node = child
+		}
+	}
+	if node.values == nil {
+		node.values = make(map[string]T)
+	}
+	node.values[method] = value
+	m.patterns = append(m.patterns, pattern)
+	return nil
+}
+
+// Patterns returns registered patterns.
+func (m *RouteMatcher[T]) Patterns() []string {
+	return m.patterns
+}
+
+// Lookup retrieves the value of the most specific pattern matching method and path.
+func (m *RouteMatcher[T]) Lookup(method, path string) (T, bool) {
+	if m == nil || m.root == nil {
+		var zeroVal T
+		return zeroVal, false
+	}
+	return m.root.lookup(strings.ToUpper(method), splitRoutePath(path))
+}
+
+// Match indicates if any pattern matches method and path.
+func (m *RouteMatcher[T]) Match(method, path string) bool {
+	_, ok := m.Lookup(method, path)
+	return ok
+}
+
+func (n *routeNode[T]) value(method string) (T, bool) {
+	if value, ok := n.values[method]; ok && method != routeMatcherAnyMethod {
+		return value, true
+	}
+	value, ok := n.values[routeMatcherAnyMethod]
+	return value, ok
+}
+
+func (n *routeNode[T]) lookup(method string, segments []string) (T, bool) {
+	if len(segments) == 0 {
+		if value, ok := n.value(method); ok {
+			return value, true
+		}
+		// double wildcards match zero segments as well
+		if n.catchAll != nil {
+			return n.catchAll.lookup(method, segments)
+		}
+		var zeroVal T
+		return zeroVal, false
+	}
+
+	if child, ok := n.literals[segments[0]]; ok {
+		if value, found := child.lookup(method, segments[1:]); found {
+			return value, true
+		}
+	}
+	if n.param != nil && segments[0] != "" {
+		if value, found := n.param.lookup(method, segments[1:]); found {
+			return value, true
+		}
+	}
+	if n.catchAll != nil {
+		// wildcard consumes as few segments as possible, letting subsequent pattern segments match
+		for i := 0; i <= len(segments); i++ {
+			if value, found := n.catchAll.lookup(method, segments[i:]); found {
+				return value, true
+			}
+		}
+	}
+	var zeroVal T
+	return zeroVal, false
+}
@@ -0,0 +1,72 @@
+package transport_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestRouteMatcher_Lookup(t *testing.T) {
+	matcher, err := transport.NewRouteMatcherMap(map[string]string{
+		"/healthz":                 "healthz",
+		"/v1/tasks/:task_id":       "task",
+		"/v1/tasks/{task_id}/logs": "task_logs",
+		"/v1/tasks/archived":       "archived",
+		"POST /v1/tasks/archived":  "archived_post",
+		"/v1/*/health":             "service_health",
+		"/public/**":               "public",
+		"GET /v1/catalog/**":       "catalog",
+		"/v1/files/**/raw":         "file_raw",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantValue string
+		wantOk    bool
+	}{
+		{name: "literal", method: http.MethodGet, path: "/healthz", wantValue: "healthz", wantOk: true},
+		{name: "trailing slash", method: http.MethodGet, path: "/healthz/", wantValue: "healthz", wantOk: true},
+		{name: "colon param", method: http.MethodGet, path: "/v1/tasks/123", wantValue: "task", wantOk: true},
+		{name: "brace param", method: http.MethodGet, path: "/v1/tasks/123/logs", wantValue: "task_logs",
+			wantOk: true},
+		{name: "literal over param", method: http.MethodGet, path: "/v1/tasks/archived", wantValue: "archived",
+			wantOk: true},
+		{name: "method over any", method: http.MethodPost, path: "/v1/tasks/archived",
+			wantValue: "archived_post", wantOk: true},
+		{name: "wildcard", method: http.MethodGet, path: "/v1/users/health", wantValue: "service_health",
+			wantOk: true},
+		{name: "wildcard single segment", method: http.MethodGet, path: "/v1/users/a/health"},
+		{name: "double wildcard zero segments", method: http.MethodGet, path: "/public", wantValue: "public",
+			wantOk: true},
+		{name: "double wildcard many segments", method: http.MethodDelete, path: "/public/a/b/c",
+			wantValue: "public", wantOk: true},
+		{name: "method qualified", method: http.MethodGet, path: "/v1/catalog/items/1", wantValue: "catalog",
+			wantOk: true},
+		{name: "method mismatch", method: http.MethodPost, path: "/v1/catalog/items/1"},
+		{name: "double wildcard mid path", method: http.MethodGet, path: "/v1/files/a/b/raw",
+			wantValue: "file_raw", wantOk: true},
+		{name: "no match", method: http.MethodGet, path: "/v1/users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := matcher.Lookup(tt.method, tt.path)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantValue, value)
+		})
+	}
+}
+
+func TestNewRouteMatcherSet(t *testing.T) {
+	_, err := transport.NewRouteMatcherSet("public/**")
+	assert.ErrorIs(t, err, transport.ErrInvalidRoutePattern)
+
+	var nilMatcher *transport.RouteMatcher[struct{}]
+	assert.False(t, nilMatcher.Match(http.MethodGet, "/healthz"))
+}
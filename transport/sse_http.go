@@ -0,0 +1,309 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/shutdown"
+)
+
+// MIMETextEventStream server-sent events media type.
+const MIMETextEventStream = "text/event-stream"
+
+// ErrInvalidEventSSE the event holds field values not representable in a server-sent events stream (e.g. line
+// breaks in identifiers).
+var ErrInvalidEventSSE = errors.New("transport: invalid server-sent event")
+
+// streamContextKey echo.Context key flagging streamed responses.
+const streamContextKey = "transport.stream"
+
+// EventSSE a server-sent event.
+type EventSSE struct {
+	// ID event identifier. Clients send the last received identifier through the Last-Event-ID header
+	// when reconnecting. MUST NOT contain line breaks nor NULL characters.
+	ID string
+	// Event event type. MUST NOT contain line breaks.
+	Event string
+	// Data event payload. String and []byte values are written as-is, any other value is encoded as JSON.
+	// Multi-line payloads are split in several data fields.
+	Data any
+	// Retry reconnection time hint sent to clients. Ignored if zero.
+	Retry time.Duration
+}
+
+// ProducerSSE produces events of a server-sent events stream.
+//
+// Sending to events blocks if the client consumes events slower than they are produced (backpressure), hence
+// producers MUST stop once ctx is done (e.g. client disconnected). lastEventID holds the identifier sent by
+// reconnecting clients, letting producers resume streams. Returning closes the stream.
+type ProducerSSE func(ctx context.Context, lastEventID string, events chan<- EventSSE) error
+
+// StreamerSSE writes server-sent events streams.
+//
+// Streams are logged when opened and closed, and skipped by NewLogRequestEcho. Gzip compression is skipped by
+// NewDefaultEchoMiddlewareGroup for requests accepting text/event-stream. Routes serving streams should be
+// excluded from handler timeouts (see ConfigHTTP.HandlerTimeoutRoutes).
+type StreamerSSE struct {
+	Config       ConfigSSE
+	ServerConfig ConfigHTTP
+	Logger       logging.Logger
+	// Coordinator ends streams once the shutdown sequence starts, so servers are able to drain. Optional.
+	Coordinator *shutdown.Coordinator
+}
+
+// NewStreamerSSEParams StreamerSSE dependencies.
+type NewStreamerSSEParams struct {
+	fx.In
+
+	Config       ConfigSSE
+	ServerConfig ConfigHTTP
+	Logger       logging.Logger
+	Coordinator  *shutdown.Coordinator `optional:"true"`
+}
+
+// NewStreamerSSE allocates a new StreamerSSE instance.
+func NewStreamerSSE(params NewStreamerSSEParams) StreamerSSE {
+	return StreamerSSE{
+		Config:       params.Config,
+		ServerConfig: params.ServerConfig,
+		Logger:       params.Logger,
+		Coordinator:  params.Coordinator,
+	}
+}
+
+// StreamEcho writes events produced by producer to the client until the producer returns, the client disconnects
+// or the server shuts down.
+//
+// Heartbeat comments are sent every ConfigSSE.HeartbeatInterval, keeping idle connections alive through proxies.
+// Clients not accepting events within ConfigSSE.WriteTimeout are disconnected.
+func (s StreamerSSE) StreamEcho(c echo.Context, producer ProducerSSE) error {
+	req := c.Request()
+	lastEventID := req.Header.Get(HeaderLastEventID)
+	if lastEventID == "" {
+		// used by EventSource polyfills unable to set headers
+		lastEventID = c.QueryParam("lastEventId")
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	res := c.Response()
+	header := res.Header()
+	header.Set(echo.HeaderContentType, MIMETextEventStream)
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set(echo.HeaderConnection, "keep-alive")
+	// disables reverse proxy buffering (e.g. nginx)
+	header.Set("X-Accel-Buffering", "no")
+	header.Del(echo.HeaderContentLength)
+	res.WriteHeader(http.StatusOK)
+	c.Set(streamContextKey, true)
+
+	writer := newEventWriterSSE(res, s.Config.WriteTimeout)
+	if err := writer.writeRetry(s.Config.Retry); err != nil {
+		return err
+	}
+
+	events := make(chan EventSSE, s.Config.BufferSize)
+	producerErrs := make(chan error, 1)
+	go func() {
+		defer close(events)
+		defer func() {
+			if r := recover(); r != nil {
+				producerErrs <- fmt.Errorf("transport: sse producer panic: %v", r)
+			}
+		}()
+		producerErrs <- producer(ctx, lastEventID, events)
+	}()
+
+	var draining <-chan struct{}
+	if s.Coordinator != nil {
+		draining = s.Coordinator.Draining()
+	}
+	var heartbeats <-chan time.Time
+	if s.Config.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(s.Config.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeats = ticker.C
+	}
+
+	startTime := time.Now()
+	requestID := header.Get(s.ServerConfig.RequestIDTargetHeader)
+	s.Logger.Info().
+		WithField("request_id", requestID).
+		WithField("route_path", c.Path()).
+		WithField("last_event_id", lastEventID).
+		WriteWithCtx(ctx, "opened sse stream")
+
+	var (
+		err        error
+		totalSent  int
+		lastSentID = lastEventID
+	)
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			// client disconnected
+			break loop
+		case <-draining:
+			// clients reconnect to other instances using the last event identifier
+			break loop
+		case event, ok := <-events:
+			if !ok {
+				err = <-producerErrs
+				break loop
+			}
+			if err = writer.writeEvent(event); err != nil {
+				break loop
+			}
+			totalSent++
+			if event.ID != "" {
+				lastSentID = event.ID
+			}
+		case <-heartbeats:
+			if err = writer.writeComment("heartbeat"); err != nil {
+				break loop
+			}
+		}
+	}
+	cancel()
+	if errors.Is(err, context.Canceled) {
+		err = nil
+	}
+
+	var logEvent logging.Event
+	if err != nil {
+		logEvent = s.Logger.Error()
+	} else {
+		logEvent = s.Logger.Info()
+	}
+	logEvent.
+		WithField("request_id", requestID).
+		WithField("route_path", c.Path()).
+		WithField("events_sent", totalSent).
+		WithField("last_event_id", lastSentID).
+		WithField("duration", time.Since(startTime)).
+		WithField("bytes_out", res.Size).
+		WithField("error", err).
+		WriteWithCtx(req.Context(), "closed sse stream")
+	return err
+}
+
+// IsStreamEcho indicates if the response of c is being streamed.
+func IsStreamEcho(c echo.Context) bool {
+	streamed, _ := c.Get(streamContextKey).(bool)
+	return streamed
+}
+
+// acceptsEventStream indicates if req accepts server-sent events.
+func acceptsEventStream(req *http.Request) bool {
+	return strings.Contains(req.Header.Get(echo.HeaderAccept), MIMETextEventStream)
+}
+
+type eventWriterSSE struct {
+	res          *echo.Response
+	controller   *http.ResponseController
+	writeTimeout time.Duration
+	buf          bytes.Buffer
+}
+
+func newEventWriterSSE(res *echo.Response, writeTimeout time.Duration) *eventWriterSSE {
+	return &eventWriterSSE{
+		res:          res,
+		controller:   http.NewResponseController(res.Writer),
+		writeTimeout: writeTimeout,
+	}
+}
+
+func (w *eventWriterSSE) writeEvent(event EventSSE) error {
+	// line breaks would let values inject fields (or whole events) into the stream
+	if strings.ContainsAny(event.ID, "\r\n\x00") {
+		return fmt.Errorf("%w: id contains line breaks or null characters", ErrInvalidEventSSE)
+	} else if strings.ContainsAny(event.Event, "\r\n") {
+		return fmt.Errorf("%w: event contains line breaks", ErrInvalidEventSSE)
+	}
+
+	w.buf.Reset()
+	if event.ID != "" {
+		w.writeField("id", event.ID)
+	}
+	if event.Event != "" {
+		w.writeField("event", event.Event)
+	}
+	if event.Retry > 0 {
+		w.writeField("retry", strconv.FormatInt(event.Retry.Milliseconds(), 10))
+	}
+
+	var data []byte
+	switch v := event.Data.(type) {
+	case nil:
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	// multi-line payloads are split in multiple data fields, clients join them back. Every line break
+	// (CRLF, LF and CR) ends a field.
+	data = bytes.ReplaceAll(data, []byte{'\r', '\n'}, []byte{'\n'})
+	data = bytes.ReplaceAll(data, []byte{'\r'}, []byte{'\n'})
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		w.writeField("data", string(line))
+	}
+	w.buf.WriteByte('\n')
+	return w.flush()
+}
+
+func (w *eventWriterSSE) writeComment(comment string) error {
+	w.buf.Reset()
+	w.buf.WriteString(": ")
+	w.buf.WriteString(comment)
+	w.buf.WriteString("\n\n")
+	return w.flush()
+}
+
+func (w *eventWriterSSE) writeRetry(retry time.Duration) error {
+	w.buf.Reset()
+	if retry > 0 {
+		w.writeField("retry", strconv.FormatInt(retry.Milliseconds(), 10))
+		w.buf.WriteByte('\n')
+	}
+	return w.flush()
+}
+
+func (w *eventWriterSSE) writeField(name, value string) {
+	w.buf.WriteString(name)
+	w.buf.WriteString(": ")
+	w.buf.WriteString(value)
+	w.buf.WriteByte('\n')
+}
+
+func (w *eventWriterSSE) flush() error {
+	if w.writeTimeout > 0 {
+		err := w.controller.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+	}
+	if w.buf.Len() > 0 {
+		if _, err := w.res.Write(w.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return w.controller.Flush()
+}
@@ -0,0 +1,134 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/transport"
+)
+
+func TestStreamerSSE_StreamEcho(t *testing.T) {
+	streamer := transport.NewStreamerSSE(transport.NewStreamerSSEParams{
+		Config: transport.ConfigSSE{
+			HeartbeatInterval: 10 * time.Millisecond,
+			BufferSize:        1,
+			WriteTimeout:      time.Second,
+		},
+		ServerConfig: transport.ConfigHTTP{RequestIDTargetHeader: echo.HeaderXRequestID},
+		Logger:       logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+	producerDone := make(chan struct{})
+	e := echo.New()
+	e.Use(transport.NewDefaultEchoMiddlewareGroup(transport.DefaultEchoMiddlewareParams{
+		Config: transport.ConfigHTTP{RequestIDTargetHeader: echo.HeaderXRequestID},
+		Logger: logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	}, transport.TraceIDEchoParams{})...)
+	e.GET("/progress", func(c echo.Context) error {
+		return streamer.StreamEcho(c, func(ctx context.Context, lastEventID string, events chan<- transport.EventSSE) error {
+			start, _ := strconv.Atoi(lastEventID)
+			for i := start + 1; i <= 3; i++ {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case events <- transport.EventSSE{ID: strconv.Itoa(i), Event: "progress", Data: map[string]int{"percent": i}}:
+				}
+			}
+			return nil
+		})
+	})
+	e.GET("/infinite", func(c echo.Context) error {
+		return streamer.StreamEcho(c, func(ctx context.Context, _ string, events chan<- transport.EventSSE) error {
+			defer close(producerDone)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	})
+	e.GET("/injection", func(c echo.Context) error {
+		return streamer.StreamEcho(c, func(ctx context.Context, _ string, events chan<- transport.EventSSE) error {
+			for _, event := range []transport.EventSSE{
+				{ID: "1", Event: "message", Data: "line 1\rid: 99\r\nline 2"},
+				{ID: "2", Event: "message\ndata: injected"},
+			} {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case events <- event:
+				}
+			}
+			return nil
+		})
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	t.Run("resume", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/progress", nil)
+		require.NoError(t, err)
+		req.Header.Set(echo.HeaderAccept, transport.MIMETextEventStream)
+		req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+		req.Header.Set(transport.HeaderLastEventID, "1")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, transport.MIMETextEventStream, res.Header.Get(echo.HeaderContentType))
+		assert.Empty(t, res.Header.Get(echo.HeaderContentEncoding))
+		var ids, data []string
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+				ids = append(ids, id)
+			} else if value, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				data = append(data, value)
+			}
+		}
+		assert.Equal(t, []string{"2", "3"}, ids)
+		assert.Equal(t, []string{`{"percent":2}`, `{"percent":3}`}, data)
+	})
+
+	t.Run("line breaks", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/injection")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var lines []string
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		// invalid events close the stream
+		assert.Equal(t, []string{"id: 1", "event: message", "data: line 1", "data: id: 99", "data: line 2", ""},
+			lines)
+	})
+
+	t.Run("client disconnect", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/infinite", nil)
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		line, err := bufio.NewReader(res.Body).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, ": heartbeat\n", line)
+		cancel()
+		select {
+		case <-producerDone:
+		case <-time.After(time.Second):
+			t.Fatal("producer was not notified of client disconnection")
+		}
+	})
+}
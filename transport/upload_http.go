@@ -0,0 +1,397 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/data/storage"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+const (
+	// sniffLength bytes used to detect content types (see http.DetectContentType).
+	sniffLength = 512
+	// checksumFieldSuffix suffix of form fields holding hex-encoded SHA-256 checksums of files (e.g. document_sha256).
+	checksumFieldSuffix = "_sha256"
+)
+
+var (
+	errUploadFileTooLarge    = errors.New("transport: upload file too large")
+	errUploadFieldTooLarge   = errors.New("transport: upload field too large")
+	errUploadRequestTooLarge = errors.New("transport: upload request too large")
+)
+
+// UploadedFileHTTP a file stored by UploaderHTTP.
+type UploadedFileHTTP struct {
+	// Field form field holding the file.
+	Field string `json:"field"`
+	// Filename client-provided file name.
+	Filename string `json:"filename"`
+	// Key storage.Bucket key the file is stored under.
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// Checksum hex-encoded SHA-256 digest of the file content.
+	Checksum string `json:"checksum"`
+}
+
+// UploadHTTP the result of a multipart upload.
+type UploadHTTP struct {
+	// Files stored files, in request order.
+	Files []UploadedFileHTTP
+	// Values non-file form fields.
+	Values map[string][]string
+}
+
+// UploadOptionsHTTP options of UploaderHTTP.UploadEcho.
+type UploadOptionsHTTP struct {
+	// Fields form fields files are accepted from. Files are accepted from every field if empty.
+	Fields []string
+	// KeyPrefix overrides ConfigUploadHTTP.KeyPrefix.
+	KeyPrefix string
+	// AllowedContentTypes overrides ConfigUploadHTTP.AllowedContentTypes.
+	AllowedContentTypes []string
+	// RequireChecksum rejects files without checksum.
+	RequireChecksum bool
+}
+
+// UploaderHTTP streams files of multipart/form-data requests into a storage.Bucket, so files are never buffered
+// entirely in memory.
+//
+// Content types are detected from file contents (the declared part content type is used only if it is consistent
+// with the contents, e.g. text/csv for plain text). Checksums are optional and verified if sent, either through a
+// Content-Digest part header (e.g. sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:) or a FIELD_sha256 form
+// field holding a hex-encoded digest, sent before the file.
+//
+// Violations are reported as systemerror errors: size limits as out of range errors, while disallowed content
+// types, checksum mismatches and malformed bodies as invalid argument errors. Files stored by failed requests
+// are removed.
+//
+// Upload routes SHOULD be excluded from (or given a larger) body limit (see ConfigHTTP.BodyLimitRoutes), as
+// ConfigUploadHTTP.MaxRequestSize is enforced by the uploader itself.
+type UploaderHTTP struct {
+	Config    ConfigUploadHTTP
+	Bucket    storage.Bucket
+	FactoryID identifier.Factory
+	Logger    logging.Logger
+}
+
+// NewUploaderHTTPParams UploaderHTTP dependencies.
+type NewUploaderHTTPParams struct {
+	fx.In
+
+	Config    ConfigUploadHTTP
+	Bucket    storage.Bucket
+	FactoryID identifier.Factory
+	Logger    logging.Logger
+}
+
+// NewUploaderHTTP allocates a new UploaderHTTP instance.
+func NewUploaderHTTP(params NewUploaderHTTPParams) UploaderHTTP {
+	return UploaderHTTP{
+		Config:    params.Config,
+		Bucket:    params.Bucket,
+		FactoryID: params.FactoryID,
+		Logger:    params.Logger,
+	}
+}
+
+// UploadEcho streams files of the request into UploaderHTTP.Bucket.
+func (u UploaderHTTP) UploadEcho(c echo.Context, opts UploadOptionsHTTP) (upload UploadHTTP, err error) {
+	req := c.Request()
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+	if err != nil || mediaType != echo.MIMEMultipartForm || params["boundary"] == "" {
+		return UploadHTTP{}, systemerror.NewInvalidFormatArgument(echo.HeaderContentType, echo.MIMEMultipartForm)
+	} else if u.Config.MaxRequestSize > 0 && req.ContentLength > u.Config.MaxRequestSize {
+		return UploadHTTP{}, systemerror.NewArgumentOutOfRangeSingle("request", "max", u.Config.MaxRequestSize)
+	}
+
+	ctx := req.Context()
+	upload = UploadHTTP{
+		Files:  make([]UploadedFileHTTP, 0),
+		Values: make(map[string][]string),
+	}
+	defer func() {
+		if err != nil {
+			u.removeFiles(ctx, upload.Files)
+			upload = UploadHTTP{}
+		}
+	}()
+
+	body := newLimitedReaderUpload(req.Body, u.Config.MaxRequestSize, errUploadRequestTooLarge)
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, errPart := reader.NextPart()
+		if errors.Is(errPart, io.EOF) {
+			return upload, nil
+		} else if errPart != nil {
+			return upload, u.newReadError("body", errPart)
+		}
+		field := part.FormName()
+		if part.FileName() == "" {
+			err = u.readValue(part, field, upload.Values)
+		} else {
+			var file UploadedFileHTTP
+			if file, err = u.storeFile(ctx, part, opts, upload); err == nil {
+				upload.Files = append(upload.Files, file)
+			}
+		}
+		_ = part.Close()
+		if err != nil {
+			return upload, err
+		}
+	}
+}
+
+func (u UploaderHTTP) readValue(part *multipart.Part, field string, values map[string][]string) error {
+	value, err := io.ReadAll(newLimitedReaderUpload(part, u.Config.MaxFieldSize, errUploadFieldTooLarge))
+	if err != nil {
+		return u.newReadError(field, err)
+	}
+	values[field] = append(values[field], string(value))
+	return nil
+}
+
+func (u UploaderHTTP) storeFile(ctx context.Context, part *multipart.Part, opts UploadOptionsHTTP,
+	upload UploadHTTP) (UploadedFileHTTP, error) {
+	field := part.FormName()
+	if len(opts.Fields) > 0 && !slices.Contains(opts.Fields, field) {
+		return UploadedFileHTTP{}, systemerror.NewArgumentNotOneOf("file_field", opts.Fields...)
+	} else if u.Config.MaxFiles > 0 && len(upload.Files) >= u.Config.MaxFiles {
+		return UploadedFileHTTP{}, systemerror.NewArgumentOutOfRangeSingle("files", "max", u.Config.MaxFiles)
+	}
+	expectedChecksum, err := parseUploadChecksum(part.Header.Get(HeaderContentDigest),
+		upload.Values[field+checksumFieldSuffix])
+	if err != nil {
+		return UploadedFileHTTP{}, systemerror.NewInvalidFormatArgument(field+".checksum", "sha-256=:BASE64:")
+	} else if opts.RequireChecksum && expectedChecksum == nil {
+		return UploadedFileHTTP{}, systemerror.NewMissingArgument(field + ".checksum")
+	}
+
+	limited := newLimitedReaderUpload(part, u.Config.MaxFileSize, errUploadFileTooLarge)
+	buffered := bufio.NewReaderSize(limited, sniffLength)
+	head, err := buffered.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return UploadedFileHTTP{}, u.newReadError(field, err)
+	}
+	contentType := detectUploadContentType(head, part.Header.Get(echo.HeaderContentType))
+	allowed := u.Config.AllowedContentTypes
+	if opts.AllowedContentTypes != nil {
+		allowed = opts.AllowedContentTypes
+	}
+	if !isContentTypeAllowed(allowed, contentType) {
+		return UploadedFileHTTP{}, systemerror.NewArgumentNotOneOf(field+".content_type", allowed...)
+	}
+
+	id, err := u.FactoryID.NewIdentifier()
+	if err != nil {
+		return UploadedFileHTTP{}, err
+	}
+	keyPrefix := u.Config.KeyPrefix
+	if opts.KeyPrefix != "" {
+		keyPrefix = opts.KeyPrefix
+	}
+	file := UploadedFileHTTP{
+		Field:       field,
+		Filename:    part.FileName(),
+		Key:         keyPrefix + id,
+		ContentType: contentType,
+	}
+	hash := sha256.New()
+	source := &trackedReaderUpload{reader: io.TeeReader(buffered, hash)}
+	obj, err := u.Bucket.Put(ctx, file.Key, source, storage.PutOptions{
+		ContentType: contentType,
+		Size:        -1,
+		Metadata: map[string]string{
+			"field":    field,
+			"filename": file.Filename,
+		},
+	})
+	if source.err != nil {
+		// nothing was stored, reading errors take precedence over storage ones
+		return UploadedFileHTTP{}, u.newReadError(field, source.err)
+	} else if err != nil {
+		return UploadedFileHTTP{}, err
+	}
+	file.Size = obj.Size
+	checksum := hash.Sum(nil)
+	file.Checksum = hex.EncodeToString(checksum)
+	if expectedChecksum != nil && !bytes.Equal(checksum, expectedChecksum) {
+		u.removeFiles(ctx, []UploadedFileHTTP{file})
+		return UploadedFileHTTP{}, systemerror.NewInvalidArgument(field+".checksum", file.Checksum,
+			hex.EncodeToString(expectedChecksum))
+	}
+	return file, nil
+}
+
+// newReadError translates errors reading the request body of field.
+func (u UploaderHTTP) newReadError(field string, err error) error {
+	switch {
+	case errors.Is(err, errUploadRequestTooLarge):
+		return systemerror.NewArgumentOutOfRangeSingle("request", "max", u.Config.MaxRequestSize)
+	case errors.Is(err, errUploadFileTooLarge):
+		return systemerror.NewArgumentOutOfRangeSingle(field, "max", u.Config.MaxFileSize)
+	case errors.Is(err, errUploadFieldTooLarge):
+		return systemerror.NewArgumentOutOfRangeSingle(field, "max", u.Config.MaxFieldSize)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return systemerror.NewMalformedArgument(field, err.Error())
+	}
+}
+
+func (u UploaderHTTP) removeFiles(ctx context.Context, files []UploadedFileHTTP) {
+	for _, file := range files {
+		if err := u.Bucket.Delete(context.WithoutCancel(ctx), file.Key); err != nil {
+			u.Logger.WithError(err).
+				WithField("key", file.Key).
+				WriteWithCtx(ctx, "failed to remove uploaded file")
+		}
+	}
+}
+
+// parseUploadChecksum parses the expected SHA-256 digest of a file from a Content-Digest header or a form field.
+// Returns nil if none was sent.
+func parseUploadChecksum(contentDigest string, fieldValues []string) ([]byte, error) {
+	if contentDigest != "" {
+		for _, entry := range strings.Split(contentDigest, ",") {
+			algorithm, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || !strings.EqualFold(algorithm, "sha-256") {
+				continue
+			}
+			value = strings.TrimSuffix(strings.TrimPrefix(value, ":"), ":")
+			digest, err := base64.StdEncoding.DecodeString(value)
+			if err != nil || len(digest) != sha256.Size {
+				return nil, errors.New("transport: malformed content digest")
+			}
+			return digest, nil
+		}
+		return nil, errors.New("transport: unsupported content digest algorithm")
+	}
+	if len(fieldValues) == 0 {
+		return nil, nil
+	}
+	digest, err := hex.DecodeString(fieldValues[len(fieldValues)-1])
+	if err != nil || len(digest) != sha256.Size {
+		return nil, errors.New("transport: malformed checksum")
+	}
+	return digest, nil
+}
+
+// detectUploadContentType detects the content type of a file from its first bytes (see http.DetectContentType),
+// so allowed content types are checked against the actual content rather than the client claims.
+//
+// declared is only used if it is consistent with the detected type: the same media type, or a more specific textual
+// type of content detected as plain text (e.g. text/csv, application/json). Markup types (e.g. text/html,
+// image/svg+xml) are never taken from declared, as they would be detected otherwise. Unknown binary content remains
+// application/octet-stream regardless of declared.
+func detectUploadContentType(head []byte, declared string) string {
+	detected := http.DetectContentType(head)
+	declaredType, _, err := mime.ParseMediaType(declared)
+	if err != nil || declaredType == "" {
+		return detected
+	}
+	detectedType, _, _ := mime.ParseMediaType(detected)
+	switch {
+	case declaredType == detectedType:
+		return detected
+	case detectedType == echo.MIMETextPlain && isPlainTextMediaType(declaredType):
+		return declaredType
+	default:
+		return detected
+	}
+}
+
+// isPlainTextMediaType indicates if content of mediaType is indistinguishable from plain text when sniffed.
+func isPlainTextMediaType(mediaType string) bool {
+	switch {
+	case mediaType == echo.MIMETextHTML || mediaType == echo.MIMETextXML:
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	default:
+		return mediaType == echo.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json")
+	}
+}
+
+func isContentTypeAllowed(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, candidate := range allowed {
+		if candidate == mediaType || candidate == "*/*" {
+			return true
+		} else if prefix, ok := strings.CutSuffix(candidate, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedReaderUpload reads up to remaining bytes from reader, returning err if reader holds more bytes.
+type limitedReaderUpload struct {
+	reader    io.Reader
+	remaining int64
+	err       error
+}
+
+// newLimitedReaderUpload allocates a reader limited to limit bytes (see limitedReaderUpload). Limits are disabled
+// if limit is not positive.
+func newLimitedReaderUpload(reader io.Reader, limit int64, err error) io.Reader {
+	if limit <= 0 {
+		return reader
+	}
+	return &limitedReaderUpload{reader: reader, remaining: limit, err: err}
+}
+
+func (l *limitedReaderUpload) Read(p []byte) (int, error) {
+	if l.remaining == 0 {
+		// probes a single byte, as contents holding exactly the limit are allowed
+		var probe [1]byte
+		n, err := l.reader.Read(probe[:])
+		if n > 0 {
+			return 0, l.err
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// trackedReaderUpload records the first reading error, so callers tell reading errors from writing ones.
+type trackedReaderUpload struct {
+	reader io.Reader
+	err    error
+}
+
+func (t *trackedReaderUpload) Read(p []byte) (int, error) {
+	n, err := t.reader.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) && t.err == nil {
+		t.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,240 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/data/storage"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/transport"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+type uploadPartTest struct {
+	field       string
+	filename    string
+	contentType string
+	digest      string
+	content     []byte
+}
+
+func newUploadRequest(t *testing.T, parts ...uploadPartTest) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		if part.filename == "" {
+			header.Set("Content-Disposition", `form-data; name="`+part.field+`"`)
+		} else {
+			header.Set("Content-Disposition", `form-data; name="`+part.field+`"; filename="`+part.filename+`"`)
+		}
+		if part.contentType != "" {
+			header.Set(echo.HeaderContentType, part.contentType)
+		}
+		if part.digest != "" {
+			header.Set(transport.HeaderContentDigest, part.digest)
+		}
+		w, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, err = w.Write(part.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	req := httptest.NewRequest(http.MethodPost, "/documents", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	// chunked, so limits are enforced while streaming
+	req.ContentLength = -1
+	return req
+}
+
+func TestUploaderHTTP(t *testing.T) {
+	root := t.TempDir()
+	bucket, err := storage.NewBucketFilesystem(storage.ConfigFilesystem{RootPath: root})
+	require.NoError(t, err)
+	uploader := transport.NewUploaderHTTP(transport.NewUploaderHTTPParams{
+		Config: transport.ConfigUploadHTTP{
+			MaxFileSize:         1024,
+			MaxRequestSize:      4096,
+			MaxFiles:            2,
+			MaxFieldSize:        64,
+			AllowedContentTypes: []string{"image/*", "application/json", "application/pdf"},
+			KeyPrefix:           "uploads/",
+		},
+		Bucket:    bucket,
+		FactoryID: identifier.NewFactoryUUID(),
+		Logger:    logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	e.POST("/documents", func(c echo.Context) error {
+		upload, err := uploader.UploadEcho(c, transport.UploadOptionsHTTP{})
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, upload)
+	})
+
+	image := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{1}, 100)...)
+	imageSum := sha256.Sum256(image)
+	jsonDoc := []byte(`{"title":"report"}`)
+
+	tests := []struct {
+		name      string
+		parts     []uploadPartTest
+		expStatus int
+		expBody   string
+		expFiles  int
+	}{
+		{
+			name: "stored",
+			parts: []uploadPartTest{
+				{field: "title", content: []byte("quarterly report")},
+				{field: "image", filename: "cover.png", contentType: "application/octet-stream", content: image,
+					digest: "sha-256=:" + base64.StdEncoding.EncodeToString(imageSum[:]) + ":"},
+				{field: "doc_sha256", content: []byte(hex.EncodeToString(sha256Sum(jsonDoc)))},
+				{field: "doc", filename: "doc.json", contentType: "application/json", content: jsonDoc},
+			},
+			expStatus: http.StatusCreated,
+			expFiles:  2,
+		},
+		{
+			name: "file too large",
+			parts: []uploadPartTest{
+				{field: "image", filename: "big.png", content: append(append([]byte{}, pngHeader...),
+					bytes.Repeat([]byte{1}, 1024)...)},
+			},
+			expStatus: http.StatusBadRequest,
+			expBody:   "'image' is out of range, expected maximum [1024]",
+		},
+		{
+			name: "request too large",
+			parts: func() []uploadPartTest {
+				parts := []uploadPartTest{{field: "a", filename: "a.png", content: image}}
+				for i := 0; i < 70; i++ {
+					parts = append(parts, uploadPartTest{field: "note", content: bytes.Repeat([]byte("x"), 60)})
+				}
+				return parts
+			}(),
+			expStatus: http.StatusBadRequest,
+			expBody:   "'request' is out of range",
+		},
+		{
+			name: "too many files",
+			parts: []uploadPartTest{
+				{field: "a", filename: "a.png", content: image},
+				{field: "b", filename: "b.png", content: image},
+				{field: "c", filename: "c.png", content: image},
+			},
+			expStatus: http.StatusBadRequest,
+			expBody:   "'files' is out of range, expected maximum [2]",
+		},
+		{
+			name: "disallowed content type",
+			parts: []uploadPartTest{
+				{field: "script", filename: "cover.png", contentType: "image/png", content: []byte("#!/bin/sh")},
+			},
+			expStatus: http.StatusBadRequest,
+			expBody:   "NOT_ONE_OF",
+		},
+		{
+			name: "svg declared as image",
+			parts: []uploadPartTest{
+				{field: "image", filename: "cover.svg", contentType: "image/svg+xml",
+					content: []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`)},
+			},
+			expStatus: http.StatusBadRequest,
+			expBody:   "NOT_ONE_OF",
+		},
+		{
+			name: "binary declared as document",
+			parts: []uploadPartTest{
+				{field: "doc", filename: "doc.pdf", contentType: "application/pdf",
+					content: []byte{0x00, 0x01, 0x02, 0xfe, 0xff, 0x10}},
+			},
+			expStatus: http.StatusBadRequest,
+			expBody:   "NOT_ONE_OF",
+		},
+		{
+			name: "checksum mismatch",
+			parts: []uploadPartTest{
+				{field: "image", filename: "a.png", content: image,
+					digest: "sha-256=:" + base64.StdEncoding.EncodeToString(make([]byte, 32)) + ":"},
+			},
+			expStatus: http.StatusBadRequest,
+			expBody:   "argument 'image.checksum' is invalid",
+		},
+		{
+			name: "field too large",
+			parts: []uploadPartTest{
+				{field: "title", content: bytes.Repeat([]byte("x"), 65)},
+			},
+			expStatus: http.StatusBadRequest,
+			expBody:   "'title' is out of range, expected maximum [64]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, newUploadRequest(t, tt.parts...))
+			assert.Equal(t, tt.expStatus, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), tt.expBody)
+			if tt.expStatus != http.StatusCreated {
+				return
+			}
+			upload := transport.UploadHTTP{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &upload))
+			require.Len(t, upload.Files, tt.expFiles)
+			assert.Equal(t, []string{"quarterly report"}, upload.Values["title"])
+			assert.Equal(t, "image/png", upload.Files[0].ContentType)
+			assert.Equal(t, hex.EncodeToString(imageSum[:]), upload.Files[0].Checksum)
+			assert.Equal(t, "application/json", upload.Files[1].ContentType)
+			assert.EqualValues(t, len(jsonDoc), upload.Files[1].Size)
+
+			body, obj, err := bucket.Get(context.Background(), upload.Files[1].Key)
+			require.NoError(t, err)
+			defer body.Close()
+			content, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, jsonDoc, content)
+			assert.Equal(t, "doc.json", obj.Metadata["filename"])
+		})
+	}
+
+	// failed requests must not leave stored files behind
+	entries, err := os.ReadDir(filepath.Join(root, "objects", "uploads"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	t.Run("not multipart", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func sha256Sum(content []byte) []byte {
+	sum := sha256.Sum256(content)
+	return sum[:]
+}
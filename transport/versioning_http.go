@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"expvar"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// MultiVersionControllerHTTP is a ControllerHTTP serving more than one API version side by side (e.g. v1 and v2
+// during migrations).
+//
+// Routes of each version are mounted under its path prefix (e.g. /v1/tasks, /v2/tasks). Requests without
+// prefix are routed by APIVersionRouterHTTP. Implement DescribedMultiVersionControllerHTTP to document routes of each
+// version in the OpenAPI document.
+type MultiVersionControllerHTTP interface {
+	ControllerHTTP
+	// APIVersions retrieves the API versions served by the controller (e.g. v1, v2).
+	APIVersions() []string
+	// SetVersionRoutes registers routes of version into g.
+	SetVersionRoutes(version string, g *echo.Group)
+}
+
+const apiVersionContextKey = "transport.api_version"
+
+// GetAPIVersionEcho retrieves the API version serving c. Returns an empty string if the route is not versioned.
+func GetAPIVersionEcho(c echo.Context) string {
+	version, _ := c.Get(apiVersionContextKey).(string)
+	return version
+}
+
+// APIVersionUsageHTTP counts requests served by each API version.
+type APIVersionUsageHTTP struct {
+	counters *expvar.Map
+}
+
+// NewAPIVersionUsageHTTP allocates a new APIVersionUsageHTTP instance.
+func NewAPIVersionUsageHTTP() *APIVersionUsageHTTP {
+	return &APIVersionUsageHTTP{
+		counters: new(expvar.Map).Init(),
+	}
+}
+
+// Add increments requests served by version.
+func (u *APIVersionUsageHTTP) Add(version string) {
+	u.counters.Add(version, 1)
+}
+
+// Count retrieves requests served by version.
+func (u *APIVersionUsageHTTP) Count(version string) int64 {
+	counter, ok := u.counters.Get(version).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return counter.Value()
+}
+
+// PublishAPIVersionUsageExpvar publishes usage counters as an expvar.Var with the given name, so they can be
+// scraped as metrics (e.g. through expvar.Handler). Does nothing if name was already published.
+func PublishAPIVersionUsageExpvar(name string, usage *APIVersionUsageHTTP) {
+	if expvar.Get(name) != nil {
+		return
+	}
+	expvar.Publish(name, usage.counters)
+}
+
+// APIVersionRouterHTTP routes requests to API versions.
+//
+// Versions are selected using (in order) the request path prefix (e.g. /v2/tasks), ConfigVersioningHTTP.VersionHeader
+// (e.g. Accept-Version: v2), a vendor media type in the Accept header (e.g. application/vnd.acme.v2+json) or
+// ConfigVersioningHTTP.DefaultVersion. Requests without prefix are rewritten to the prefixed route of the selected
+// version before routing takes place.
+//
+// Responses carry the API-Version header. Responses of deprecated versions carry Deprecation (RFC 9745) and
+// Sunset (RFC 8594) headers as well.
+type APIVersionRouterHTTP struct {
+	config   ConfigVersioningHTTP
+	usage    *APIVersionUsageHTTP
+	versions []string
+	routes   *RouteMatcher[struct{}]
+}
+
+// NewAPIVersionRouterHTTPParams APIVersionRouterHTTP dependencies.
+type NewAPIVersionRouterHTTPParams struct {
+	fx.In
+
+	Config ConfigVersioningHTTP
+	Usage  *APIVersionUsageHTTP
+}
+
+// NewAPIVersionRouterHTTP allocates a new APIVersionRouterHTTP instance. Versioned routes are registered through
+// RegisterRoutes.
+func NewAPIVersionRouterHTTP(params NewAPIVersionRouterHTTPParams) *APIVersionRouterHTTP {
+	return &APIVersionRouterHTTP{
+		config: params.Config,
+		usage:  params.Usage,
+		routes: NewRouteMatcher[struct{}](),
+	}
+}
+
+// Versions retrieves known API versions.
+func (r *APIVersionRouterHTTP) Versions() []string {
+	return r.versions
+}
+
+// RegisterRoutes registers routes of e prefixed by any of versions (e.g. GET /v1/tasks/:task_id).
+func (r *APIVersionRouterHTTP) RegisterRoutes(e *echo.Echo, versions []string) error {
+	for _, version := range versions {
+		if version != "" && !slices.Contains(r.versions, version) {
+			r.versions = append(r.versions, version)
+		}
+	}
+	for _, route := range e.Routes() {
+		if route.Method == echo.RouteNotFound || parseAPIVersionPrefix(route.Path, r.versions) == "" {
+			continue
+		}
+		path := route.Path
+		// Echo wildcards match any number of segments
+		if strings.HasSuffix(path, "/*") {
+			path += "*"
+		}
+		if err := r.routes.Add(route.Method+" "+path, struct{}{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAPIVersionPrefix retrieves the version prefixing path. Returns an empty string if path has no
+// known version prefix.
+func parseAPIVersionPrefix(path string, versions []string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if slices.Contains(versions, segment) {
+		return segment
+	}
+	return ""
+}
+
+// Pre allocates a middleware routing requests to API versions. Register it using echo.Echo.Pre, so requests are
+// rewritten before routing.
+func (r *APIVersionRouterHTTP) Pre() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			path := echo.GetPath(req)
+			version := parseAPIVersionPrefix(path, r.versions)
+			if version == "" {
+				// only requests to versioned routes are negotiated, so version headers never reject
+				// unversioned routes (e.g. /healthz)
+				if !r.hasVersionedRoute(req.Method, path) {
+					return next(c)
+				}
+				var err error
+				if version, err = r.negotiateVersion(req); err != nil {
+					return err
+				} else if version == "" || !r.routes.Match(req.Method, "/"+version+path) {
+					return next(c)
+				}
+				req.URL.Path = "/" + version + req.URL.Path
+				if req.URL.RawPath != "" {
+					req.URL.RawPath = "/" + version + req.URL.RawPath
+				}
+			}
+
+			c.Set(apiVersionContextKey, version)
+			r.usage.Add(version)
+			r.setResponseHeaders(c.Response().Header(), version)
+			return next(c)
+		}
+	}
+}
+
+// hasVersionedRoute indicates if any version serves method and path (unprefixed).
+func (r *APIVersionRouterHTTP) hasVersionedRoute(method, path string) bool {
+	return slices.ContainsFunc(r.versions, func(version string) bool {
+		return r.routes.Match(method, "/"+version+path)
+	})
+}
+
+// negotiateVersion retrieves the version requested through headers, falling back to the default version.
+func (r *APIVersionRouterHTTP) negotiateVersion(req *http.Request) (string, error) {
+	if version := req.Header.Get(r.config.VersionHeader); version != "" {
+		if !slices.Contains(r.versions, version) {
+			return "", systemerror.NewArgumentNotOneOf(r.config.VersionHeader, r.versions...)
+		}
+		return version, nil
+	}
+	if version := r.parseMediaTypeVersion(req.Header.Get(echo.HeaderAccept)); version != "" {
+		if !slices.Contains(r.versions, version) {
+			return "", systemerror.NewArgumentNotOneOf(echo.HeaderAccept, r.versions...)
+		}
+		return version, nil
+	}
+	return r.config.DefaultVersion, nil
+}
+
+// parseMediaTypeVersion retrieves the version of the first vendor media type in header. Both
+// application/vnd.VENDOR.VERSION+json and application/vnd.VENDOR+json; version=VERSION forms are supported.
+func (r *APIVersionRouterHTTP) parseMediaTypeVersion(header string) string {
+	if r.config.MediaTypeVendor == "" {
+		return ""
+	}
+	vendorPrefix := "vnd." + strings.ToLower(r.config.MediaTypeVendor)
+	for _, mediaRange := range parseAcceptHeader(header) {
+		subtype := strings.ToLower(mediaRange.Subtype)
+		if !strings.EqualFold(mediaRange.Type, "application") || !strings.HasPrefix(subtype, vendorPrefix) {
+			continue
+		}
+		subtype, _, _ = strings.Cut(strings.TrimPrefix(subtype, vendorPrefix), "+")
+		if version, ok := strings.CutPrefix(subtype, "."); ok && version != "" {
+			return version
+		} else if version = mediaRange.Params["version"]; subtype == "" && version != "" {
+			return version
+		}
+	}
+	return ""
+}
+
+func (r *APIVersionRouterHTTP) setResponseHeaders(header http.Header, version string) {
+	header.Set(HeaderAPIVersion, version)
+	header.Add(echo.HeaderVary, r.config.VersionHeader)
+	if r.config.MediaTypeVendor != "" {
+		header.Add(echo.HeaderVary, echo.HeaderAccept)
+	}
+	if sunsetAt, ok := r.config.SunsetTimes[version]; ok {
+		header.Set(HeaderSunset, sunsetAt.UTC().Format(http.TimeFormat))
+	}
+	deprecatedAt, ok := r.config.DeprecationTimes[version]
+	if !ok {
+		return
+	}
+	header.Set(HeaderDeprecation, "@"+strconv.FormatInt(deprecatedAt.Unix(), 10))
+	if r.config.DeprecationLink != "" {
+		header.Add(HeaderLink, "<"+r.config.DeprecationLink+`>; rel="deprecation"`)
+	}
+}
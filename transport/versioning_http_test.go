@@ -0,0 +1,164 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/application"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/transport"
+	"github.com/hadroncorp/geck/versioning"
+)
+
+type taskControllerMultiVersion struct{}
+
+var _ transport.MultiVersionControllerHTTP = taskControllerMultiVersion{}
+
+func (t taskControllerMultiVersion) SetRoutes(_ *echo.Echo) {}
+
+func (t taskControllerMultiVersion) APIVersions() []string {
+	return []string{"v1", "v2"}
+}
+
+func (t taskControllerMultiVersion) SetVersionRoutes(version string, g *echo.Group) {
+	g.GET("/tasks/:task_id", func(c echo.Context) error {
+		return c.String(http.StatusOK, version+":"+transport.GetAPIVersionEcho(c)+":"+c.Param("task_id"))
+	})
+}
+
+func TestAPIVersionRouterHTTP(t *testing.T) {
+	t.Setenv("HTTP_API_DEFAULT_VERSION", "v2")
+	t.Setenv("HTTP_API_MEDIA_TYPE_VENDOR", "acme")
+	t.Setenv("HTTP_API_DEPRECATED_VERSIONS", "v1=2025-01-01T00:00:00Z")
+	t.Setenv("HTTP_API_SUNSET_VERSIONS", "v1=2025-06-30T00:00:00Z")
+	t.Setenv("HTTP_API_DEPRECATION_LINK", "https://docs.example.com/migrations/v2")
+	cfg, err := transport.NewConfigVersioningHTTP()
+	require.NoError(t, err)
+	semver, err := versioning.NewSemanticVersion("v2.1.0")
+	require.NoError(t, err)
+
+	usage := transport.NewAPIVersionUsageHTTP()
+	router := transport.NewAPIVersionRouterHTTP(transport.NewAPIVersionRouterHTTPParams{
+		Config: cfg,
+		Usage:  usage,
+	})
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	require.NoError(t, transport.RegisterControllersEcho(transport.RegisterControllersEchoParams{
+		Echo:                    e,
+		Config:                  application.Config{Semver: semver},
+		Logger:                  logging.NewZerologLoggerAdapter(zerolog.Nop()),
+		VersionRouter:           router,
+		MultiVersionControllers: []transport.MultiVersionControllerHTTP{taskControllerMultiVersion{}},
+	}))
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantBody   string
+		wantDeprec bool
+	}{
+		{
+			name:       "path prefix",
+			path:       "/v1/tasks/123",
+			wantStatus: http.StatusOK,
+			wantBody:   "v1:v1:123",
+			wantDeprec: true,
+		},
+		{
+			name:       "default version",
+			path:       "/tasks/123",
+			wantStatus: http.StatusOK,
+			wantBody:   "v2:v2:123",
+		},
+		{
+			name:       "version header",
+			path:       "/tasks/123",
+			header:     http.Header{"Accept-Version": {"v1"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "v1:v1:123",
+			wantDeprec: true,
+		},
+		{
+			name:       "vendor media type",
+			path:       "/tasks/123",
+			header:     http.Header{echo.HeaderAccept: {"application/vnd.acme.v1+json"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "v1:v1:123",
+			wantDeprec: true,
+		},
+		{
+			name:       "vendor media type parameter",
+			path:       "/tasks/123",
+			header:     http.Header{echo.HeaderAccept: {"application/vnd.acme+json; version=v2"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "v2:v2:123",
+		},
+		{
+			name:       "unknown version",
+			path:       "/tasks/123",
+			header:     http.Header{"Accept-Version": {"v9"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unversioned route",
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unversioned route unknown version",
+			path:       "/healthz",
+			header:     http.Header{"Accept-Version": {"v9"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unversioned route unknown media type version",
+			path:       "/healthz",
+			header:     http.Header{echo.HeaderAccept: {"application/vnd.acme.v9+json"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "escaped path",
+			path:       "/tasks/a%2Fb",
+			header:     http.Header{"Accept-Version": {"v1"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "v1:v1:a%2Fb",
+			wantDeprec: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+			if !tt.wantDeprec {
+				assert.Empty(t, rec.Header().Get(transport.HeaderDeprecation))
+				return
+			}
+			assert.Equal(t, "@1735689600", rec.Header().Get(transport.HeaderDeprecation))
+			assert.Equal(t, "Mon, 30 Jun 2025 00:00:00 GMT", rec.Header().Get(transport.HeaderSunset))
+			assert.Equal(t, `<https://docs.example.com/migrations/v2>; rel="deprecation"`,
+				rec.Header().Get(transport.HeaderLink))
+		})
+	}
+	assert.EqualValues(t, 4, usage.Count("v1"))
+	assert.EqualValues(t, 2, usage.Count("v2"))
+}
@@ -0,0 +1,33 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/webhook"
+)
+
+// NewWebhookVerifierEcho allocates a middleware verifying signatures of received webhook deliveries (see
+// webhook.Verifier) using any of secrets. Deliveries with invalid signatures or stale timestamps are rejected with
+// a systemerror.StatusUnauthenticated error.
+//
+// Request bodies are read entirely, use NewBodyLimitEcho to bound their size.
+func NewWebhookVerifierEcho(verifier webhook.Verifier, secrets ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			if err = verifier.Verify(req.Header, body, secrets...); err != nil {
+				return systemerror.NewUnauthenticated()
+			}
+			return next(c)
+		}
+	}
+}
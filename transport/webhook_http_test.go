@@ -0,0 +1,67 @@
+package transport_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/transport"
+	"github.com/hadroncorp/geck/webhook"
+)
+
+func TestNewWebhookVerifierEcho(t *testing.T) {
+	const secret = "whsec_dGVzdA=="
+	const payload = `{"id":"evt_1","type":"task.created"}`
+	e := echo.New()
+	e.HTTPErrorHandler = transport.HandleEchoError
+	e.POST("/hooks", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(body))
+	}, transport.NewWebhookVerifierEcho(webhook.Verifier{Tolerance: time.Minute}, "whsec_b2xk", secret))
+
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		secret    string
+		expStatus int
+	}{
+		{name: "valid", timestamp: time.Now(), secret: secret, expStatus: http.StatusOK},
+		{name: "invalid signature", timestamp: time.Now(), secret: "whsec_b3RoZXI=", expStatus: http.StatusUnauthorized},
+		{name: "stale timestamp", timestamp: time.Now().Add(-time.Hour), secret: secret,
+			expStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(payload))
+			req.Header.Set(webhook.HeaderID, "msg_1")
+			req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(tt.timestamp.Unix(), 10))
+			signature, err := webhook.Sign(tt.secret, "msg_1", tt.timestamp, []byte(payload))
+			require.NoError(t, err)
+			req.Header.Set(webhook.HeaderSignature, "v1,bogus "+signature)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expStatus, rec.Code)
+			if tt.expStatus == http.StatusOK {
+				assert.Equal(t, payload, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("missing headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(payload))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
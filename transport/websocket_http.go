@@ -0,0 +1,406 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/emirpasic/gods/v2/sets/hashset"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+	"golang.org/x/net/websocket"
+
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/observability/tracing"
+	"github.com/hadroncorp/geck/security"
+)
+
+// ErrConnWebSocketClosed the WebSocket connection is closed.
+var ErrConnWebSocketClosed = errors.New("transport: websocket connection closed")
+
+// ControllerWebSocket is a controller serving WebSocket connections at Path. Messages received through
+// connections are routed to the handlers registered in RegisterHandlers.
+type ControllerWebSocket interface {
+	Path() string
+	RegisterHandlers(router *RouterWebSocket)
+}
+
+// ConnWebSocket is an open WebSocket connection.
+//
+// Messages are written by a dedicated goroutine, hence Send is safe for concurrent use.
+type ConnWebSocket struct {
+	id        string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	principal security.Principal
+	// groups guarded by RegistryWebSocket.
+	groups   *hashset.Set[string]
+	outgoing chan []byte
+}
+
+// ID retrieves the connection identifier.
+func (c *ConnWebSocket) ID() string {
+	return c.id
+}
+
+// Context retrieves the connection context. Holds the security.Principal and tracing span of the upgrade request,
+// and gets cancelled once the connection is closed.
+func (c *ConnWebSocket) Context() context.Context {
+	return c.ctx
+}
+
+// Principal retrieves the security.Principal who opened the connection. Nil if the route skipped authentication.
+func (c *ConnWebSocket) Principal() security.Principal {
+	return c.principal
+}
+
+// Send queues a message of msgType to the client. Blocks until the message is queued, ctx is done or the connection
+// is closed.
+func (c *ConnWebSocket) Send(ctx context.Context, msgType string, data any) error {
+	payload, err := newEnvelopeWebSocket(msgType, "", data)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, payload)
+}
+
+func (c *ConnWebSocket) send(ctx context.Context, payload []byte) error {
+	select {
+	case <-c.ctx.Done():
+		return ErrConnWebSocketClosed
+	default:
+	}
+	select {
+	case c.outgoing <- payload:
+		return nil
+	case <-c.ctx.Done():
+		return ErrConnWebSocketClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *ConnWebSocket) trySend(payload []byte) bool {
+	select {
+	case <-c.ctx.Done():
+		return false
+	default:
+	}
+	select {
+	case c.outgoing <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the connection.
+func (c *ConnWebSocket) Close() {
+	c.cancel()
+}
+
+func newEnvelopeWebSocket(msgType, id string, data any) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(EnvelopeWebSocket{
+		Type: msgType,
+		ID:   id,
+		Data: raw,
+	})
+}
+
+// ServerWebSocket upgrades HTTP requests to WebSocket connections.
+//
+// Upgrades are performed by route handlers, hence after global middlewares (e.g. JWT authentication) ran and the
+// security.Principal is available. Connections are logged when opened and closed, and skipped by
+// NewLogRequestEcho.
+type ServerWebSocket struct {
+	config       ConfigWebSocket
+	serverConfig ConfigHTTP
+	registry     *RegistryWebSocket
+	factoryID    identifier.Factory
+	traceFactory tracing.TraceFactory
+	logger       logging.Logger
+}
+
+// NewServerWebSocketParams ServerWebSocket dependencies.
+type NewServerWebSocketParams struct {
+	fx.In
+
+	Config       ConfigWebSocket
+	ServerConfig ConfigHTTP
+	Registry     *RegistryWebSocket
+	FactoryID    identifier.Factory
+	TraceFactory tracing.TraceFactory `optional:"true"`
+	Logger       logging.Logger
+}
+
+// NewServerWebSocket allocates a new ServerWebSocket instance.
+func NewServerWebSocket(params NewServerWebSocketParams) *ServerWebSocket {
+	return &ServerWebSocket{
+		config:       params.Config,
+		serverConfig: params.ServerConfig,
+		registry:     params.Registry,
+		factoryID:    params.FactoryID,
+		traceFactory: params.TraceFactory,
+		logger:       params.Logger,
+	}
+}
+
+// HandlerEcho allocates an echo.HandlerFunc upgrading requests to WebSocket connections, routing their messages
+// with router.
+func (s *ServerWebSocket) HandlerEcho(router *RouterWebSocket) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		connID, err := s.factoryID.NewIdentifier()
+		if err != nil {
+			return err
+		}
+
+		c.Set(streamContextKey, true)
+		writer := &activityResponseWriter{ResponseWriter: c.Response()}
+		server := websocket.Server{
+			Handshake: s.handshake,
+			Handler: func(ws *websocket.Conn) {
+				s.serve(c, ws, writer.conn, connID, router)
+			},
+		}
+		server.ServeHTTP(writer, c.Request())
+		return nil
+	}
+}
+
+func (s *ServerWebSocket) handshake(cfg *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(cfg, req)
+	if err != nil {
+		return err
+	} else if origin == nil {
+		// non-browser clients
+		return nil
+	}
+	cfg.Origin = origin
+	if len(s.config.AllowedOrigins) == 0 || slices.Contains(s.config.AllowedOrigins, "*") {
+		return nil
+	} else if !slices.Contains(s.config.AllowedOrigins, (&url.URL{Scheme: origin.Scheme, Host: origin.Host}).String()) {
+		return websocket.ErrBadWebSocketOrigin
+	}
+	return nil
+}
+
+func (s *ServerWebSocket) serve(c echo.Context, ws *websocket.Conn, activity *activityConn, connID string,
+	router *RouterWebSocket) {
+	ws.MaxPayloadBytes = s.config.MaxMessageSize
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+	principal, _ := security.GetPrincipalFromContext(ctx)
+	conn := &ConnWebSocket{
+		id:        connID,
+		ctx:       ctx,
+		cancel:    cancel,
+		principal: principal,
+		groups:    hashset.New[string](),
+		outgoing:  make(chan []byte, s.config.SendBufferSize),
+	}
+	s.registry.register(conn)
+	defer s.registry.unregister(conn)
+
+	startTime := time.Now()
+	requestID := c.Response().Header().Get(s.serverConfig.RequestIDTargetHeader)
+	s.logger.Info().
+		WithField("request_id", requestID).
+		WithField("route_path", c.Path()).
+		WithField("connection_id", connID).
+		WriteWithCtx(ctx, "opened websocket connection")
+
+	writeDone := make(chan struct{})
+	go func() {
+		defer close(writeDone)
+		s.writeLoop(conn, ws, activity)
+	}()
+	totalReceived, err := s.readLoop(conn, ws, router)
+	cancel()
+	<-writeDone
+
+	var logEvent logging.Event
+	if err != nil {
+		logEvent = s.logger.Error()
+	} else {
+		logEvent = s.logger.Info()
+	}
+	logEvent.
+		WithField("request_id", requestID).
+		WithField("route_path", c.Path()).
+		WithField("connection_id", connID).
+		WithField("messages_received", totalReceived).
+		WithField("duration", time.Since(startTime)).
+		WithField("error", err).
+		WriteWithCtx(c.Request().Context(), "closed websocket connection")
+}
+
+// readLoop reads and dispatches messages sequentially until the connection is closed. Returns unexpected
+// read errors only.
+func (s *ServerWebSocket) readLoop(conn *ConnWebSocket, ws *websocket.Conn, router *RouterWebSocket) (int, error) {
+	total := 0
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(ws, &raw); err != nil {
+			if conn.ctx.Err() != nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return total, nil
+			}
+			return total, err
+		}
+		total++
+
+		var msg EnvelopeWebSocket
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			s.sendError(conn, "", err)
+			continue
+		}
+		var msgCtx context.Context
+		if s.traceFactory != nil {
+			msgCtx = s.traceFactory.NewTracedContext(conn.ctx)
+		} else {
+			msgCtx = tracing.NewTracedContext(conn.ctx)
+		}
+		if err := router.dispatch(msgCtx, conn, msg); err != nil {
+			s.logger.WithError(err).
+				WithField("connection_id", conn.id).
+				WithField("message_type", msg.Type).
+				WriteWithCtx(msgCtx, "failed to handle websocket message")
+			s.sendError(conn, msg.ID, err)
+		}
+	}
+}
+
+func (s *ServerWebSocket) sendError(conn *ConnWebSocket, msgID string, err error) {
+	payload, errEnvelope := newEnvelopeWebSocket(MessageTypeErrorWebSocket, msgID, convertContainerErrorsEcho(err))
+	if errEnvelope != nil {
+		return
+	}
+	_ = conn.send(conn.ctx, payload)
+}
+
+// writeLoop writes queued messages and keepalive pings until the connection is closed. Closes the underlying
+// connection once done, unblocking readLoop.
+func (s *ServerWebSocket) writeLoop(conn *ConnWebSocket, ws *websocket.Conn, activity *activityConn) {
+	defer ws.Close()
+
+	var pings <-chan time.Time
+	if s.config.PingInterval > 0 {
+		ticker := time.NewTicker(s.config.PingInterval)
+		defer ticker.Stop()
+		pings = ticker.C
+	}
+	for {
+		select {
+		case <-conn.ctx.Done():
+			return
+		case payload := <-conn.outgoing:
+			s.setWriteDeadline(ws)
+			if err := websocket.Message.Send(ws, string(payload)); err != nil {
+				conn.Close()
+				return
+			}
+		case <-pings:
+			// pongs are consumed by the underlying websocket reader, so any inbound traffic counts as liveness
+			if activity != nil && time.Since(activity.lastReadTime()) > s.config.PingInterval+s.config.PongTimeout {
+				s.logger.Info().
+					WithField("connection_id", conn.id).
+					WriteWithCtx(conn.ctx, "closing unresponsive websocket connection")
+				conn.Close()
+				return
+			}
+			s.setWriteDeadline(ws)
+			ws.PayloadType = websocket.PingFrame
+			_, err := ws.Write(nil)
+			ws.PayloadType = websocket.TextFrame
+			if err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *ServerWebSocket) setWriteDeadline(ws *websocket.Conn) {
+	if s.config.WriteTimeout > 0 {
+		_ = ws.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout))
+	}
+}
+
+// activityResponseWriter is a http.ResponseWriter tracking reads of hijacked connections.
+type activityResponseWriter struct {
+	http.ResponseWriter
+	conn *activityConn
+}
+
+func (w *activityResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.conn = &activityConn{Conn: conn}
+	w.conn.touch()
+	var reader io.Reader = w.conn
+	if buffered := rw.Reader.Buffered(); buffered > 0 {
+		// bytes already read by the HTTP server must be consumed first
+		peeked, _ := rw.Reader.Peek(buffered)
+		reader = io.MultiReader(bytes.NewReader(bytes.Clone(peeked)), w.conn)
+	}
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(reader), rw.Writer), nil
+}
+
+// activityConn is a net.Conn registering the last time data was read.
+type activityConn struct {
+	net.Conn
+	lastRead atomic.Int64
+}
+
+func (c *activityConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.touch()
+	}
+	return n, err
+}
+
+func (c *activityConn) touch() {
+	c.lastRead.Store(time.Now().UnixNano())
+}
+
+func (c *activityConn) lastReadTime() time.Time {
+	return time.Unix(0, c.lastRead.Load())
+}
+
+// RegisterControllersWebSocketParams RegisterControllersWebSocket dependencies.
+type RegisterControllersWebSocketParams struct {
+	fx.In
+
+	Echo        *echo.Echo
+	Server      *ServerWebSocket
+	Logger      logging.Logger
+	Controllers []ControllerWebSocket `group:"controllers_websocket"`
+}
+
+// RegisterControllersWebSocket mounts every ControllerWebSocket into Echo.
+func RegisterControllersWebSocket(params RegisterControllersWebSocketParams) {
+	params.Logger.Info().
+		WithField("total_controllers", len(params.Controllers)).
+		Write("registering websocket controllers")
+	for _, controller := range params.Controllers {
+		router := NewRouterWebSocket()
+		controller.RegisterHandlers(router)
+		params.Echo.GET(controller.Path(), params.Server.HandlerEcho(router))
+	}
+}
@@ -0,0 +1,138 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"golang.org/x/net/websocket"
+
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/security"
+	"github.com/hadroncorp/geck/transport"
+)
+
+type echoMessage struct {
+	Text string `json:"text"`
+}
+
+type echoControllerWebSocket struct {
+	registry *transport.RegistryWebSocket
+}
+
+func (e echoControllerWebSocket) Path() string {
+	return "/ws"
+}
+
+func (e echoControllerWebSocket) RegisterHandlers(router *transport.RouterWebSocket) {
+	transport.HandleWebSocket(router, "echo",
+		func(ctx context.Context, conn *transport.ConnWebSocket, data echoMessage) error {
+			return conn.Send(ctx, "echoed", data)
+		})
+	transport.HandleWebSocket(router, "join",
+		func(_ context.Context, conn *transport.ConnWebSocket, data echoMessage) error {
+			e.registry.Join(conn, data.Text)
+			return conn.Send(conn.Context(), "joined", data)
+		})
+}
+
+func TestServerWebSocket(t *testing.T) {
+	lifecycle := fxtest.NewLifecycle(t)
+	logger := logging.NewZerologLoggerAdapter(zerolog.Nop())
+	registry := transport.NewRegistryWebSocket(transport.NewRegistryWebSocketParams{
+		Lifecycle: lifecycle,
+		Logger:    logger,
+	})
+	server := transport.NewServerWebSocket(transport.NewServerWebSocketParams{
+		Config: transport.ConfigWebSocket{
+			PingInterval:   time.Second,
+			PongTimeout:    time.Second,
+			WriteTimeout:   time.Second,
+			MaxMessageSize: 1024,
+			SendBufferSize: 8,
+		},
+		Registry:  registry,
+		FactoryID: identifier.NewFactoryUUID(),
+		Logger:    logger,
+	})
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := context.WithValue(c.Request().Context(), security.PrincipalContextKey,
+				security.PrincipalTemplate{Identifier: "user-1"})
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
+	transport.RegisterControllersWebSocket(transport.RegisterControllersWebSocketParams{
+		Echo:        e,
+		Server:      server,
+		Logger:      logger,
+		Controllers: []transport.ControllerWebSocket{echoControllerWebSocket{registry: registry}},
+	})
+	httpServer := httptest.NewServer(e)
+	defer httpServer.Close()
+	lifecycle.RequireStart()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/ws", "", httpServer.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+	receive := func() transport.EnvelopeWebSocket {
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(time.Second)))
+		var msg transport.EnvelopeWebSocket
+		require.NoError(t, websocket.JSON.Receive(ws, &msg))
+		return msg
+	}
+
+	t.Run("typed handler", func(t *testing.T) {
+		require.NoError(t, websocket.JSON.Send(ws, transport.EnvelopeWebSocket{
+			Type: "echo",
+			Data: json.RawMessage(`{"text":"hello"}`),
+		}))
+		msg := receive()
+		assert.Equal(t, "echoed", msg.Type)
+		assert.JSONEq(t, `{"text":"hello"}`, string(msg.Data))
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		require.NoError(t, websocket.JSON.Send(ws, transport.EnvelopeWebSocket{Type: "unknown", ID: "msg-1"}))
+		msg := receive()
+		assert.Equal(t, transport.MessageTypeErrorWebSocket, msg.Type)
+		assert.Equal(t, "msg-1", msg.ID)
+		assert.Contains(t, string(msg.Data), "INVALID_ARGUMENT")
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		require.NoError(t, websocket.JSON.Send(ws, transport.EnvelopeWebSocket{
+			Type: "join",
+			Data: json.RawMessage(`{"text":"tasks"}`),
+		}))
+		assert.Equal(t, "joined", receive().Type)
+
+		total, err := registry.SendToGroup(context.Background(), "tasks", "task_updated", echoMessage{Text: "1"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "task_updated", receive().Type)
+
+		total, err = registry.SendToUser(context.Background(), "user-1", "notification", echoMessage{Text: "2"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "notification", receive().Type)
+	})
+
+	t.Run("graceful close", func(t *testing.T) {
+		lifecycle.RequireStop()
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(time.Second)))
+		var msg transport.EnvelopeWebSocket
+		assert.Error(t, websocket.JSON.Receive(ws, &msg))
+	})
+}
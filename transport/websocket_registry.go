@@ -0,0 +1,212 @@
+package transport
+
+import (
+	"context"
+	"sync"
+
+	"github.com/emirpasic/gods/v2/sets/hashset"
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/shutdown"
+)
+
+// RegistryWebSocket keeps track of open WebSocket connections, letting components send messages to specific
+// users or groups of connections.
+//
+// Connections are closed gracefully when the application stops.
+type RegistryWebSocket struct {
+	logger logging.Logger
+
+	mu          sync.RWMutex
+	connections map[string]*ConnWebSocket
+	users       map[string]*hashset.Set[string]
+	groups      map[string]*hashset.Set[string]
+	wg          sync.WaitGroup
+}
+
+// NewRegistryWebSocketParams RegistryWebSocket dependencies.
+type NewRegistryWebSocketParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Logger    logging.Logger
+	// Coordinator closes connections during shutdown.PhaseServers (hijacked connections are not drained by
+	// servers). Connections are closed once the application stops if not provided.
+	Coordinator *shutdown.Coordinator `optional:"true"`
+}
+
+// NewRegistryWebSocket allocates a new RegistryWebSocket instance.
+func NewRegistryWebSocket(params NewRegistryWebSocketParams) *RegistryWebSocket {
+	registry := &RegistryWebSocket{
+		logger:      params.Logger,
+		connections: make(map[string]*ConnWebSocket),
+		users:       make(map[string]*hashset.Set[string]),
+		groups:      make(map[string]*hashset.Set[string]),
+	}
+	shutdown.Append(params.Lifecycle, params.Coordinator, shutdown.PhaseServers, "websocket_registry", fx.Hook{
+		OnStop: registry.Close,
+	})
+	return registry
+}
+
+func (r *RegistryWebSocket) register(conn *ConnWebSocket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.wg.Add(1)
+	r.connections[conn.id] = conn
+	if conn.principal != nil {
+		addToIndex(r.users, conn.principal.ID(), conn.id)
+	}
+}
+
+func (r *RegistryWebSocket) unregister(conn *ConnWebSocket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.connections[conn.id]; !ok {
+		return
+	}
+	delete(r.connections, conn.id)
+	if conn.principal != nil {
+		removeFromIndex(r.users, conn.principal.ID(), conn.id)
+	}
+	for _, group := range conn.groups.Values() {
+		removeFromIndex(r.groups, group, conn.id)
+	}
+	r.wg.Done()
+}
+
+func addToIndex(index map[string]*hashset.Set[string], key, connID string) {
+	set, ok := index[key]
+	if !ok {
+		set = hashset.New[string]()
+		index[key] = set
+	}
+	set.Add(connID)
+}
+
+func removeFromIndex(index map[string]*hashset.Set[string], key, connID string) {
+	set, ok := index[key]
+	if !ok {
+		return
+	}
+	set.Remove(connID)
+	if set.Empty() {
+		delete(index, key)
+	}
+}
+
+// Get retrieves an open connection by its identifier.
+func (r *RegistryWebSocket) Get(connID string) (*ConnWebSocket, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conn, ok := r.connections[connID]
+	return conn, ok
+}
+
+// Join adds conn to the given groups.
+func (r *RegistryWebSocket) Join(conn *ConnWebSocket, groups ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.connections[conn.id]; !ok {
+		return
+	}
+	for _, group := range groups {
+		conn.groups.Add(group)
+		addToIndex(r.groups, group, conn.id)
+	}
+}
+
+// Leave removes conn from the given groups.
+func (r *RegistryWebSocket) Leave(conn *ConnWebSocket, groups ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, group := range groups {
+		conn.groups.Remove(group)
+		removeFromIndex(r.groups, group, conn.id)
+	}
+}
+
+// SendToUser sends a message to every connection opened by the principal with the given identifier. Returns the
+// number of connections the message was queued to.
+func (r *RegistryWebSocket) SendToUser(ctx context.Context, principalID, msgType string, data any) (int, error) {
+	return r.sendToIndex(ctx, r.users, principalID, msgType, data)
+}
+
+// SendToGroup sends a message to every connection within group. Returns the number of connections the message
+// was queued to.
+func (r *RegistryWebSocket) SendToGroup(ctx context.Context, group, msgType string, data any) (int, error) {
+	return r.sendToIndex(ctx, r.groups, group, msgType, data)
+}
+
+// Broadcast sends a message to every open connection. Returns the number of connections the message was queued to.
+func (r *RegistryWebSocket) Broadcast(ctx context.Context, msgType string, data any) (int, error) {
+	r.mu.RLock()
+	conns := make([]*ConnWebSocket, 0, len(r.connections))
+	for _, conn := range r.connections {
+		conns = append(conns, conn)
+	}
+	r.mu.RUnlock()
+	return r.broadcast(ctx, conns, msgType, data)
+}
+
+func (r *RegistryWebSocket) sendToIndex(ctx context.Context, index map[string]*hashset.Set[string], key,
+	msgType string, data any) (int, error) {
+	r.mu.RLock()
+	set, ok := index[key]
+	if !ok {
+		r.mu.RUnlock()
+		return 0, nil
+	}
+	conns := make([]*ConnWebSocket, 0, set.Size())
+	for _, connID := range set.Values() {
+		conns = append(conns, r.connections[connID])
+	}
+	r.mu.RUnlock()
+	return r.broadcast(ctx, conns, msgType, data)
+}
+
+// broadcast queues a message to conns without blocking. Connections unable to keep up with queued messages
+// are closed, so a single slow client cannot delay the rest.
+func (r *RegistryWebSocket) broadcast(ctx context.Context, conns []*ConnWebSocket, msgType string,
+	data any) (int, error) {
+	payload, err := newEnvelopeWebSocket(msgType, "", data)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, conn := range conns {
+		if conn.trySend(payload) {
+			total++
+			continue
+		}
+		r.logger.Error().
+			WithField("connection_id", conn.id).
+			WriteWithCtx(ctx, "closing slow websocket connection")
+		conn.Close()
+	}
+	return total, nil
+}
+
+// Close closes every open connection, waiting until they are released or ctx is done.
+func (r *RegistryWebSocket) Close(ctx context.Context) error {
+	r.mu.RLock()
+	total := len(r.connections)
+	for _, conn := range r.connections {
+		conn.Close()
+	}
+	r.mu.RUnlock()
+	r.logger.Info().WithField("total_connections", total).WriteWithCtx(ctx, "closing websocket connections")
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
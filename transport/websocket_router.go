@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// MessageTypeErrorWebSocket type of envelopes notifying clients about failed messages. Envelope data holds
+// Errors.
+const MessageTypeErrorWebSocket = "error"
+
+// EnvelopeWebSocket is the JSON structure of every WebSocket message, routed by its type.
+type EnvelopeWebSocket struct {
+	Type string `json:"type"`
+	// ID optional client-generated identifier, echoed in error envelopes to correlate failures.
+	ID   string          `json:"id,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// HandlerFuncWebSocket handles WebSocket messages of a specific type.
+type HandlerFuncWebSocket func(ctx context.Context, conn *ConnWebSocket, msg EnvelopeWebSocket) error
+
+// RouterWebSocket routes WebSocket messages to handlers by their envelope type.
+type RouterWebSocket struct {
+	handlers map[string]HandlerFuncWebSocket
+}
+
+// NewRouterWebSocket allocates an empty RouterWebSocket.
+func NewRouterWebSocket() *RouterWebSocket {
+	return &RouterWebSocket{
+		handlers: make(map[string]HandlerFuncWebSocket),
+	}
+}
+
+// Handle registers handler for messages of msgType. Registering the same type twice overrides its handler.
+func (r *RouterWebSocket) Handle(msgType string, handler HandlerFuncWebSocket) {
+	r.handlers[msgType] = handler
+}
+
+// HandleWebSocket registers a typed handler for messages of msgType. Envelope data is decoded into T, malformed
+// data is reported as systemerror invalid-argument errors.
+func HandleWebSocket[T any](router *RouterWebSocket, msgType string,
+	handler func(ctx context.Context, conn *ConnWebSocket, data T) error) {
+	router.Handle(msgType, func(ctx context.Context, conn *ConnWebSocket, msg EnvelopeWebSocket) error {
+		var data T
+		if len(msg.Data) > 0 {
+			if err := json.Unmarshal(msg.Data, &data); err != nil {
+				return systemerror.NewMalformedArgument("data", err.Error())
+			}
+		}
+		return handler(ctx, conn, data)
+	})
+}
+
+func (r *RouterWebSocket) dispatch(ctx context.Context, conn *ConnWebSocket, msg EnvelopeWebSocket) error {
+	handler, ok := r.handlers[msg.Type]
+	if !ok {
+		types := make([]string, 0, len(r.handlers))
+		for msgType := range r.handlers {
+			types = append(types, msgType)
+		}
+		sort.Strings(types)
+		return systemerror.NewArgumentNotOneOf("type", types...)
+	}
+	return handler(ctx, conn, msg)
+}
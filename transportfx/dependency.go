@@ -1,6 +1,7 @@
 package transportfx
 
 import (
+	"github.com/caarlos0/env/v11"
 	"go.uber.org/fx"
 
 	"github.com/hadroncorp/geck/observability/logging"
@@ -22,12 +23,27 @@ func AsVersionedControllerHTTP(t any) any {
 	)
 }
 
+func AsMultiVersionControllerHTTP(t any) any {
+	return fx.Annotate(t,
+		fx.As(new(transport.MultiVersionControllerHTTP)),
+		fx.ResultTags(`group:"multiversion_controllers_http"`),
+	)
+}
+
 func AsMiddlewareHTTP(t any) any {
 	return fx.Annotate(t,
 		fx.ResultTags(`group:"middlewares_http"`),
 	)
 }
 
+// AsAuthenticatedMiddlewareHTTP registers an HTTP middleware running after every middleware registered with
+// AsMiddlewareHTTP (e.g. authenticators).
+func AsAuthenticatedMiddlewareHTTP(t any) any {
+	return fx.Annotate(t,
+		fx.ResultTags(`group:"authenticated_middlewares_http"`),
+	)
+}
+
 func AsMiddlewaresHTTP(t any) any {
 	return fx.Annotate(t,
 		fx.ResultTags(`group:"middlewares_groups_http"`),
@@ -62,5 +78,203 @@ var TransportJWTModuleHTTP = fx.Module("transport_http_jwt",
 	fx.Provide(
 		transport.NewEchoJWTAuthenticatorConfig,
 		AsMiddlewareHTTP(transport.NewEchoJWTAuthenticator),
+		AsOpenAPISecuritySchemeHTTP(transport.NewOpenAPISecuritySchemeJWT),
+	),
+)
+
+var TransportVersioningModuleHTTP = fx.Module("transport_http_versioning",
+	fx.Provide(
+		transport.NewConfigVersioningHTTP,
+		transport.NewAPIVersionUsageHTTP,
+		transport.NewAPIVersionRouterHTTP,
+	),
+	fx.Invoke(
+		func(usage *transport.APIVersionUsageHTTP) {
+			transport.PublishAPIVersionUsageExpvar("geck.transport.http.api_versions", usage)
+		},
+	),
+)
+
+// TransportOperationModuleHTTP serves long-running operations. Requires an operation.Manager (see operationfx).
+var TransportOperationModuleHTTP = fx.Module("transport_http_operation",
+	fx.Provide(
+		AsVersionedControllerHTTP(transport.NewOperationControllerHTTP),
+	),
+)
+
+var TransportResponseCacheModuleHTTP = fx.Module("transport_http_response_cache",
+	fx.Provide(
+		env.ParseAs[transport.ConfigResponseCacheHTTP],
+		transport.NewResponseCacheHTTP,
+	),
+)
+
+// TransportJSONSchemaModuleHTTP validates requests (and responses) of routes registered in transport.JSONSchemaHTTP.
+var TransportJSONSchemaModuleHTTP = fx.Module("transport_http_json_schema",
+	fx.Provide(
+		env.ParseAs[transport.ConfigJSONSchemaHTTP],
+		transport.NewJSONSchemaHTTP,
+		AsMiddlewareHTTP(transport.NewJSONSchemaEcho),
+	),
+)
+
+// TransportUploadModuleHTTP provides a transport.UploaderHTTP. Requires a storage.Bucket (see storagefx).
+var TransportUploadModuleHTTP = fx.Module("transport_http_upload",
+	fx.Provide(
+		env.ParseAs[transport.ConfigUploadHTTP],
+		transport.NewUploaderHTTP,
+	),
+)
+
+var TransportSSEModuleHTTP = fx.Module("transport_http_sse",
+	fx.Provide(
+		env.ParseAs[transport.ConfigSSE],
+		transport.NewStreamerSSE,
+	),
+)
+
+func AsControllerWebSocket(t any) any {
+	return fx.Annotate(t,
+		fx.As(new(transport.ControllerWebSocket)),
+		fx.ResultTags(`group:"controllers_websocket"`),
+	)
+}
+
+var TransportWebSocketModuleHTTP = fx.Module("transport_http_websocket",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("transport.websocket"),
+	),
+	fx.Provide(
+		env.ParseAs[transport.ConfigWebSocket],
+		transport.NewRegistryWebSocket,
+		transport.NewServerWebSocket,
+	),
+	fx.Invoke(
+		transport.RegisterControllersWebSocket,
+	),
+)
+
+var TransportClientModuleHTTP = fx.Module("transport_http_client",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("transport.http.client"),
+	),
+	fx.Provide(
+		transport.NewConfigClientHTTP,
+		transport.NewClientHTTP,
+	),
+)
+
+// TransportRateLimitModuleHTTP limits requests using transport.ConfigRateLimitHTTP. Runs after authenticators so
+// the principal key strategy identifies authenticated clients.
+var TransportRateLimitModuleHTTP = fx.Module("transport_http_rate_limit",
+	fx.Provide(
+		transport.NewConfigRateLimitHTTP,
+		AsAuthenticatedMiddlewareHTTP(transport.NewRateLimitEcho),
+	),
+)
+
+func AsControllerGRPC(t any) any {
+	return fx.Annotate(t,
+		fx.As(new(transport.ControllerGRPC)),
+		fx.ResultTags(`group:"controllers_grpc"`),
+	)
+}
+
+func AsUnaryInterceptorGRPC(t any) any {
+	return fx.Annotate(t,
+		fx.ResultTags(`group:"unary_interceptors_grpc"`),
+	)
+}
+
+func AsUnaryInterceptorsGRPC(t any) any {
+	return fx.Annotate(t,
+		fx.ResultTags(`group:"unary_interceptors_groups_grpc"`),
+	)
+}
+
+func AsStreamInterceptorGRPC(t any) any {
+	return fx.Annotate(t,
+		fx.ResultTags(`group:"stream_interceptors_grpc"`),
+	)
+}
+
+func AsStreamInterceptorsGRPC(t any) any {
+	return fx.Annotate(t,
+		fx.ResultTags(`group:"stream_interceptors_groups_grpc"`),
+	)
+}
+
+func AsServerOptionGRPC(t any) any {
+	return fx.Annotate(t,
+		fx.ResultTags(`group:"server_options_grpc"`),
+	)
+}
+
+var TransportModuleGRPC = fx.Module("transport_grpc",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("transport.grpc"),
+	),
+	fx.Provide(
+		transport.NewConfigGRPC,
+		transport.NewServerGRPC,
+		// interceptors
+		AsUnaryInterceptorsGRPC(transport.NewDefaultUnaryInterceptorGroupGRPC),
+		AsStreamInterceptorsGRPC(transport.NewDefaultStreamInterceptorGroupGRPC),
+		// controllers
+		AsControllerGRPC(transport.NewHealthControllerGRPC),
+	),
+	fx.Invoke(
+		transport.RegisterControllersGRPC,
+		func(logger logging.Logger, cfg transport.ConfigGRPC) {
+			logger.Debug().
+				WithField("methods", cfg.AuthenticationWhitelist).
+				Write("skipping security in specified methods")
+		},
+	),
+)
+
+var TransportJWTModuleGRPC = fx.Module("transport_grpc_jwt",
+	fx.Provide(
+		transport.NewJWTAuthenticatorGRPC,
+		AsUnaryInterceptorGRPC(transport.NewJWTAuthenticatorUnaryGRPC),
+		AsStreamInterceptorGRPC(transport.NewJWTAuthenticatorStreamGRPC),
+	),
+)
+
+func AsOpenAPISecuritySchemeHTTP(t any) any {
+	return fx.Annotate(t,
+		fx.ResultTags(`group:"openapi_security_schemes_http"`),
+	)
+}
+
+var TransportOpenAPIModuleHTTP = fx.Module("transport_http_openapi",
+	fx.Provide(
+		env.ParseAs[transport.ConfigOpenAPI],
+		transport.NewOpenAPIDocument,
+	),
+	fx.Invoke(
+		transport.RegisterOpenAPIControllerHTTP,
+	),
+)
+
+var TransportIdempotencyCacheModuleHTTP = fx.Module("transport_http_idempotency_cache",
+	fx.Provide(
+		transport.NewConfigIdempotencyHTTP,
+		fx.Annotate(
+			transport.NewIdempotencyStoreCache,
+			fx.As(new(transport.IdempotencyStore)),
+		),
+		AsAuthenticatedMiddlewareHTTP(transport.NewIdempotencyEcho),
+	),
+)
+
+var TransportIdempotencyPostgresModuleHTTP = fx.Module("transport_http_idempotency_postgres",
+	fx.Provide(
+		transport.NewConfigIdempotencyHTTP,
+		fx.Annotate(
+			transport.NewIdempotencyStorePostgres,
+			fx.As(new(transport.IdempotencyStore)),
+		),
+		AsAuthenticatedMiddlewareHTTP(transport.NewIdempotencyEcho),
 	),
 )
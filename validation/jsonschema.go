@@ -0,0 +1,534 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+var jsonSchemaUUIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// jsonSchemaFormats format checks. Unknown formats are ignored (annotations only, as stated by the specification).
+var jsonSchemaFormats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	},
+	"uuid": jsonSchemaUUIDRegex.MatchString,
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	},
+	"ipv6": func(s string) bool {
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	},
+}
+
+// JSONSchema is a compiled JSON Schema (draft 2020-12) document.
+//
+// Supported keywords are: type, enum, const, properties, required, additionalProperties, minProperties,
+// maxProperties, items, minItems, maxItems, uniqueItems, minLength, maxLength, pattern, format (date-time, date,
+// email, uuid, uri, ipv4 and ipv6), minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf,
+// oneOf, not and $ref (local references only, e.g. #/$defs/address). Unknown keywords are ignored.
+type JSONSchema struct {
+	root *jsonSchemaNode
+}
+
+// jsonSchemaNode a schema (or sub-schema) as decoded from JSON.
+type jsonSchemaNode struct {
+	Ref                  string                     `json:"$ref"`
+	Defs                 map[string]*jsonSchemaNode `json:"$defs"`
+	Definitions          map[string]*jsonSchemaNode `json:"definitions"`
+	Type                 jsonSchemaTypes            `json:"type"`
+	Enum                 []any                      `json:"enum"`
+	Const                json.RawMessage            `json:"const"`
+	Properties           map[string]*jsonSchemaNode `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties *jsonSchemaNode            `json:"additionalProperties"`
+	MinProperties        *int                       `json:"minProperties"`
+	MaxProperties        *int                       `json:"maxProperties"`
+	Items                *jsonSchemaNode            `json:"items"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	UniqueItems          bool                       `json:"uniqueItems"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	Pattern              string                     `json:"pattern"`
+	Format               string                     `json:"format"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+	MultipleOf           *float64                   `json:"multipleOf"`
+	AllOf                []*jsonSchemaNode          `json:"allOf"`
+	AnyOf                []*jsonSchemaNode          `json:"anyOf"`
+	OneOf                []*jsonSchemaNode          `json:"oneOf"`
+	Not                  *jsonSchemaNode            `json:"not"`
+
+	// boolean schemas (true accepts everything, false rejects everything).
+	boolean    *bool
+	pattern    *regexp.Regexp
+	ref        *jsonSchemaNode
+	constValue any
+}
+
+type jsonSchemaTypes []string
+
+func (t *jsonSchemaTypes) UnmarshalJSON(raw []byte) error {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		*t = jsonSchemaTypes{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+func (n *jsonSchemaNode) UnmarshalJSON(raw []byte) error {
+	switch string(bytes.TrimSpace(raw)) {
+	case "true", "false":
+		val := string(bytes.TrimSpace(raw)) == "true"
+		n.boolean = &val
+		return nil
+	}
+	type alias jsonSchemaNode
+	return json.Unmarshal(raw, (*alias)(n))
+}
+
+// NewJSONSchema compiles a JSON Schema document. Returns an error if the document is malformed, a pattern is not
+// a valid regular expression, a reference could not be resolved or references form a cycle not consuming the
+// validated value (e.g. {"$ref":"#"}).
+func NewJSONSchema(raw []byte) (*JSONSchema, error) {
+	root := &jsonSchemaNode{}
+	if err := json.Unmarshal(raw, root); err != nil {
+		return nil, fmt.Errorf("validation: malformed json schema: %w", err)
+	}
+	nodes := make(map[*jsonSchemaNode]struct{})
+	if err := root.compile(root, nodes); err != nil {
+		return nil, err
+	}
+	if err := checkJSONSchemaCycles(nodes); err != nil {
+		return nil, err
+	}
+	return &JSONSchema{root: root}, nil
+}
+
+// MustJSONSchema compiles a JSON Schema document (see NewJSONSchema), panicking if it is invalid. Use it to declare
+// schemas as package variables.
+func MustJSONSchema(raw []byte) *JSONSchema {
+	schema, err := NewJSONSchema(raw)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func (n *jsonSchemaNode) compile(root *jsonSchemaNode, visited map[*jsonSchemaNode]struct{}) error {
+	if n == nil || n.boolean != nil {
+		return nil
+	} else if _, ok := visited[n]; ok {
+		return nil
+	}
+	visited[n] = struct{}{}
+
+	var err error
+	if n.Pattern != "" {
+		if n.pattern, err = regexp.Compile(n.Pattern); err != nil {
+			return fmt.Errorf("validation: invalid json schema pattern %q: %w", n.Pattern, err)
+		}
+	}
+	if len(n.Const) > 0 {
+		if err = json.Unmarshal(n.Const, &n.constValue); err != nil {
+			return err
+		}
+	}
+	if n.Ref != "" {
+		if n.ref, err = resolveJSONSchemaRef(root, n.Ref); err != nil {
+			return err
+		}
+	}
+
+	children := make([]*jsonSchemaNode, 0, len(n.Properties)+len(n.Defs)+len(n.Definitions)+
+		len(n.AllOf)+len(n.AnyOf)+len(n.OneOf)+3)
+	for _, child := range n.Defs {
+		children = append(children, child)
+	}
+	for _, child := range n.Definitions {
+		children = append(children, child)
+	}
+	for _, child := range n.Properties {
+		children = append(children, child)
+	}
+	children = append(children, n.AllOf...)
+	children = append(children, n.AnyOf...)
+	children = append(children, n.OneOf...)
+	children = append(children, n.AdditionalProperties, n.Items, n.Not)
+	for _, child := range children {
+		if err = child.compile(root, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inPlace returns the sub-schemas applied to the same value as n (i.e. $ref, allOf, anyOf, oneOf and not), as
+// opposed to the ones applied to its properties or items.
+func (n *jsonSchemaNode) inPlace() []*jsonSchemaNode {
+	out := make([]*jsonSchemaNode, 0, len(n.AllOf)+len(n.AnyOf)+len(n.OneOf)+2)
+	out = append(out, n.ref)
+	out = append(out, n.AllOf...)
+	out = append(out, n.AnyOf...)
+	out = append(out, n.OneOf...)
+	return append(out, n.Not)
+}
+
+// checkJSONSchemaCycles returns an error if any of the compiled nodes reaches itself through in-place sub-schemas
+// only, as validating a value against it would never end. Recursive schemas are allowed as long as every cycle
+// descends into properties or items (e.g. {"properties":{"children":{"items":{"$ref":"#"}}}}).
+func checkJSONSchemaCycles(nodes map[*jsonSchemaNode]struct{}) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	states := make(map[*jsonSchemaNode]int, len(nodes))
+	var visit func(n *jsonSchemaNode, ref string) error
+	visit = func(n *jsonSchemaNode, ref string) error {
+		if n == nil || n.boolean != nil {
+			return nil
+		}
+		switch states[n] {
+		case visiting:
+			return fmt.Errorf("validation: cyclic json schema reference %q", ref)
+		case visited:
+			return nil
+		}
+		states[n] = visiting
+		for _, child := range n.inPlace() {
+			childRef := ref
+			if child != nil && child == n.ref {
+				childRef = n.Ref
+			}
+			if err := visit(child, childRef); err != nil {
+				return err
+			}
+		}
+		states[n] = visited
+		return nil
+	}
+	for n := range nodes {
+		if err := visit(n, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveJSONSchemaRef resolves local references (JSON pointers within the root document).
+func resolveJSONSchemaRef(root *jsonSchemaNode, ref string) (*jsonSchemaNode, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("validation: unsupported json schema reference %q, only local references are allowed",
+			ref)
+	} else if pointer == "" {
+		return root, nil
+	}
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	if len(segments) != 2 {
+		return nil, fmt.Errorf("validation: unsupported json schema reference %q", ref)
+	}
+	name := strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[1])
+	var node *jsonSchemaNode
+	switch segments[0] {
+	case "$defs":
+		node = root.Defs[name]
+	case "definitions":
+		node = root.Definitions[name]
+	}
+	if node == nil {
+		return nil, fmt.Errorf("validation: unresolved json schema reference %q", ref)
+	}
+	return node, nil
+}
+
+// ValidateJSON validates a JSON document (see Validate).
+func (s *JSONSchema) ValidateJSON(raw []byte) error {
+	var instance any
+	if err := json.Unmarshal(raw, &instance); err != nil {
+		return systemerror.NewMalformedArgument(jsonPointerArgument(""), err.Error())
+	}
+	return s.Validate(instance)
+}
+
+// Validate validates a decoded JSON value (i.e. produced by json.Unmarshal into an any value).
+//
+// Violations are returned joined, as systemerror invalid argument and out of range errors (the same ones
+// go-playground validation errors are translated to). Fields are named after their JSON pointer
+// (e.g. /metadata/tags/0), using / for the root value.
+func (s *JSONSchema) Validate(instance any) error {
+	errs := make([]error, 0)
+	s.root.validate(instance, "", &errs)
+	return errors.Join(errs...)
+}
+
+// matches indicates if instance is valid against n, without collecting errors.
+func (n *jsonSchemaNode) matches(instance any) bool {
+	errs := make([]error, 0)
+	n.validate(instance, "", &errs)
+	return len(errs) == 0
+}
+
+func (n *jsonSchemaNode) validate(instance any, pointer string, errs *[]error) {
+	field := jsonPointerArgument(pointer)
+	if n.boolean != nil {
+		if !*n.boolean {
+			*errs = append(*errs, systemerror.NewMalformedArgument(field, "value is not allowed"))
+		}
+		return
+	}
+	if n.ref != nil {
+		n.ref.validate(instance, pointer, errs)
+	}
+
+	if len(n.Type) > 0 && !matchesJSONSchemaTypes(n.Type, instance) {
+		*errs = append(*errs, systemerror.NewInvalidArgument(field, jsonTypeOf(instance),
+			strings.Join(n.Type, ",")))
+		// remaining keywords would report redundant errors
+		return
+	}
+	if len(n.Enum) > 0 && !containsJSONValue(n.Enum, instance) {
+		*errs = append(*errs, systemerror.NewArgumentNotOneOf(field, encodeJSONValues(n.Enum)...))
+	}
+	if len(n.Const) > 0 && !reflect.DeepEqual(n.constValue, instance) {
+		*errs = append(*errs, systemerror.NewNotEqualsArgument(field, string(n.Const)))
+	}
+
+	switch val := instance.(type) {
+	case string:
+		n.validateString(val, field, errs)
+	case float64:
+		n.validateNumber(val, field, errs)
+	case []any:
+		n.validateArray(val, pointer, errs)
+	case map[string]any:
+		n.validateObject(val, pointer, errs)
+	}
+
+	for _, sub := range n.AllOf {
+		sub.validate(instance, pointer, errs)
+	}
+	if len(n.AnyOf) > 0 {
+		matched := false
+		for _, sub := range n.AnyOf {
+			if matched = sub.matches(instance); matched {
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, systemerror.NewMalformedArgument(field, "value does not match any schema (anyOf)"))
+		}
+	}
+	if len(n.OneOf) > 0 {
+		total := 0
+		for _, sub := range n.OneOf {
+			if sub.matches(instance) {
+				total++
+			}
+		}
+		if total != 1 {
+			*errs = append(*errs, systemerror.NewMalformedArgument(field,
+				fmt.Sprintf("value matches %d schemas, expected exactly one (oneOf)", total)))
+		}
+	}
+	if n.Not != nil && n.Not.matches(instance) {
+		*errs = append(*errs, systemerror.NewMalformedArgument(field, "value matches a disallowed schema (not)"))
+	}
+}
+
+func (n *jsonSchemaNode) validateString(val, field string, errs *[]error) {
+	length := utf8.RuneCountInString(val)
+	if n.MinLength != nil && length < *n.MinLength {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "min", *n.MinLength))
+	}
+	if n.MaxLength != nil && length > *n.MaxLength {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "max", *n.MaxLength))
+	}
+	if n.pattern != nil && !n.pattern.MatchString(val) {
+		*errs = append(*errs, systemerror.NewInvalidFormatArgument(field, n.Pattern))
+	}
+	if check, ok := jsonSchemaFormats[n.Format]; ok && !check(val) {
+		*errs = append(*errs, systemerror.NewInvalidFormatArgument(field, n.Format))
+	}
+}
+
+func (n *jsonSchemaNode) validateNumber(val float64, field string, errs *[]error) {
+	if n.Minimum != nil && val < *n.Minimum {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "gte", *n.Minimum))
+	}
+	if n.Maximum != nil && val > *n.Maximum {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "lte", *n.Maximum))
+	}
+	if n.ExclusiveMinimum != nil && val <= *n.ExclusiveMinimum {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "gt", *n.ExclusiveMinimum))
+	}
+	if n.ExclusiveMaximum != nil && val >= *n.ExclusiveMaximum {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "lt", *n.ExclusiveMaximum))
+	}
+	if n.MultipleOf != nil && *n.MultipleOf > 0 {
+		quotient := val / *n.MultipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			*errs = append(*errs, systemerror.NewInvalidArgument(field, strconv.FormatFloat(val, 'f', -1, 64),
+				"multiple of "+strconv.FormatFloat(*n.MultipleOf, 'f', -1, 64)))
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateArray(val []any, pointer string, errs *[]error) {
+	field := jsonPointerArgument(pointer)
+	if n.MinItems != nil && len(val) < *n.MinItems {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "min", *n.MinItems))
+	}
+	if n.MaxItems != nil && len(val) > *n.MaxItems {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "max", *n.MaxItems))
+	}
+	if n.UniqueItems {
+		for i := 1; i < len(val); i++ {
+			if containsJSONValue(val[:i], val[i]) {
+				*errs = append(*errs, systemerror.NewMalformedArgument(field, "items must be unique"))
+				break
+			}
+		}
+	}
+	if n.Items != nil {
+		for i, item := range val {
+			n.Items.validate(item, pointer+"/"+strconv.Itoa(i), errs)
+		}
+	}
+}
+
+func (n *jsonSchemaNode) validateObject(val map[string]any, pointer string, errs *[]error) {
+	field := jsonPointerArgument(pointer)
+	if n.MinProperties != nil && len(val) < *n.MinProperties {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "min", *n.MinProperties))
+	}
+	if n.MaxProperties != nil && len(val) > *n.MaxProperties {
+		*errs = append(*errs, systemerror.NewArgumentOutOfRangeSingle(field, "max", *n.MaxProperties))
+	}
+	for _, name := range n.Required {
+		if _, ok := val[name]; !ok {
+			*errs = append(*errs, systemerror.NewMissingArgument(jsonPointerArgument(joinJSONPointer(pointer, name))))
+		}
+	}
+
+	// sorted keys keep errors order stable
+	keys := make([]string, 0, len(val))
+	for key := range val {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPointer := joinJSONPointer(pointer, key)
+		if prop, ok := n.Properties[key]; ok {
+			prop.validate(val[key], childPointer, errs)
+			continue
+		}
+		if n.AdditionalProperties != nil {
+			n.AdditionalProperties.validate(val[key], childPointer, errs)
+		}
+	}
+}
+
+func matchesJSONSchemaTypes(types []string, instance any) bool {
+	actual := jsonTypeOf(instance)
+	for _, typ := range types {
+		if typ == actual || (typ == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeOf(instance any) string {
+	switch val := instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", instance)
+	}
+}
+
+func containsJSONValue(values []any, instance any) bool {
+	for _, val := range values {
+		if reflect.DeepEqual(val, instance) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeJSONValues(values []any) []string {
+	out := make([]string, 0, len(values))
+	for _, val := range values {
+		if str, ok := val.(string); ok {
+			out = append(out, str)
+			continue
+		}
+		raw, _ := json.Marshal(val)
+		out = append(out, string(raw))
+	}
+	return out
+}
+
+func joinJSONPointer(pointer, key string) string {
+	return pointer + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// jsonPointerArgument returns the argument name of pointer, using / for the root value.
+func jsonPointerArgument(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
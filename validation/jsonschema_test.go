@@ -0,0 +1,130 @@
+package validation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/validation"
+)
+
+var metadataSchema = validation.MustJSONSchema([]byte(`{
+	"type": "object",
+	"required": ["name", "labels"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 3, "pattern": "^[a-z-]+$"},
+		"priority": {"type": "integer", "minimum": 1, "exclusiveMaximum": 10},
+		"kind": {"enum": ["bug", "feature"]},
+		"owner": {"type": "string", "format": "email"},
+		"labels": {"type": "array", "maxItems": 2, "uniqueItems": true, "items": {"$ref": "#/$defs/label"}}
+	},
+	"$defs": {
+		"label": {"type": "object", "required": ["key"], "properties": {"key/name": {"type": "string"}}}
+	}
+}`))
+
+func reasons(t *testing.T, err error) map[string]string {
+	t.Helper()
+	joined, ok := err.(interface{ Unwrap() []error })
+	require.True(t, ok)
+	out := make(map[string]string)
+	for _, errItem := range joined.Unwrap() {
+		sysErr := systemerror.SystemError{}
+		require.True(t, errors.As(errItem, &sysErr))
+		out[sysErr.Message()] = sysErr.Reason()
+	}
+	return out
+}
+
+func TestJSONSchema(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		err := metadataSchema.ValidateJSON([]byte(`{"name":"my-task","priority":9,"kind":"bug",
+			"owner":"jane@example.com","labels":[{"key":"a"}]}`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := metadataSchema.ValidateJSON([]byte(`{"name":"My","priority":10.5,"kind":"chore",
+			"owner":"nope","extra":true,"labels":[{"key/name":1},{"key":"a"},{"key":"a"}]}`))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, systemerror.ErrInvalidArgument))
+		assert.True(t, errors.Is(err, systemerror.ErrOutOfRange))
+		assert.Equal(t, map[string]string{
+			"'/name' is out of range, expected minimum [3]":           "ARGUMENT_OUT_OF_RANGE",
+			"'/name' has an invalid format, expected [^[a-z-]+$]":     "INVALID_FORMAT",
+			"argument '/priority' is invalid":                         "INVALID_ARGUMENT",
+			"'/kind' is not one of the expected values [bug,feature]": "NOT_ONE_OF",
+			"'/owner' has an invalid format, expected [email]":        "INVALID_FORMAT",
+			"argument '/extra' could not be parsed":                   "MALFORMED_ARGUMENT",
+			"'/labels' is out of range, expected maximum [2]":         "ARGUMENT_OUT_OF_RANGE",
+			"argument '/labels' could not be parsed":                  "MALFORMED_ARGUMENT",
+			"argument '/labels/0/key' is missing":                     "MISSING_ARGUMENT",
+			"argument '/labels/0/key~1name' is invalid":               "INVALID_ARGUMENT",
+		}, reasons(t, err))
+	})
+
+	t.Run("combinators", func(t *testing.T) {
+		schema := validation.MustJSONSchema([]byte(`{
+			"oneOf": [{"type": "string"}, {"type": "integer", "multipleOf": 5}],
+			"not": {"const": "forbidden"}
+		}`))
+		assert.NoError(t, schema.ValidateJSON([]byte(`"ok"`)))
+		assert.NoError(t, schema.ValidateJSON([]byte(`15`)))
+		assert.Error(t, schema.ValidateJSON([]byte(`"forbidden"`)))
+		assert.Error(t, schema.ValidateJSON([]byte(`12`)))
+		assert.Error(t, schema.ValidateJSON([]byte(`{`)))
+	})
+
+	t.Run("malformed schema", func(t *testing.T) {
+		_, err := validation.NewJSONSchema([]byte(`{"pattern": "("}`))
+		assert.Error(t, err)
+		_, err = validation.NewJSONSchema([]byte(`{"$ref": "#/$defs/missing"}`))
+		assert.Error(t, err)
+		_, err = validation.NewJSONSchema([]byte(`{"$ref": "https://example.com/schema.json"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("cyclic references", func(t *testing.T) {
+		for _, raw := range []string{
+			`{"$ref": "#"}`,
+			`{"$ref": "#/$defs/a", "$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"allOf": [{"$ref": "#/$defs/a"}]}}}`,
+			`{"$defs": {"a": {"not": {"anyOf": [{"type": "string"}, {"$ref": "#/$defs/a"}]}}}}`,
+		} {
+			_, err := validation.NewJSONSchema([]byte(raw))
+			assert.ErrorContains(t, err, "cyclic json schema reference", raw)
+		}
+
+		tree := validation.MustJSONSchema([]byte(`{
+			"type": "object",
+			"required": ["name"],
+			"properties": {"name": {"type": "string"}, "children": {"type": "array", "items": {"$ref": "#"}}}
+		}`))
+		assert.NoError(t, tree.ValidateJSON([]byte(`{"name":"a","children":[{"name":"b","children":[]}]}`)))
+		assert.Error(t, tree.ValidateJSON([]byte(`{"name":"a","children":[{"children":[]}]}`)))
+	})
+}
+
+func TestJSONSchemaValidator(t *testing.T) {
+	type task struct {
+		Name     string         `json:"name"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	validator := validation.NewJSONSchemaValidator()
+	validation.RegisterJSONSchemaType[task](validator, validation.MustJSONSchema([]byte(`{
+		"properties": {"metadata": {"type": "object", "maxProperties": 1}}
+	}`)))
+	ctx := context.Background()
+
+	assert.NoError(t, validator.Validate(ctx, task{Metadata: map[string]any{"a": 1}}))
+	err := validator.Validate(ctx, &task{Metadata: map[string]any{"a": 1, "b": 2}})
+	assert.True(t, errors.Is(err, systemerror.ErrOutOfRange))
+	assert.NoError(t, validator.Validate(ctx, struct{}{}))
+
+	multi := validation.MultiValidator{validation.NewGoPlaygroundValidator(), validator}
+	assert.Error(t, multi.Validate(ctx, task{Metadata: map[string]any{"a": 1, "b": 2}}))
+}
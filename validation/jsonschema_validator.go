@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// JSONSchemaValidator is a Validator using JSON Schema documents registered per Go type (see
+// RegisterJSONSchemaType) or per name (e.g. routes). Values are encoded as JSON before validating them, so schemas
+// follow their encoding/json representation.
+//
+// Values of types with no registered schema are valid.
+type JSONSchemaValidator struct {
+	mu     sync.RWMutex
+	byType map[reflect.Type]*JSONSchema
+	byName map[string]*JSONSchema
+}
+
+var _ Validator = (*JSONSchemaValidator)(nil)
+
+// NewJSONSchemaValidator allocates a new JSONSchemaValidator instance.
+func NewJSONSchemaValidator() *JSONSchemaValidator {
+	return &JSONSchemaValidator{
+		byType: make(map[reflect.Type]*JSONSchema),
+		byName: make(map[string]*JSONSchema),
+	}
+}
+
+// RegisterJSONSchemaType registers schema for values of type T (and *T).
+func RegisterJSONSchemaType[T any](v *JSONSchemaValidator, schema *JSONSchema) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.byType[reflect.TypeOf((*T)(nil)).Elem()] = schema
+}
+
+// Register registers schema under name.
+func (v *JSONSchemaValidator) Register(name string, schema *JSONSchema) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.byName[name] = schema
+}
+
+// Schema retrieves the schema registered under name.
+func (v *JSONSchemaValidator) Schema(name string) (*JSONSchema, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	schema, ok := v.byName[name]
+	return schema, ok
+}
+
+func (v *JSONSchemaValidator) Validate(_ context.Context, val any) error {
+	typ := reflect.TypeOf(val)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	v.mu.RLock()
+	schema, ok := v.byType[typ]
+	v.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return schema.ValidateJSON(raw)
+}
+
+// ValidateJSON validates raw using the schema registered under name. Documents are valid if no schema was
+// registered under name.
+func (v *JSONSchemaValidator) ValidateJSON(_ context.Context, name string, raw []byte) error {
+	schema, ok := v.Schema(name)
+	if !ok {
+		return nil
+	}
+	return schema.ValidateJSON(raw)
+}
+
+// MultiValidator is a Validator running every validator, joining their errors. Use it to combine struct tag and
+// JSON Schema validation (e.g. NewGoPlaygroundValidator and NewJSONSchemaValidator).
+type MultiValidator []Validator
+
+var _ Validator = MultiValidator(nil)
+
+func (m MultiValidator) Validate(ctx context.Context, v any) error {
+	errs := make([]error, 0, len(m))
+	for _, validator := range m {
+		if err := validator.Validate(ctx, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -14,3 +14,11 @@ var GoPlaygroundValidationModule = fx.Module("validation_go_playground",
 		),
 	),
 )
+
+// JSONSchemaValidationModule provides a validation.JSONSchemaValidator. Combine it with other validators using
+// validation.MultiValidator.
+var JSONSchemaValidationModule = fx.Module("validation_json_schema",
+	fx.Provide(
+		validation.NewJSONSchemaValidator,
+	),
+)
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress the subscription endpoint resolves to a loopback, link-local, private or otherwise internal
+// address (see Config.AllowPrivateNetworks).
+var ErrBlockedAddress = errors.New("webhook: blocked endpoint address")
+
+// sharedAddressSpace carrier-grade NAT range (RFC 6598), not covered by netip.Addr.IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isBlockedAddr indicates if addr is not a public unicast address.
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsGlobalUnicast() || addr.IsPrivate() || sharedAddressSpace.Contains(addr)
+}
+
+// NewClientHTTP allocates the http.Client used by Dispatcher to send deliveries.
+//
+// As subscription endpoints are provided by (potentially untrusted) subscribers, the client refuses to connect to
+// loopback, link-local (e.g. cloud metadata services at 169.254.169.254), private and unspecified addresses unless
+// Config.AllowPrivateNetworks is set. Addresses are checked once resolved, right before connecting, so DNS records
+// pointing to internal addresses are refused as well. Redirects are not followed, redirected deliveries fail.
+func NewClientHTTP(cfg Config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || isBlockedAddr(addrPort.Addr()) {
+				return ErrBlockedAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// proxies would connect on behalf of the dialer, bypassing address checks
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+// Config configuration structure for Dispatcher and Repository instances.
+type Config struct {
+	// MaxAttempts maximum number of delivery attempts. Deliveries are dead-lettered afterward.
+	MaxAttempts int `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`
+	// RetryBaseDelay delay before the first retry. Following delays grow exponentially.
+	RetryBaseDelay time.Duration `env:"WEBHOOK_RETRY_BASE_DELAY" envDefault:"30s"`
+	// RetryMaxDelay maximum delay between attempts.
+	RetryMaxDelay time.Duration `env:"WEBHOOK_RETRY_MAX_DELAY" envDefault:"1h"`
+	// AttemptTimeout maximum time a delivery attempt takes, including the response.
+	AttemptTimeout time.Duration `env:"WEBHOOK_ATTEMPT_TIMEOUT" envDefault:"10s"`
+	// PollInterval time between checks of due deliveries.
+	PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"5s"`
+	// BatchSize maximum number of due deliveries attempted per poll.
+	BatchSize int `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"`
+	// Concurrency maximum number of concurrent delivery attempts.
+	Concurrency int `env:"WEBHOOK_CONCURRENCY" envDefault:"8"`
+	// AllowPrivateNetworks allows deliveries to loopback, link-local and private addresses (see NewClientHTTP).
+	// Meant for development and tests only.
+	AllowPrivateNetworks bool `env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS"`
+	// SignatureTolerance maximum age of signature timestamps accepted by Verifier.
+	SignatureTolerance time.Duration `env:"WEBHOOK_SIGNATURE_TOLERANCE" envDefault:"5m"`
+	// SubscriptionTableName table used by SubscriptionRepositoryPostgres.
+	SubscriptionTableName string `env:"WEBHOOK_SUBSCRIPTION_TABLE_NAME" envDefault:"webhook_subscriptions"`
+	// DeliveryTableName table used by DeliveryRepositoryPostgres.
+	DeliveryTableName string `env:"WEBHOOK_DELIVERY_TABLE_NAME" envDefault:"webhook_deliveries"`
+}
+
+// NewConfig allocates a new Config instance from environment variables. Returns an error if Config.PollInterval is
+// not positive, as Dispatcher would not be able to poll due deliveries.
+func NewConfig() (Config, error) {
+	cfg, err := env.ParseAs[Config]()
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.PollInterval <= 0 {
+		return Config{}, errors.New("webhook: WEBHOOK_POLL_INTERVAL must be positive")
+	}
+	return cfg, nil
+}
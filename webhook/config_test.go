@@ -0,0 +1,21 @@
+package webhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/webhook"
+)
+
+func TestNewConfig(t *testing.T) {
+	cfg, err := webhook.NewConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, cfg.PollInterval)
+
+	t.Setenv("WEBHOOK_POLL_INTERVAL", "0s")
+	_, err = webhook.NewConfig()
+	assert.Error(t, err)
+}
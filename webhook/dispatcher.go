@@ -0,0 +1,437 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/fx"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/shutdown"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// maxErrorLength maximum length of Delivery.LastError values.
+const maxErrorLength = 512
+
+// Dispatcher manages Subscription(s) and delivers published Event(s) to them.
+//
+// Deliveries are stored as pending before being attempted in the background, so events are not lost if the process
+// stops. Due deliveries are claimed using optimistic concurrency, so several replicas might share the repositories.
+// Failed attempts are retried with exponential backoff (and jitter) until Config.MaxAttempts is reached, dead-lettering
+// the delivery afterward. Dead-lettered deliveries are kept and might be delivered again using Replay.
+type Dispatcher struct {
+	config        Config
+	subscriptions SubscriptionRepository
+	deliveries    DeliveryRepository
+	factoryID     identifier.Factory
+	client        *http.Client
+	logger        logging.Logger
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDispatcherParams Dispatcher dependencies.
+type NewDispatcherParams struct {
+	fx.In
+
+	Lifecycle     fx.Lifecycle
+	Config        Config
+	Subscriptions SubscriptionRepository
+	Deliveries    DeliveryRepository
+	FactoryID     identifier.Factory
+	// Client used to send deliveries. Defaults to NewClientHTTP if not provided. Custom clients are responsible
+	// for refusing internal endpoint addresses.
+	Client *http.Client `optional:"true"`
+	Logger logging.Logger
+	// Coordinator stops the dispatcher during shutdown.PhaseWorkers. The dispatcher stops once the application
+	// stops if not provided.
+	Coordinator *shutdown.Coordinator `optional:"true"`
+}
+
+// NewDispatcher allocates a new Dispatcher instance. Due deliveries are attempted every Config.PollInterval and
+// right after publishing events.
+func NewDispatcher(params NewDispatcherParams) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		config:        params.Config,
+		subscriptions: params.Subscriptions,
+		deliveries:    params.Deliveries,
+		factoryID:     params.FactoryID,
+		client:        params.Client,
+		logger:        params.Logger,
+		wake:          make(chan struct{}, 1),
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+	if d.client == nil {
+		d.client = NewClientHTTP(params.Config)
+	}
+	shutdown.Append(params.Lifecycle, params.Coordinator, shutdown.PhaseWorkers, "webhook_dispatcher", fx.Hook{
+		OnStart: func(_ context.Context) error {
+			go d.run()
+			return nil
+		},
+		OnStop: d.shutdown,
+	})
+	return d
+}
+
+// Subscribe registers url as a Subscription of events matching eventTypes (see Subscription.Matches). The returned
+// subscription holds the generated signing secret.
+//
+// Endpoints using internal IP addresses are refused right away, while host names are checked on every delivery
+// attempt (see NewClientHTTP).
+func (d *Dispatcher) Subscribe(ctx context.Context, endpoint string, eventTypes []string) (Subscription, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Hostname() == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return Subscription{}, systemerror.NewInvalidFormatArgument("url", "absolute HTTP(S) URL")
+	}
+	if addr, errAddr := netip.ParseAddr(parsed.Hostname()); errAddr == nil && !d.config.AllowPrivateNetworks &&
+		isBlockedAddr(addr) {
+		return Subscription{}, systemerror.NewInvalidFormatArgument("url", "public HTTP(S) URL")
+	}
+	id, err := d.factoryID.NewIdentifier()
+	if err != nil {
+		return Subscription{}, err
+	}
+	secret, err := NewSecret()
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub := Subscription{
+		Auditable:  persistence.NewAuditable(ctx),
+		ID:         id,
+		URL:        endpoint,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	}
+	if err = d.subscriptions.Save(ctx, sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes the subscription with the given identifier. Its pending deliveries are dead-lettered.
+func (d *Dispatcher) Unsubscribe(ctx context.Context, id string) error {
+	sub, err := d.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	return d.subscriptions.Remove(ctx, sub)
+}
+
+// GetSubscription retrieves the subscription with the given identifier.
+func (d *Dispatcher) GetSubscription(ctx context.Context, id string) (Subscription, error) {
+	sub, err := d.subscriptions.FindByKey(ctx, id)
+	if err != nil {
+		return Subscription{}, err
+	} else if sub == nil {
+		return Subscription{}, systemerror.NewResourceNotFound[Subscription](id)
+	}
+	return *sub, nil
+}
+
+// ListSubscriptions retrieves subscriptions matching criteria.
+func (d *Dispatcher) ListSubscriptions(ctx context.Context, criteria data.Criteria) (data.Page[Subscription], error) {
+	return d.subscriptions.FindAll(ctx, criteria)
+}
+
+// Publish creates an Event of eventType holding eventData (encoded as JSON), storing a pending Delivery for every
+// matching subscription.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, eventData any) (Event, error) {
+	raw, err := json.Marshal(eventData)
+	if err != nil {
+		return Event{}, err
+	}
+	eventID, err := d.factoryID.NewIdentifier()
+	if err != nil {
+		return Event{}, err
+	}
+	event := Event{
+		ID:        eventID,
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      raw,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return Event{}, err
+	}
+
+	subs, err := d.subscriptions.FindByEventType(ctx, eventType)
+	if err != nil {
+		return Event{}, err
+	}
+	deliveries := make([]Delivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveryID, errID := d.factoryID.NewIdentifier()
+		if errID != nil {
+			return Event{}, errID
+		}
+		deliveries = append(deliveries, Delivery{
+			Auditable:       persistence.NewAuditable(ctx),
+			ID:              deliveryID,
+			SubscriptionID:  sub.ID,
+			EventID:         event.ID,
+			EventType:       event.Type,
+			Payload:         payload,
+			Status:          DeliveryStatusPending,
+			NextAttemptTime: event.Timestamp,
+		})
+	}
+	if len(deliveries) == 0 {
+		return event, nil
+	}
+	if err = d.deliveries.SaveMany(ctx, deliveries); err != nil {
+		return Event{}, err
+	}
+	d.notify()
+	return event, nil
+}
+
+// GetDelivery retrieves the delivery with the given identifier.
+func (d *Dispatcher) GetDelivery(ctx context.Context, id string) (Delivery, error) {
+	delivery, err := d.deliveries.FindByKey(ctx, id)
+	if err != nil {
+		return Delivery{}, err
+	} else if delivery == nil {
+		return Delivery{}, systemerror.NewResourceNotFound[Delivery](id)
+	}
+	return *delivery, nil
+}
+
+// ListDeliveries retrieves deliveries matching criteria (see DeliveryRepository for supported filters). Use
+// FieldStatus filter with DeliveryStatusDeadLettered to list the dead-letter records.
+func (d *Dispatcher) ListDeliveries(ctx context.Context, criteria data.Criteria) (data.Page[Delivery], error) {
+	return d.deliveries.FindAll(ctx, criteria)
+}
+
+// Replay schedules the delivery with the given identifier to be attempted right away, resetting its attempts.
+// Pending deliveries are returned as-is.
+func (d *Dispatcher) Replay(ctx context.Context, id string) (Delivery, error) {
+	delivery, err := d.GetDelivery(ctx, id)
+	if err != nil {
+		return Delivery{}, err
+	} else if delivery.Status == DeliveryStatusPending {
+		return delivery, nil
+	}
+	delivery.Status = DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptTime = time.Now().UTC()
+	delivery.LastStatusCode = 0
+	delivery.LastError = ""
+	delivery.Update(ctx)
+	if err = d.deliveries.Save(ctx, delivery); err != nil {
+		return Delivery{}, err
+	}
+	d.notify()
+	return delivery, nil
+}
+
+// notify wakes the delivery loop up, without blocking if it is already awake.
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.deliverDue()
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// deliverDue attempts due deliveries, up to Config.BatchSize.
+func (d *Dispatcher) deliverDue() {
+	due, err := d.deliveries.FindDue(d.ctx, time.Now().UTC(), d.config.BatchSize)
+	if err != nil {
+		if d.ctx.Err() == nil {
+			d.logger.WithError(err).Write("failed to find due webhook deliveries")
+		}
+		return
+	}
+	group := errgroup.Group{}
+	group.SetLimit(max(d.config.Concurrency, 1))
+	for _, delivery := range due {
+		claimed, ok := d.claim(delivery)
+		if !ok {
+			continue
+		}
+		group.Go(func() error {
+			d.attempt(claimed)
+			return nil
+		})
+	}
+	_ = group.Wait()
+}
+
+// claim postpones the next attempt of delivery while it is being attempted, so other replicas skip it. Returns false
+// if delivery was claimed (or modified) concurrently.
+func (d *Dispatcher) claim(delivery Delivery) (Delivery, bool) {
+	delivery.NextAttemptTime = time.Now().UTC().Add(2 * max(d.config.AttemptTimeout, time.Second))
+	delivery.Update(d.ctx)
+	if err := d.deliveries.Save(d.ctx, delivery); err != nil {
+		if !isVersionMismatch(err) && d.ctx.Err() == nil {
+			d.logger.WithError(err).
+				WithField("delivery_id", delivery.ID).
+				Write("failed to claim webhook delivery")
+		}
+		return Delivery{}, false
+	}
+	return delivery, true
+}
+
+func (d *Dispatcher) attempt(delivery Delivery) {
+	sub, err := d.subscriptions.FindByKey(d.ctx, delivery.SubscriptionID)
+	if d.ctx.Err() != nil {
+		// claim expires, so the delivery gets attempted once the dispatcher starts again
+		return
+	}
+	switch {
+	case err != nil:
+		delivery.LastStatusCode, delivery.LastError = 0, err.Error()
+		d.fail(&delivery)
+	case sub == nil || !sub.IsActive:
+		delivery.Attempts++
+		delivery.Status = DeliveryStatusDeadLettered
+		delivery.LastStatusCode, delivery.LastError = 0, "subscription not found"
+	default:
+		delivery.LastStatusCode, err = d.send(*sub, delivery)
+		if d.ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			delivery.Attempts++
+			delivery.Status = DeliveryStatusSucceeded
+			delivery.LastError = ""
+			break
+		}
+		delivery.LastError = truncate(err.Error(), maxErrorLength)
+		d.fail(&delivery)
+	}
+
+	ctx := context.WithoutCancel(d.ctx)
+	delivery.Update(ctx)
+	if err = d.deliveries.Save(ctx, delivery); err != nil {
+		d.logger.WithError(err).
+			WithField("delivery_id", delivery.ID).
+			Write("failed to store webhook delivery attempt")
+		return
+	}
+	if delivery.Status == DeliveryStatusDeadLettered {
+		d.logger.Warn().
+			WithField("delivery_id", delivery.ID).
+			WithField("subscription_id", delivery.SubscriptionID).
+			WithField("event_type", delivery.EventType).
+			WithField("attempts", delivery.Attempts).
+			Write("webhook delivery dead-lettered")
+	}
+}
+
+// fail records a failed attempt of delivery, scheduling its next attempt or dead-lettering it.
+func (d *Dispatcher) fail(delivery *Delivery) {
+	delivery.Attempts++
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = DeliveryStatusDeadLettered
+		return
+	}
+	delivery.NextAttemptTime = time.Now().UTC().Add(d.backoff(delivery.Attempts))
+}
+
+// backoff computes the delay before the next attempt, using full jitter over half of the exponential delay.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < d.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if d.config.RetryMaxDelay > 0 {
+		delay = min(delay, d.config.RetryMaxDelay)
+	}
+	if delay <= 1 {
+		return delay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half)
+}
+
+// send performs a signed delivery attempt, returning the response status code.
+func (d *Dispatcher) send(sub Subscription, delivery Delivery) (int, error) {
+	ctx := d.ctx
+	if d.config.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.AttemptTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	signature, err := Sign(sub.Secret, delivery.ID, now, delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, signature)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// drain (limited) so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook: endpoint responded with status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// shutdown stops the delivery loop, cancelling in-flight attempts. Cancelled attempts are retried once their claim
+// expires.
+func (d *Dispatcher) shutdown(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.done:
+		return nil
+	}
+}
@@ -0,0 +1,212 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/identifier"
+	"github.com/hadroncorp/geck/observability/logging"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/systemerror"
+	"github.com/hadroncorp/geck/webhook"
+)
+
+func newTestDispatcher(t *testing.T, allowPrivateNetworks bool) (*webhook.Dispatcher, *fxtest.Lifecycle) {
+	encryptor := encryption.NewEncryptorAES(encryption.ConfigEncryptor{
+		SecretKey: data.PageTokenDefaultEncryptionKey,
+	})
+	lifecycle := fxtest.NewLifecycle(t)
+	dispatcher := webhook.NewDispatcher(webhook.NewDispatcherParams{
+		Lifecycle: lifecycle,
+		Config: webhook.Config{
+			MaxAttempts:    3,
+			RetryBaseDelay: time.Millisecond,
+			RetryMaxDelay:  5 * time.Millisecond,
+			AttemptTimeout: time.Second,
+			PollInterval:   5 * time.Millisecond,
+			BatchSize:      10,
+			Concurrency:    2,
+			// test servers listen on loopback addresses
+			AllowPrivateNetworks: allowPrivateNetworks,
+		},
+		Subscriptions: webhook.NewSubscriptionRepositoryMemory(encryptor),
+		Deliveries:    webhook.NewDeliveryRepositoryMemory(encryptor),
+		FactoryID:     identifier.NewFactoryUUID(),
+		Logger:        logging.NewZerologLoggerAdapter(zerolog.Nop()),
+	})
+	lifecycle.RequireStart()
+	return dispatcher, lifecycle
+}
+
+func waitDeliveryStatus(t *testing.T, dispatcher *webhook.Dispatcher, id string,
+	status webhook.DeliveryStatus) webhook.Delivery {
+	var delivery webhook.Delivery
+	require.Eventually(t, func() bool {
+		var err error
+		delivery, err = dispatcher.GetDelivery(context.Background(), id)
+		require.NoError(t, err)
+		return delivery.Status == status
+	}, time.Second, 5*time.Millisecond)
+	return delivery
+}
+
+func TestDispatcher(t *testing.T) {
+	dispatcher, lifecycle := newTestDispatcher(t, true)
+	defer lifecycle.RequireStop()
+	ctx := context.Background()
+
+	var fail atomic.Bool
+	var received atomic.Int32
+	verifier := webhook.Verifier{Tolerance: time.Minute}
+	var secret string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := verifier.Verify(r.Header, body, secret); err != nil || fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		event := webhook.Event{}
+		if err := json.Unmarshal(body, &event); err != nil || event.Type != r.Header.Get(webhook.HeaderEventType) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	_, err := dispatcher.Subscribe(ctx, "ftp://example.com", nil)
+	assert.Error(t, err)
+	sub, err := dispatcher.Subscribe(ctx, srv.URL, []string{"task.*"})
+	require.NoError(t, err)
+	secret = sub.Secret
+
+	t.Run("not matching", func(t *testing.T) {
+		_, err := dispatcher.Publish(ctx, "user.created", map[string]string{"user_id": "123"})
+		require.NoError(t, err)
+		page, err := dispatcher.ListDeliveries(ctx, data.Criteria{})
+		require.NoError(t, err)
+		assert.Empty(t, page.Items)
+	})
+
+	var deliveryID string
+	t.Run("succeeded", func(t *testing.T) {
+		event, err := dispatcher.Publish(ctx, "task.created", map[string]string{"task_id": "123"})
+		require.NoError(t, err)
+		page, err := dispatcher.ListDeliveries(ctx, data.Criteria{})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, event.ID, page.Items[0].EventID)
+		deliveryID = page.Items[0].ID
+
+		delivery := waitDeliveryStatus(t, dispatcher, deliveryID, webhook.DeliveryStatusSucceeded)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+		assert.EqualValues(t, 1, received.Load())
+	})
+
+	t.Run("dead-lettered and replayed", func(t *testing.T) {
+		fail.Store(true)
+		_, err := dispatcher.Publish(ctx, "task.deleted", map[string]string{"task_id": "123"})
+		require.NoError(t, err)
+		page, err := dispatcher.ListDeliveries(ctx, data.Criteria{
+			Filters: []data.CriteriaFilter{{Field: webhook.FieldEventType, Operator: data.OperatorEquals,
+				Value: []any{"task.deleted"}}},
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+
+		delivery := waitDeliveryStatus(t, dispatcher, page.Items[0].ID, webhook.DeliveryStatusDeadLettered)
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+		assert.NotEmpty(t, delivery.LastError)
+
+		fail.Store(false)
+		delivery, err = dispatcher.Replay(ctx, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.DeliveryStatusPending, delivery.Status)
+		delivery = waitDeliveryStatus(t, dispatcher, delivery.ID, webhook.DeliveryStatusSucceeded)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.EqualValues(t, 2, received.Load())
+	})
+
+	t.Run("unsubscribed", func(t *testing.T) {
+		require.NoError(t, dispatcher.Unsubscribe(ctx, sub.ID))
+		_, err := dispatcher.Replay(ctx, deliveryID)
+		require.NoError(t, err)
+		delivery := waitDeliveryStatus(t, dispatcher, deliveryID, webhook.DeliveryStatusDeadLettered)
+		assert.Equal(t, "subscription not found", delivery.LastError)
+	})
+}
+
+func TestDispatcher_BlockedAddresses(t *testing.T) {
+	dispatcher, lifecycle := newTestDispatcher(t, false)
+	defer lifecycle.RequireStop()
+	ctx := context.Background()
+
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	for _, endpoint := range []string{srv.URL, "http://169.254.169.254/latest/meta-data", "http://10.0.0.1/hook",
+		"http://[::1]/hook", "http://[::ffff:192.168.0.1]/hook", "http://0.0.0.0/hook"} {
+		_, err = dispatcher.Subscribe(ctx, endpoint, nil)
+		assert.ErrorIs(t, err, systemerror.ErrInvalidArgument, endpoint)
+	}
+
+	// host names are resolved right before connecting
+	_, err = dispatcher.Subscribe(ctx, "http://localhost:"+srvURL.Port()+"/hook", nil)
+	require.NoError(t, err)
+	_, err = dispatcher.Publish(ctx, "task.created", map[string]string{"task_id": "123"})
+	require.NoError(t, err)
+	page, err := dispatcher.ListDeliveries(ctx, data.Criteria{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	delivery := waitDeliveryStatus(t, dispatcher, page.Items[0].ID, webhook.DeliveryStatusDeadLettered)
+	assert.Contains(t, delivery.LastError, webhook.ErrBlockedAddress.Error())
+	assert.Zero(t, received.Load())
+}
+
+func TestDispatcher_Redirect(t *testing.T) {
+	dispatcher, lifecycle := newTestDispatcher(t, true)
+	defer lifecycle.RequireStop()
+	ctx := context.Background()
+
+	var received atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	_, err := dispatcher.Subscribe(ctx, srv.URL, nil)
+	require.NoError(t, err)
+	_, err = dispatcher.Publish(ctx, "task.created", map[string]string{"task_id": "123"})
+	require.NoError(t, err)
+	page, err := dispatcher.ListDeliveries(ctx, data.Criteria{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	delivery := waitDeliveryStatus(t, dispatcher, page.Items[0].ID, webhook.DeliveryStatusDeadLettered)
+	assert.Equal(t, http.StatusTemporaryRedirect, delivery.LastStatusCode)
+	assert.Zero(t, received.Load())
+}
@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"errors"
+
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+var (
+	// ErrInvalidSignature the delivery signature is missing or does not match any secret.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrStaleTimestamp the delivery timestamp is out of the accepted tolerance (e.g. replayed deliveries).
+	ErrStaleTimestamp = errors.New("webhook: stale timestamp")
+	// ErrInvalidSecret the signing secret is not a whsec_ prefixed, base64-encoded key (see NewSecret).
+	ErrInvalidSecret = errors.New("webhook: invalid secret")
+)
+
+// isVersionMismatch indicates if err was caused by a concurrent update (see systemerror.NewVersionMismatch).
+func isVersionMismatch(err error) bool {
+	var sysErr systemerror.Error
+	return errors.As(err, &sysErr) && sysErr.Reason() == "VERSION_MISMATCH"
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/data/persistence"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+const (
+	// FieldSubscriptionID Delivery.SubscriptionID field, used by data.Criteria filters.
+	FieldSubscriptionID = "subscription_id"
+	// FieldEventType Delivery.EventType field, used by data.Criteria filters.
+	FieldEventType = "event_type"
+	// FieldStatus Delivery.Status field, used by data.Criteria filters.
+	FieldStatus = "status"
+
+	defaultPageSize = 50
+	maxPageSize     = 250
+)
+
+// SubscriptionRepository persists Subscription(s). FindAll supports no filters, subscriptions are ordered by
+// creation time, newest first.
+type SubscriptionRepository interface {
+	persistence.PagingCrudRepository[Subscription, string]
+	// FindByEventType retrieves active subscriptions matching eventType (see Subscription.Matches).
+	FindByEventType(ctx context.Context, eventType string) ([]Subscription, error)
+}
+
+// DeliveryRepository persists Delivery(ies).
+//
+// Save implements optimistic concurrency: deliveries with zero version are inserted, while the rest are updated
+// only if the stored version is the previous one (otherwise, systemerror.NewVersionMismatch is returned).
+//
+// FindAll supports equality filters of FieldSubscriptionID, FieldEventType and FieldStatus fields. Deliveries are
+// ordered by creation time, newest first.
+type DeliveryRepository interface {
+	persistence.PagingCrudRepository[Delivery, string]
+	// FindDue retrieves up to limit pending deliveries due at the given time.
+	FindDue(ctx context.Context, at time.Time, limit int) ([]Delivery, error)
+}
+
+// parseCriteriaFilters parses equality filters of criteria, accepting only the given fields.
+func parseCriteriaFilters(criteria data.Criteria, fields ...string) (map[string]string, error) {
+	filters := make(map[string]string, len(criteria.Filters))
+	for _, filter := range criteria.Filters {
+		if filter.Operator != data.OperatorEquals || len(filter.Value) != 1 {
+			return nil, systemerror.NewArgumentNotOneOf(filter.Field+".operator", data.OperatorEquals.String())
+		}
+		if !slices.Contains(fields, filter.Field) {
+			return nil, systemerror.NewArgumentNotOneOf("filter", fields...)
+		}
+		filters[filter.Field] = fmt.Sprintf("%v", filter.Value[0])
+	}
+	return filters, nil
+}
+
+func newPageSize(criteria data.Criteria) int {
+	if criteria.PageSize <= 0 {
+		return defaultPageSize
+	}
+	return int(min(criteria.PageSize, maxPageSize))
+}
+
+// newPageTokens computes offset page tokens of a page starting at offset.
+func newPageTokens(encryptor encryption.Encryptor, offset, pageSize, pageItems, total int) (data.PageToken,
+	data.PageToken, error) {
+	var prev, next data.PageToken
+	var err error
+	if offset+pageItems < total {
+		if next, err = data.NewPageTokenOffset(encryptor, offset+pageItems); err != nil {
+			return nil, nil, err
+		}
+	}
+	if offset > 0 {
+		if prev, err = data.NewPageTokenOffset(encryptor, max(offset-pageSize, 0)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return prev, next, nil
+}
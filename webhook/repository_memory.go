@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hadroncorp/geck/data"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+// SubscriptionRepositoryMemory is the in-process SubscriptionRepository implementation, useful for testing.
+type SubscriptionRepositoryMemory struct {
+	Encryptor encryption.Encryptor
+
+	mu            *sync.RWMutex
+	subscriptions map[string]Subscription
+}
+
+var _ SubscriptionRepository = SubscriptionRepositoryMemory{}
+
+// NewSubscriptionRepositoryMemory allocates a new SubscriptionRepositoryMemory instance.
+func NewSubscriptionRepositoryMemory(encryptor encryption.Encryptor) SubscriptionRepositoryMemory {
+	return SubscriptionRepositoryMemory{
+		Encryptor:     encryptor,
+		mu:            &sync.RWMutex{},
+		subscriptions: make(map[string]Subscription),
+	}
+}
+
+func (r SubscriptionRepositoryMemory) Save(_ context.Context, entity Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.subscriptions[entity.ID]; exists && entity.Version == 0 {
+		return systemerror.NewResourceAlreadyExists[Subscription](entity.ID)
+	}
+	r.subscriptions[entity.ID] = entity
+	return nil
+}
+
+func (r SubscriptionRepositoryMemory) SaveMany(ctx context.Context, entities []Subscription) error {
+	errs := make([]error, 0, len(entities))
+	for _, entity := range entities {
+		if err := r.Save(ctx, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r SubscriptionRepositoryMemory) Remove(_ context.Context, entity Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subscriptions, entity.ID)
+	return nil
+}
+
+func (r SubscriptionRepositoryMemory) FindByKey(_ context.Context, key string) (*Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sub, ok := r.subscriptions[key]
+	if !ok {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+func (r SubscriptionRepositoryMemory) FindAll(_ context.Context,
+	criteria data.Criteria) (data.Page[Subscription], error) {
+	if _, err := parseCriteriaFilters(criteria); err != nil {
+		return data.Page[Subscription]{}, err
+	}
+	r.mu.RLock()
+	items := make([]Subscription, 0, len(r.subscriptions))
+	for _, sub := range r.subscriptions {
+		items = append(items, sub)
+	}
+	r.mu.RUnlock()
+	sort.Slice(items, func(i, j int) bool {
+		return newerThan(items[i].CreateTime, items[i].ID, items[j].CreateTime, items[j].ID)
+	})
+	return paginateMemory(r.Encryptor, criteria, items)
+}
+
+func (r SubscriptionRepositoryMemory) FindByEventType(_ context.Context, eventType string) ([]Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Subscription, 0)
+	for _, sub := range r.subscriptions {
+		if sub.IsActive && sub.Matches(eventType) {
+			out = append(out, sub)
+		}
+	}
+	return out, nil
+}
+
+// DeliveryRepositoryMemory is the in-process DeliveryRepository implementation, useful for testing.
+type DeliveryRepositoryMemory struct {
+	Encryptor encryption.Encryptor
+
+	mu         *sync.RWMutex
+	deliveries map[string]Delivery
+}
+
+var _ DeliveryRepository = DeliveryRepositoryMemory{}
+
+// NewDeliveryRepositoryMemory allocates a new DeliveryRepositoryMemory instance.
+func NewDeliveryRepositoryMemory(encryptor encryption.Encryptor) DeliveryRepositoryMemory {
+	return DeliveryRepositoryMemory{
+		Encryptor:  encryptor,
+		mu:         &sync.RWMutex{},
+		deliveries: make(map[string]Delivery),
+	}
+}
+
+func (r DeliveryRepositoryMemory) Save(_ context.Context, entity Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, exists := r.deliveries[entity.ID]
+	switch {
+	case entity.Version == 0 && exists:
+		return systemerror.NewResourceAlreadyExists[Delivery](entity.ID)
+	case entity.Version > 0 && !exists:
+		return systemerror.NewResourceNotFound[Delivery](entity.ID)
+	case entity.Version > 0 && current.Version != entity.Version-1:
+		return systemerror.NewVersionMismatch(strconv.FormatInt(entity.Version-1, 10),
+			strconv.FormatInt(current.Version, 10))
+	}
+	r.deliveries[entity.ID] = entity
+	return nil
+}
+
+func (r DeliveryRepositoryMemory) SaveMany(ctx context.Context, entities []Delivery) error {
+	errs := make([]error, 0, len(entities))
+	for _, entity := range entities {
+		if err := r.Save(ctx, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r DeliveryRepositoryMemory) Remove(_ context.Context, entity Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.deliveries, entity.ID)
+	return nil
+}
+
+func (r DeliveryRepositoryMemory) FindByKey(_ context.Context, key string) (*Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	delivery, ok := r.deliveries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &delivery, nil
+}
+
+func (r DeliveryRepositoryMemory) FindAll(_ context.Context, criteria data.Criteria) (data.Page[Delivery], error) {
+	filters, err := parseCriteriaFilters(criteria, FieldSubscriptionID, FieldEventType, FieldStatus)
+	if err != nil {
+		return data.Page[Delivery]{}, err
+	}
+	r.mu.RLock()
+	items := make([]Delivery, 0, len(r.deliveries))
+	for _, delivery := range r.deliveries {
+		if matchesDeliveryFilters(delivery, filters) {
+			items = append(items, delivery)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(items, func(i, j int) bool {
+		return newerThan(items[i].CreateTime, items[i].ID, items[j].CreateTime, items[j].ID)
+	})
+	return paginateMemory(r.Encryptor, criteria, items)
+}
+
+func matchesDeliveryFilters(delivery Delivery, filters map[string]string) bool {
+	for field, value := range filters {
+		switch {
+		case field == FieldSubscriptionID && delivery.SubscriptionID != value,
+			field == FieldEventType && delivery.EventType != value,
+			field == FieldStatus && string(delivery.Status) != value:
+			return false
+		}
+	}
+	return true
+}
+
+func (r DeliveryRepositoryMemory) FindDue(_ context.Context, at time.Time, limit int) ([]Delivery, error) {
+	r.mu.RLock()
+	items := make([]Delivery, 0)
+	for _, delivery := range r.deliveries {
+		if delivery.Status == DeliveryStatusPending && !delivery.NextAttemptTime.After(at) {
+			items = append(items, delivery)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].NextAttemptTime.Before(items[j].NextAttemptTime)
+	})
+	return items[:min(limit, len(items))], nil
+}
+
+func newerThan(timeA time.Time, idA string, timeB time.Time, idB string) bool {
+	if timeA.Equal(timeB) {
+		return idA > idB
+	}
+	return timeA.After(timeB)
+}
+
+func paginateMemory[T any](encryptor encryption.Encryptor, criteria data.Criteria, items []T) (data.Page[T],
+	error) {
+	pageSize := newPageSize(criteria)
+	offset := min(data.ConvertOffsetSafe(criteria.PageToken, encryptor), len(items))
+	end := min(offset+pageSize, len(items))
+	page := data.Page[T]{
+		TotalItems: len(items),
+		Items:      items[offset:end],
+	}
+	var err error
+	page.PreviousPageToken, page.NextPageToken, err = newPageTokens(encryptor, offset, pageSize, end-offset,
+		len(items))
+	if err != nil {
+		return data.Page[T]{}, err
+	}
+	return page, nil
+}
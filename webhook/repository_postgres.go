@@ -0,0 +1,381 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hadroncorp/geck/data"
+	gecksql "github.com/hadroncorp/geck/data/sql"
+	"github.com/hadroncorp/geck/security/encryption"
+	"github.com/hadroncorp/geck/systemerror"
+)
+
+const (
+	postgresAuditableColumns    = "create_time,create_by,last_update_time,last_update_by,is_active,version"
+	postgresSubscriptionColumns = "subscription_id,url,secret,event_types," + postgresAuditableColumns
+	postgresDeliveryColumns     = "delivery_id,subscription_id,event_id,event_type,payload,status,attempts," +
+		"next_attempt_time,last_status_code,last_error," + postgresAuditableColumns
+)
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func isDuplicateKeyError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "ERROR: duplicate key")
+}
+
+// SubscriptionRepositoryPostgres is the SubscriptionRepository implementation using a PostgreSQL table with the
+// following structure:
+//
+//	CREATE TABLE webhook_subscriptions (
+//		subscription_id  VARCHAR(128) PRIMARY KEY,
+//		url              TEXT NOT NULL,
+//		secret           BYTEA NOT NULL,
+//		event_types      JSONB,
+//		create_time      TIMESTAMPTZ NOT NULL,
+//		create_by        VARCHAR(256) NOT NULL,
+//		last_update_time TIMESTAMPTZ NOT NULL,
+//		last_update_by   VARCHAR(256) NOT NULL,
+//		is_active        BOOLEAN NOT NULL,
+//		version          BIGINT NOT NULL
+//	);
+//
+// Signing secrets are stored encrypted using Encryptor.
+//
+// Table name is set by Config.SubscriptionTableName.
+type SubscriptionRepositoryPostgres struct {
+	Config    Config
+	Client    gecksql.Client
+	Encryptor encryption.Encryptor
+}
+
+var _ SubscriptionRepository = SubscriptionRepositoryPostgres{}
+
+// NewSubscriptionRepositoryPostgres allocates a new SubscriptionRepositoryPostgres instance.
+func NewSubscriptionRepositoryPostgres(cfg Config, client gecksql.Client,
+	encryptor encryption.Encryptor) SubscriptionRepositoryPostgres {
+	return SubscriptionRepositoryPostgres{
+		Config:    cfg,
+		Client:    client,
+		Encryptor: encryptor,
+	}
+}
+
+func (r SubscriptionRepositoryPostgres) Save(ctx context.Context, entity Subscription) error {
+	eventTypes, err := json.Marshal(entity.EventTypes)
+	if err != nil {
+		return err
+	}
+	secret, err := r.Encryptor.Encrypt(entity.Secret)
+	if err != nil {
+		return err
+	}
+	if entity.Version == 0 {
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)",
+			r.Config.SubscriptionTableName, postgresSubscriptionColumns)
+		_, err = r.Client.ExecContext(ctx, stmt, entity.ID, entity.URL, secret, eventTypes,
+			entity.CreateTime, entity.CreateBy, entity.LastUpdateTime, entity.LastUpdateBy, entity.IsActive,
+			entity.Version)
+		if isDuplicateKeyError(err) {
+			return systemerror.NewResourceAlreadyExists[Subscription](entity.ID)
+		}
+		return err
+	}
+	stmt := fmt.Sprintf(`UPDATE %s SET url=$2,secret=$3,event_types=$4,last_update_time=$5,last_update_by=$6,
+is_active=$7,version=$8 WHERE subscription_id=$1`, r.Config.SubscriptionTableName)
+	_, err = r.Client.ExecContext(ctx, stmt, entity.ID, entity.URL, secret, eventTypes,
+		entity.LastUpdateTime, entity.LastUpdateBy, entity.IsActive, entity.Version)
+	return err
+}
+
+func (r SubscriptionRepositoryPostgres) SaveMany(ctx context.Context, entities []Subscription) error {
+	errs := make([]error, 0, len(entities))
+	for _, entity := range entities {
+		if err := r.Save(ctx, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r SubscriptionRepositoryPostgres) Remove(ctx context.Context, entity Subscription) error {
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE subscription_id=$1", r.Config.SubscriptionTableName)
+	_, err := r.Client.ExecContext(ctx, stmt, entity.ID)
+	return err
+}
+
+func (r SubscriptionRepositoryPostgres) FindByKey(ctx context.Context, key string) (*Subscription, error) {
+	stmt := fmt.Sprintf("SELECT %s FROM %s WHERE subscription_id=$1", postgresSubscriptionColumns,
+		r.Config.SubscriptionTableName)
+	sub, err := r.scan(r.Client.QueryRowContext(ctx, stmt, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r SubscriptionRepositoryPostgres) FindAll(ctx context.Context,
+	criteria data.Criteria) (data.Page[Subscription], error) {
+	if _, err := parseCriteriaFilters(criteria); err != nil {
+		return data.Page[Subscription]{}, err
+	}
+	return findAllPostgres(ctx, r.Client, r.Encryptor, criteria, r.Config.SubscriptionTableName,
+		postgresSubscriptionColumns, "subscription_id", "TRUE", nil, r.scan)
+}
+
+func (r SubscriptionRepositoryPostgres) FindByEventType(ctx context.Context,
+	eventType string) ([]Subscription, error) {
+	// event type patterns are matched in-process, subscriptions are expected to be few
+	stmt := fmt.Sprintf("SELECT %s FROM %s WHERE is_active", postgresSubscriptionColumns,
+		r.Config.SubscriptionTableName)
+	rows, err := r.Client.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]Subscription, 0)
+	for rows.Next() {
+		sub, errScan := r.scan(rows)
+		if errScan != nil {
+			return nil, errScan
+		} else if sub.Matches(eventType) {
+			out = append(out, sub)
+		}
+	}
+	return out, rows.Err()
+}
+
+// scan reads a Subscription row, decrypting its signing secret.
+func (r SubscriptionRepositoryPostgres) scan(row rowScanner) (Subscription, error) {
+	sub := Subscription{}
+	var secret, eventTypes []byte
+	err := row.Scan(&sub.ID, &sub.URL, &secret, &eventTypes, &sub.CreateTime, &sub.CreateBy,
+		&sub.LastUpdateTime, &sub.LastUpdateBy, &sub.IsActive, &sub.Version)
+	if err != nil {
+		return Subscription{}, err
+	}
+	plainSecret, err := r.Encryptor.Decrypt(secret)
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.Secret = string(plainSecret)
+	if len(eventTypes) > 0 {
+		if err = json.Unmarshal(eventTypes, &sub.EventTypes); err != nil {
+			return Subscription{}, err
+		}
+	}
+	return sub, nil
+}
+
+// DeliveryRepositoryPostgres is the DeliveryRepository implementation using a PostgreSQL table with the following
+// structure:
+//
+//	CREATE TABLE webhook_deliveries (
+//		delivery_id       VARCHAR(128) PRIMARY KEY,
+//		subscription_id   VARCHAR(128) NOT NULL,
+//		event_id          VARCHAR(128) NOT NULL,
+//		event_type        VARCHAR(256) NOT NULL,
+//		payload           JSONB NOT NULL,
+//		status            VARCHAR(32) NOT NULL,
+//		attempts          INT NOT NULL,
+//		next_attempt_time TIMESTAMPTZ NOT NULL,
+//		last_status_code  INT NOT NULL,
+//		last_error        TEXT NOT NULL,
+//		create_time       TIMESTAMPTZ NOT NULL,
+//		create_by         VARCHAR(256) NOT NULL,
+//		last_update_time  TIMESTAMPTZ NOT NULL,
+//		last_update_by    VARCHAR(256) NOT NULL,
+//		is_active         BOOLEAN NOT NULL,
+//		version           BIGINT NOT NULL
+//	);
+//	CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_time) WHERE status = 'PENDING';
+//
+// Table name is set by Config.DeliveryTableName.
+type DeliveryRepositoryPostgres struct {
+	Config    Config
+	Client    gecksql.Client
+	Encryptor encryption.Encryptor
+}
+
+var _ DeliveryRepository = DeliveryRepositoryPostgres{}
+
+// NewDeliveryRepositoryPostgres allocates a new DeliveryRepositoryPostgres instance.
+func NewDeliveryRepositoryPostgres(cfg Config, client gecksql.Client,
+	encryptor encryption.Encryptor) DeliveryRepositoryPostgres {
+	return DeliveryRepositoryPostgres{
+		Config:    cfg,
+		Client:    client,
+		Encryptor: encryptor,
+	}
+}
+
+func (r DeliveryRepositoryPostgres) Save(ctx context.Context, entity Delivery) error {
+	if entity.Version == 0 {
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)",
+			r.Config.DeliveryTableName, postgresDeliveryColumns)
+		_, err := r.Client.ExecContext(ctx, stmt, entity.ID, entity.SubscriptionID, entity.EventID,
+			entity.EventType, []byte(entity.Payload), entity.Status, entity.Attempts, entity.NextAttemptTime,
+			entity.LastStatusCode, entity.LastError, entity.CreateTime, entity.CreateBy, entity.LastUpdateTime,
+			entity.LastUpdateBy, entity.IsActive, entity.Version)
+		if isDuplicateKeyError(err) {
+			return systemerror.NewResourceAlreadyExists[Delivery](entity.ID)
+		}
+		return err
+	}
+
+	stmt := fmt.Sprintf(`UPDATE %s SET status=$2,attempts=$3,next_attempt_time=$4,last_status_code=$5,
+last_error=$6,last_update_time=$7,last_update_by=$8,is_active=$9,version=$10 WHERE delivery_id=$1 AND version=$11`,
+		r.Config.DeliveryTableName)
+	res, err := r.Client.ExecContext(ctx, stmt, entity.ID, entity.Status, entity.Attempts, entity.NextAttemptTime,
+		entity.LastStatusCode, entity.LastError, entity.LastUpdateTime, entity.LastUpdateBy, entity.IsActive,
+		entity.Version, entity.Version-1)
+	if err != nil {
+		return err
+	}
+	if affected, errRows := res.RowsAffected(); errRows != nil {
+		return errRows
+	} else if affected > 0 {
+		return nil
+	}
+
+	var currentVersion int64
+	stmt = fmt.Sprintf("SELECT version FROM %s WHERE delivery_id=$1", r.Config.DeliveryTableName)
+	err = r.Client.QueryRowContext(ctx, stmt, entity.ID).Scan(&currentVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return systemerror.NewResourceNotFound[Delivery](entity.ID)
+	} else if err != nil {
+		return err
+	}
+	return systemerror.NewVersionMismatch(strconv.FormatInt(entity.Version-1, 10),
+		strconv.FormatInt(currentVersion, 10))
+}
+
+func (r DeliveryRepositoryPostgres) SaveMany(ctx context.Context, entities []Delivery) error {
+	errs := make([]error, 0, len(entities))
+	for _, entity := range entities {
+		if err := r.Save(ctx, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r DeliveryRepositoryPostgres) Remove(ctx context.Context, entity Delivery) error {
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE delivery_id=$1", r.Config.DeliveryTableName)
+	_, err := r.Client.ExecContext(ctx, stmt, entity.ID)
+	return err
+}
+
+func (r DeliveryRepositoryPostgres) FindByKey(ctx context.Context, key string) (*Delivery, error) {
+	stmt := fmt.Sprintf("SELECT %s FROM %s WHERE delivery_id=$1", postgresDeliveryColumns,
+		r.Config.DeliveryTableName)
+	delivery, err := scanDelivery(r.Client.QueryRowContext(ctx, stmt, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r DeliveryRepositoryPostgres) FindAll(ctx context.Context, criteria data.Criteria) (data.Page[Delivery],
+	error) {
+	filters, err := parseCriteriaFilters(criteria, FieldSubscriptionID, FieldEventType, FieldStatus)
+	if err != nil {
+		return data.Page[Delivery]{}, err
+	}
+	where := "TRUE"
+	args := make([]any, 0, len(filters))
+	// field names are trusted, only accepted fields are parsed
+	for _, field := range []string{FieldSubscriptionID, FieldEventType, FieldStatus} {
+		if value, ok := filters[field]; ok {
+			args = append(args, value)
+			where += fmt.Sprintf(" AND %s=$%d", field, len(args))
+		}
+	}
+	return findAllPostgres(ctx, r.Client, r.Encryptor, criteria, r.Config.DeliveryTableName,
+		postgresDeliveryColumns, "delivery_id", where, args, scanDelivery)
+}
+
+func (r DeliveryRepositoryPostgres) FindDue(ctx context.Context, at time.Time, limit int) ([]Delivery, error) {
+	stmt := fmt.Sprintf(`SELECT %s FROM %s WHERE status=$1 AND next_attempt_time<=$2
+ORDER BY next_attempt_time LIMIT %d`, postgresDeliveryColumns, r.Config.DeliveryTableName, limit)
+	rows, err := r.Client.QueryContext(ctx, stmt, DeliveryStatusPending, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]Delivery, 0, limit)
+	for rows.Next() {
+		delivery, errScan := scanDelivery(rows)
+		if errScan != nil {
+			return nil, errScan
+		}
+		out = append(out, delivery)
+	}
+	return out, rows.Err()
+}
+
+func scanDelivery(row rowScanner) (Delivery, error) {
+	delivery := Delivery{}
+	var payload []byte
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptTime, &delivery.LastStatusCode,
+		&delivery.LastError, &delivery.CreateTime, &delivery.CreateBy, &delivery.LastUpdateTime,
+		&delivery.LastUpdateBy, &delivery.IsActive, &delivery.Version)
+	if err != nil {
+		return Delivery{}, err
+	}
+	delivery.Payload = payload
+	return delivery, nil
+}
+
+// findAllPostgres retrieves a page of rows matching where, ordered by creation time (newest first).
+func findAllPostgres[T any](ctx context.Context, client gecksql.Client, encryptor encryption.Encryptor,
+	criteria data.Criteria, table, columns, keyColumn, where string, args []any,
+	scan func(row rowScanner) (T, error)) (data.Page[T], error) {
+	var total int
+	stmt := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, where)
+	if err := client.QueryRowContext(ctx, stmt, args...).Scan(&total); err != nil {
+		return data.Page[T]{}, err
+	}
+
+	pageSize := newPageSize(criteria)
+	offset := data.ConvertOffsetSafe(criteria.PageToken, encryptor)
+	stmt = fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY create_time DESC, %s DESC LIMIT %d OFFSET %d",
+		columns, table, where, keyColumn, pageSize, offset)
+	rows, err := client.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return data.Page[T]{}, err
+	}
+	defer rows.Close()
+	page := data.Page[T]{
+		TotalItems: total,
+		Items:      make([]T, 0, pageSize),
+	}
+	for rows.Next() {
+		item, errScan := scan(rows)
+		if errScan != nil {
+			return data.Page[T]{}, errScan
+		}
+		page.Items = append(page.Items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return data.Page[T]{}, err
+	}
+	page.PreviousPageToken, page.NextPageToken, err = newPageTokens(encryptor, offset, pageSize, len(page.Items),
+		total)
+	if err != nil {
+		return data.Page[T]{}, err
+	}
+	return page, nil
+}
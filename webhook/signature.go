@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delivery headers, following the Standard Webhooks specification.
+const (
+	// HeaderID unique identifier of the delivery. Receivers use it to discard duplicated deliveries.
+	HeaderID = "Webhook-Id"
+	// HeaderTimestamp delivery attempt time, as Unix seconds.
+	HeaderTimestamp = "Webhook-Timestamp"
+	// HeaderSignature space-separated list of signatures using the nomenclature: VERSION,SIGNATURE
+	// (e.g. v1,K5oZfzN95Z9UVu1EsfQmfVNQhnkZ2pj9o9NDN/H/pI4=).
+	HeaderSignature = "Webhook-Signature"
+	// HeaderEventType the type of the delivered event (e.g. task.created).
+	HeaderEventType = "Webhook-Event-Type"
+
+	signatureVersion = "v1"
+	secretPrefix     = "whsec_"
+)
+
+// NewSecret generates a random signing secret.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// Sign computes the HMAC-SHA256 signature of a delivery, returning it using HeaderSignature nomenclature. The signed
+// content is: ID.TIMESTAMP.PAYLOAD.
+//
+// Following the Standard Webhooks specification, the signing key is the base64-decoded portion of secret after the
+// whsec_ prefix (see NewSecret). Returns ErrInvalidSecret if secret does not follow that format.
+func Sign(secret, id string, timestamp time.Time, payload []byte) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, secretPrefix))
+	if err != nil || len(key) == 0 {
+		return "", ErrInvalidSecret
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(payload)
+	return signatureVersion + "," + base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verifier verifies signatures of received deliveries.
+type Verifier struct {
+	// Tolerance maximum age (and clock skew) of delivery timestamps. Timestamps are not checked if zero.
+	Tolerance time.Duration
+}
+
+// NewVerifier allocates a new Verifier instance.
+func NewVerifier(cfg Config) Verifier {
+	return Verifier{
+		Tolerance: cfg.SignatureTolerance,
+	}
+}
+
+// Verify checks the delivery signature in header against payload using any of secrets (more than one secret might
+// be valid while rotating them). Returns ErrStaleTimestamp if the delivery timestamp is out of tolerance,
+// ErrInvalidSecret if any of secrets is malformed (see Sign), and ErrInvalidSignature if no signature matches.
+func (v Verifier) Verify(header http.Header, payload []byte, secrets ...string) error {
+	id := header.Get(HeaderID)
+	rawTimestamp := header.Get(HeaderTimestamp)
+	rawSignatures := header.Get(HeaderSignature)
+	if id == "" || rawTimestamp == "" || rawSignatures == "" {
+		return ErrInvalidSignature
+	}
+	unixTimestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	timestamp := time.Unix(unixTimestamp, 0)
+	if age := time.Since(timestamp); v.Tolerance > 0 && (age > v.Tolerance || age < -v.Tolerance) {
+		return ErrStaleTimestamp
+	}
+
+	signatures := strings.Fields(rawSignatures)
+	for _, secret := range secrets {
+		expected, errSign := Sign(secret, id, timestamp, payload)
+		if errSign != nil {
+			return errSign
+		}
+		for _, signature := range signatures {
+			if hmac.Equal([]byte(expected), []byte(signature)) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,47 @@
+package webhook_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hadroncorp/geck/webhook"
+)
+
+func TestSign(t *testing.T) {
+	// reference vector of the Standard Webhooks specification
+	signature, err := webhook.Sign("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+		time.Unix(1614265330, 0), []byte(`{"test": 2432232314}`))
+	require.NoError(t, err)
+	assert.Equal(t, "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=", signature)
+
+	_, err = webhook.Sign("whsec_not base64!", "msg_1", time.Now(), nil)
+	assert.ErrorIs(t, err, webhook.ErrInvalidSecret)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	secret, err := webhook.NewSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	payload := []byte(`{"type":"task.created"}`)
+	signature, err := webhook.Sign(secret, "msg_1", now, payload)
+	require.NoError(t, err)
+	header := http.Header{}
+	header.Set(webhook.HeaderID, "msg_1")
+	header.Set(webhook.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	header.Set(webhook.HeaderSignature, "v1,b3RoZXI= "+signature)
+
+	verifier := webhook.Verifier{Tolerance: time.Minute}
+	otherSecret, err := webhook.NewSecret()
+	require.NoError(t, err)
+	assert.NoError(t, verifier.Verify(header, payload, otherSecret, secret))
+	assert.ErrorIs(t, verifier.Verify(header, payload, otherSecret), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, verifier.Verify(header, []byte(`{}`), secret), webhook.ErrInvalidSignature)
+
+	header.Set(webhook.HeaderTimestamp, strconv.FormatInt(now.Add(-time.Hour).Unix(), 10))
+	assert.ErrorIs(t, verifier.Verify(header, payload, secret), webhook.ErrStaleTimestamp)
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hadroncorp/geck/data/persistence"
+)
+
+// Subscription is an endpoint notified of events.
+type Subscription struct {
+	persistence.Auditable
+	ID string
+	// URL endpoint deliveries are sent to.
+	URL string
+	// Secret key deliveries are signed with (see Sign).
+	Secret string
+	// EventTypes types of events delivered. Entries might be exact types (e.g. task.created), prefixes
+	// (e.g. task.*) or * for every type. Every type is delivered if empty.
+	EventTypes []string
+}
+
+var _ persistence.Persistable = Subscription{}
+
+// Matches indicates if events of eventType are delivered to the subscription.
+func (s Subscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, filter := range s.EventTypes {
+		if filter == "*" || filter == eventType {
+			return true
+		} else if prefix, ok := strings.CutSuffix(filter, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a notification of a resource change.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// DeliveryStatus the status of a Delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusPending the delivery is waiting for its next attempt.
+	DeliveryStatusPending DeliveryStatus = "PENDING"
+	// DeliveryStatusSucceeded the endpoint acknowledged the delivery (2xx response).
+	DeliveryStatusSucceeded DeliveryStatus = "SUCCEEDED"
+	// DeliveryStatusDeadLettered every attempt failed. Use Dispatcher.Replay to deliver it again.
+	DeliveryStatusDeadLettered DeliveryStatus = "DEAD_LETTERED"
+)
+
+// Delivery is the delivery of an Event to a Subscription.
+type Delivery struct {
+	persistence.Auditable
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	// Payload the JSON-encoded Event sent as request body.
+	Payload json.RawMessage
+	Status  DeliveryStatus
+	// Attempts number of attempts performed.
+	Attempts int
+	// NextAttemptTime time the next attempt is due.
+	NextAttemptTime time.Time
+	// LastStatusCode response status code of the last attempt. Zero if no response was received.
+	LastStatusCode int
+	// LastError failure description of the last attempt.
+	LastError string
+}
+
+var _ persistence.Persistable = Delivery{}
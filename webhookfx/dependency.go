@@ -0,0 +1,46 @@
+package webhookfx
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/hadroncorp/geck/observability/loggingfx"
+	"github.com/hadroncorp/geck/webhook"
+)
+
+var WebhookMemoryModule = fx.Module("webhook_memory",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("webhook"),
+	),
+	fx.Provide(
+		webhook.NewConfig,
+		fx.Annotate(
+			webhook.NewSubscriptionRepositoryMemory,
+			fx.As(new(webhook.SubscriptionRepository)),
+		),
+		fx.Annotate(
+			webhook.NewDeliveryRepositoryMemory,
+			fx.As(new(webhook.DeliveryRepository)),
+		),
+		webhook.NewDispatcher,
+		webhook.NewVerifier,
+	),
+)
+
+var WebhookPostgresModule = fx.Module("webhook_postgres",
+	fx.Decorate(
+		loggingfx.DecorateLoggerWithModule("webhook"),
+	),
+	fx.Provide(
+		webhook.NewConfig,
+		fx.Annotate(
+			webhook.NewSubscriptionRepositoryPostgres,
+			fx.As(new(webhook.SubscriptionRepository)),
+		),
+		fx.Annotate(
+			webhook.NewDeliveryRepositoryPostgres,
+			fx.As(new(webhook.DeliveryRepository)),
+		),
+		webhook.NewDispatcher,
+		webhook.NewVerifier,
+	),
+)